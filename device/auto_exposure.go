@@ -0,0 +1,122 @@
+package device
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// AutoExposureTuning holds the parameters of the software auto exposure control loop
+// (see WithSoftwareAutoExposure). The loop adjusts the exposure proportionally to the
+// difference between the target and the measured mean luma. Once the exposure reaches
+// its limits, the gain control (if supported) is adjusted instead.
+type AutoExposureTuning struct {
+	// ExposureGain is the proportional factor applied to the exposure range
+	ExposureGain float64
+	// GainGain is the proportional factor applied to the gain range
+	GainGain float64
+	// Tolerance is the luma distance from the target that is left uncorrected
+	Tolerance uint8
+	// Interval is the minimum time between two control adjustments
+	Interval time.Duration
+}
+
+// DefaultAutoExposureTuning is used when software auto exposure is enabled without tuning
+var DefaultAutoExposureTuning = AutoExposureTuning{
+	ExposureGain: 0.25,
+	GainGain:     0.10,
+	Tolerance:    8,
+	Interval:     200 * time.Millisecond,
+}
+
+// autoExposure implements a simple proportional control loop that computes the mean luma
+// of captured frames and updates the exposure (and gain) controls to reach a target brightness.
+type autoExposure struct {
+	fd       uintptr
+	target   uint8
+	tuning   AutoExposureTuning
+	pixFmt   v4l2.PixFormat
+	exposure v4l2.Control
+	gain     v4l2.Control
+	hasGain  bool
+	last     time.Time
+}
+
+// newAutoExposure queries the exposure and gain controls used by the control loop.
+// The exposure control (CtrlCameraExposureAbsolute) is required, the gain control is optional.
+func newAutoExposure(fd uintptr, target uint8, tuning AutoExposureTuning, pixFmt v4l2.PixFormat) (*autoExposure, error) {
+	exposure, err := v4l2.GetControl(fd, v4l2.CtrlCameraExposureAbsolute)
+	if err != nil {
+		return nil, fmt.Errorf("auto exposure: %w", err)
+	}
+	ae := &autoExposure{fd: fd, target: target, tuning: tuning, pixFmt: pixFmt, exposure: exposure}
+	if gain, err := v4l2.GetControl(fd, v4l2.CtrlGain); err == nil {
+		ae.gain = gain
+		ae.hasGain = true
+	}
+	return ae, nil
+}
+
+// update measures the frame brightness and, if the adjustment interval has elapsed,
+// moves the exposure (or gain) towards the target brightness.
+func (ae *autoExposure) update(frame v4l2.Frame) {
+	if time.Since(ae.last) < ae.tuning.Interval {
+		return
+	}
+	mean, _, ok := v4l2.LumaStats(frame.Data, ae.pixFmt)
+	if !ok {
+		return
+	}
+	ae.last = time.Now()
+
+	diff := int(ae.target) - int(mean)
+	if diff >= -int(ae.tuning.Tolerance) && diff <= int(ae.tuning.Tolerance) {
+		return
+	}
+	errRatio := float64(diff) / 255
+
+	exposure := adjustControl(ae.exposure, errRatio*ae.tuning.ExposureGain)
+	if exposure != ae.exposure.Value {
+		if err := v4l2.SetControlValue(ae.fd, ae.exposure.ID, exposure); err == nil {
+			ae.exposure.Value = exposure
+		}
+		return
+	}
+
+	// exposure is at its limit, use gain if available
+	if !ae.hasGain {
+		return
+	}
+	gain := adjustControl(ae.gain, errRatio*ae.tuning.GainGain)
+	if gain != ae.gain.Value {
+		if err := v4l2.SetControlValue(ae.fd, ae.gain.ID, gain); err == nil {
+			ae.gain.Value = gain
+		}
+	}
+}
+
+// adjustControl returns the control value moved by ratio of the control range,
+// aligned to the control step and clamped to the control limits.
+func adjustControl(ctrl v4l2.Control, ratio float64) v4l2.CtrlValue {
+	delta := int64(ratio * float64(int64(ctrl.Maximum)-int64(ctrl.Minimum)))
+	if delta == 0 {
+		switch {
+		case ratio > 0:
+			delta = 1
+		case ratio < 0:
+			delta = -1
+		}
+	}
+	if ctrl.Step > 1 {
+		delta = delta / int64(ctrl.Step) * int64(ctrl.Step)
+	}
+	val := int64(ctrl.Value) + delta
+	if val < int64(ctrl.Minimum) {
+		val = int64(ctrl.Minimum)
+	}
+	if val > int64(ctrl.Maximum) {
+		val = int64(ctrl.Maximum)
+	}
+	return v4l2.CtrlValue(val)
+}
@@ -0,0 +1,124 @@
+package device
+
+import (
+	"sync"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// Broadcaster fans out the frames received from a source channel (i.e. Device.Frames) to
+// any number of subscribers. A subscriber that falls behind misses frames, instead of
+// slowing down the other subscribers or buffering frames without bound. The source is only
+// read while there is at least one subscriber, other consumers of the source (i.e. GetOutput
+// on the same device) receive the frames otherwise.
+type Broadcaster struct {
+	source <-chan v4l2.Frame
+	mu     sync.Mutex
+	subs   map[chan v4l2.Frame]struct{}
+	wake   chan struct{} // signaled when subscribing
+	quit   chan struct{} // closed to stop an idle broadcaster
+	done   chan struct{}
+	closed bool
+}
+
+// NewBroadcaster starts broadcasting the frames received from source until it is closed,
+// at which point all subscriber channels are closed.
+func NewBroadcaster(source <-chan v4l2.Frame) *Broadcaster {
+	b := &Broadcaster{
+		source: source,
+		subs:   make(map[chan v4l2.Frame]struct{}),
+		wake:   make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer b.closeSubs()
+		for {
+			// wait for a subscriber before reading the source
+			b.mu.Lock()
+			idle := len(b.subs) == 0
+			b.mu.Unlock()
+			if idle {
+				select {
+				case <-b.wake:
+					continue
+				case <-b.quit:
+					return
+				}
+			}
+
+			frame, ok := <-source
+			if !ok {
+				return
+			}
+			b.mu.Lock()
+			for sub := range b.subs {
+				select {
+				case sub <- frame:
+				default: // subscriber is behind, drop the frame
+				}
+			}
+			b.mu.Unlock()
+		}
+	}()
+	return b
+}
+
+// closeSubs closes the subscriber channels once the broadcast is over
+func (b *Broadcaster) closeSubs() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		close(sub)
+	}
+	b.subs = nil
+	b.closed = true
+	close(b.done)
+}
+
+// stop ends the broadcast when it has no subscriber, i.e. when its source is replaced.
+// A broadcast with subscribers ends when its source is closed.
+func (b *Broadcaster) stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	select {
+	case <-b.quit:
+	default:
+		close(b.quit)
+	}
+}
+
+// Subscribe returns a channel, buffering up to size frames, that receives the broadcast frames.
+// The returned function unsubscribes and must be called when the subscriber is done.
+func (b *Broadcaster) Subscribe(size int) (<-chan v4l2.Frame, func()) {
+	sub := make(chan v4l2.Frame, size)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(sub)
+		return sub, func() {}
+	}
+	b.subs[sub] = struct{}{}
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+
+	var once sync.Once
+	return sub, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subs[sub]; ok {
+				delete(b.subs, sub)
+				close(sub)
+			}
+		})
+	}
+}
+
+// Done returns a channel that is closed when the source channel is closed
+func (b *Broadcaster) Done() <-chan struct{} {
+	return b.done
+}
@@ -0,0 +1,74 @@
+package device
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+func TestBroadcaster(t *testing.T) {
+	source := make(chan v4l2.Frame)
+	b := NewBroadcaster(source)
+
+	fast, unsubFast := b.Subscribe(4)
+	defer unsubFast()
+	slow, unsubSlow := b.Subscribe(1)
+	defer unsubSlow()
+
+	for i := uint32(0); i < 3; i++ {
+		source <- v4l2.Frame{Sequence: i}
+	}
+	close(source)
+	<-b.Done()
+
+	var fastCount int
+	for range fast {
+		fastCount++
+	}
+	if fastCount != 3 {
+		t.Errorf("expecting 3 frames for fast subscriber, got %d", fastCount)
+	}
+
+	var slowFrames []v4l2.Frame
+	for frame := range slow {
+		slowFrames = append(slowFrames, frame)
+	}
+	if len(slowFrames) != 1 || slowFrames[0].Sequence != 0 {
+		t.Errorf("expecting slow subscriber to drop frames, got %v", slowFrames)
+	}
+
+	if _, ok := <-func() <-chan v4l2.Frame { ch, _ := b.Subscribe(1); return ch }(); ok {
+		t.Error("expecting closed channel when subscribing after source closed")
+	}
+}
+
+func TestBroadcasterIdle(t *testing.T) {
+	source := make(chan v4l2.Frame, 1)
+	b := NewBroadcaster(source)
+	defer b.stop()
+
+	// without subscribers, frames are left to the other consumers of the source
+	source <- v4l2.Frame{Sequence: 1}
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case frame := <-source:
+		if frame.Sequence != 1 {
+			t.Errorf("unexpected frame %d", frame.Sequence)
+		}
+	default:
+		t.Fatal("expecting the frame to be left in the source without subscribers")
+	}
+
+	frames, unsubscribe := b.Subscribe(1)
+	defer unsubscribe()
+	source <- v4l2.Frame{Sequence: 2}
+	select {
+	case frame := <-frames:
+		if frame.Sequence != 2 {
+			t.Errorf("unexpected frame %d", frame.Sequence)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expecting a frame once subscribed")
+	}
+}
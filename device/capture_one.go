@@ -0,0 +1,107 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+	sys "golang.org/x/sys/unix"
+)
+
+// captureOnePollMillis is the poll timeout between checks of the context in CaptureOne
+const captureOnePollMillis = 100
+
+// CaptureOne captures a single frame synchronously, outside of the streaming loop, for
+// diagnosing driver behavior. It allocates the device buffers, queues only the first one,
+// turns the stream on, waits for the buffer to be dequeued (or ctx to be done), then turns
+// the stream off and releases the buffers. No startup frames are skipped and the data is
+// not decoded. The returned frame carries the complete dequeued buffer information (Raw).
+// It cannot be used while streaming, the device is streaming until it returns, and requires
+// streaming IO (MMAP or DMABUF) with single-planar buffers.
+func (d *Device) CaptureOne(ctx context.Context) (v4l2.Frame, error) {
+	if d.config.ioType != v4l2.IOTypeMMAP && d.config.ioType != v4l2.IOTypeDMABuf {
+		return v4l2.Frame{}, fmt.Errorf("device: %s: capture one: %w", d.path, v4l2.ErrStreamingUnsupported)
+	}
+	if d.isMultiPlanar() {
+		return v4l2.Frame{}, fmt.Errorf("device: %s: capture one: multi-planar buffers: %w", d.path, v4l2.ErrorUnsupportedFeature)
+	}
+
+	// the buffers are allocated as for a stream, which cannot start meanwhile
+	d.streamMu.Lock()
+	defer d.streamMu.Unlock()
+	if d.IsStreaming() {
+		return v4l2.Frame{}, fmt.Errorf("device: %s: capture one: stream started", d.path)
+	}
+	d.mu.Lock()
+	d.streaming = true
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		d.streaming = false
+		d.mu.Unlock()
+	}()
+
+	bufReq, err := v4l2.InitBuffers(d)
+	if err != nil {
+		return v4l2.Frame{}, fmt.Errorf("device: %s: capture one: %w", d.path, err)
+	}
+	d.config.bufSize = bufReq.Count
+	d.requestedBuf = bufReq
+	defer v4l2.ResetBuffers(d)
+
+	if d.config.ioType == v4l2.IOTypeMMAP {
+		if d.buffers, err = v4l2.MapMemoryBuffers(d); err != nil {
+			return v4l2.Frame{}, fmt.Errorf("device: %s: capture one: %w", d.path, err)
+		}
+		defer func() {
+			v4l2.UnmapMemoryBuffers(d)
+			d.buffers = nil
+		}()
+	}
+
+	if err := d.queueBuffer(0); err != nil {
+		return v4l2.Frame{}, fmt.Errorf("device: %s: capture one: %w", d.path, err)
+	}
+	if err := v4l2.StreamOn(d); err != nil {
+		return v4l2.Frame{}, fmt.Errorf("device: %s: capture one: %w", d.path, err)
+	}
+	defer v4l2.StreamOff(d)
+
+	fds := []sys.PollFd{{Fd: int32(d.fd), Events: sys.POLLIN}}
+	for {
+		if err := ctx.Err(); err != nil {
+			return v4l2.Frame{}, fmt.Errorf("device: %s: capture one: %w", d.path, err)
+		}
+		n, err := sys.Poll(fds, captureOnePollMillis)
+		if err != nil {
+			if errors.Is(err, sys.EINTR) {
+				continue
+			}
+			return v4l2.Frame{}, fmt.Errorf("device: %s: capture one: poll: %w", d.path, err)
+		}
+		if n == 0 {
+			continue
+		}
+
+		buff, err := v4l2.DequeueBuffer(d.fd, d.config.ioType, d.bufType)
+		if err != nil {
+			if errors.Is(err, sys.EAGAIN) {
+				continue
+			}
+			return v4l2.Frame{}, fmt.Errorf("device: %s: capture one: %w", d.path, err)
+		}
+
+		data := []byte{}
+		if buff.Flags&v4l2.BufFlagMapped != 0 && buff.Flags&v4l2.BufFlagError == 0 {
+			data = make([]byte, buff.BytesUsed)
+			copy(data, d.buffers[buff.Index][:buff.BytesUsed])
+		}
+		frame := v4l2.NewFrame(buff, data)
+		if pixFmt, err := d.currentPixFormat(); err == nil {
+			frame = frame.WithFormat(pixFmt)
+		}
+		frame.Raw = buff
+		return frame, nil
+	}
+}
@@ -0,0 +1,141 @@
+package device
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// ctrlTypeNames are the control type names used by v4l2-ctl
+var ctrlTypeNames = map[v4l2.CtrlType]string{
+	v4l2.CtrlTypeInt:         "int",
+	v4l2.CtrlTypeBool:        "bool",
+	v4l2.CtrlTypeMenu:        "menu",
+	v4l2.CtrlTypeButton:      "button",
+	v4l2.CtrlTypeInt64:       "int64",
+	v4l2.CtrlTypeString:      "str",
+	v4l2.CtrlTypeBitMask:     "bitmask",
+	v4l2.CtrlTypeIntegerMenu: "intmenu",
+}
+
+// ctrlFlagNames are the control flag names used by v4l2-ctl, in reporting order
+var ctrlFlagNames = []struct {
+	flag v4l2.CtrlFlag
+	name string
+}{
+	{v4l2.CtrlFlagDisabled, "disabled"},
+	{v4l2.CtrlFlagGrabbed, "grabbed"},
+	{v4l2.CtrlFlagReadOnly, "read-only"},
+	{v4l2.CtrlFlagUpdate, "update"},
+	{v4l2.CtrlFlagInactive, "inactive"},
+	{v4l2.CtrlFlagSlider, "slider"},
+	{v4l2.CtrlFlagWriteOnly, "write-only"},
+	{v4l2.CtrlFlagVolatile, "volatile"},
+	{v4l2.CtrlFlagHasPayload, "has-payload"},
+	{v4l2.CtrlFlagExecuteOnWrite, "execute-on-write"},
+	{v4l2.CtrlFlagModifyLayout, "modify-layout"},
+	{v4l2.CtrlFlagDynamicArray, "dynamic-array"},
+}
+
+// ControlsReport returns the device controls and their current values formatted like
+// the output of `v4l2-ctl -L`, grouped by control class, for diagnostics and bug reports:
+//
+//	User Controls
+//
+//	                     brightness 0x00980900 (int)    : min=-64 max=64 step=1 default=0 value=0
+//
+// Menu items are listed below their control. Values that cannot be read are reported as "?".
+func (d *Device) ControlsReport() (string, error) {
+	ctrls, err := v4l2.QueryAllControls(d.fd)
+	if err != nil && len(ctrls) == 0 {
+		return "", fmt.Errorf("device: %s: controls report: %w", d.path, err)
+	}
+
+	var report strings.Builder
+	for _, ctrl := range ctrls {
+		if ctrl.Flags()&v4l2.CtrlFlagDisabled != 0 {
+			continue
+		}
+		if ctrl.Type == v4l2.CtrlTypeClass {
+			fmt.Fprintf(&report, "\n%s\n\n", ctrl.Name)
+			continue
+		}
+
+		typeName, ok := ctrlTypeNames[ctrl.Type]
+		if !ok {
+			typeName = fmt.Sprintf("type %d", ctrl.Type)
+		}
+		fmt.Fprintf(&report, "%31s %#08x %-10s: ", controlReportName(ctrl.Name), ctrl.ID, "("+typeName+")")
+
+		switch ctrl.Type {
+		case v4l2.CtrlTypeButton:
+			fmt.Fprint(&report, "flags=")
+		case v4l2.CtrlTypeBool, v4l2.CtrlTypeMenu, v4l2.CtrlTypeIntegerMenu:
+			fmt.Fprintf(&report, "min=%d max=%d default=%d value=%s", ctrl.Minimum, ctrl.Maximum, ctrl.Default, d.controlReportValue(ctrl))
+		default:
+			fmt.Fprintf(&report, "min=%d max=%d step=%d default=%d value=%s", ctrl.Minimum, ctrl.Maximum, ctrl.Step, ctrl.Default, d.controlReportValue(ctrl))
+		}
+		if flags := controlReportFlags(ctrl.Flags()); flags != "" {
+			if ctrl.Type != v4l2.CtrlTypeButton {
+				fmt.Fprint(&report, " flags=")
+			}
+			fmt.Fprint(&report, flags)
+		}
+		fmt.Fprintln(&report)
+
+		if ctrl.IsMenu() {
+			items, _ := ctrl.GetMenuItems()
+			for _, item := range items {
+				fmt.Fprintf(&report, "\t\t\t\t%d: %s\n", item.Index, item.Name)
+			}
+		}
+	}
+	return report.String(), nil
+}
+
+// controlReportName formats the control name like v4l2-ctl (lower case, underscores)
+func controlReportName(name string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if underscore && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			underscore = false
+			b.WriteRune(r)
+			continue
+		}
+		underscore = true
+	}
+	return b.String()
+}
+
+func (d *Device) controlReportValue(ctrl v4l2.Control) string {
+	if ctrl.Flags()&v4l2.CtrlFlagWriteOnly != 0 {
+		return "?"
+	}
+	if ctrl.Type == v4l2.CtrlTypeInt64 {
+		val, err := v4l2.GetExtControlValue64(d.fd, ctrl.ID)
+		if err != nil {
+			return "?"
+		}
+		return fmt.Sprintf("%d", val)
+	}
+	val, err := v4l2.GetControlValue(d.fd, ctrl.ID)
+	if err != nil {
+		return "?"
+	}
+	return fmt.Sprintf("%d", val)
+}
+
+func controlReportFlags(flags v4l2.CtrlFlag) string {
+	var names []string
+	for _, f := range ctrlFlagNames {
+		if flags&f.flag != 0 {
+			names = append(names, f.name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -4,8 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"image"
+	"log"
+	"math"
 	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
 	sys "syscall"
+	"time"
 
 	"github.com/vladimirvivien/go4vl/v4l2"
 )
@@ -17,22 +24,50 @@ type Device struct {
 	config       config
 	bufType      v4l2.BufType
 	cap          v4l2.Capability
-	cropCap      v4l2.CropCapability
 	buffers      [][]byte
 	requestedBuf v4l2.RequestBuffers
 	streaming    bool
 	output       chan []byte
+	frames       chan v4l2.Frame
+	autoExposure *autoExposure
+	controls     map[v4l2.CtrlID]v4l2.CtrlValue // values set, when persisted
+	health       *healthMonitor
+	readOnly     bool
+
+	mu              sync.Mutex
+	streamMu        sync.Mutex // serializes starting and stopping the stream
+	outputForwarded bool
+	images          chan image.Image
+	imagesForwarded bool
+	err             error
+	stats           Stats
+	planes          [][][]byte // mapped planes of multi-planar buffers
+	numPlanes       int
+	events          chan v4l2.Event
+	controlEvents   chan v4l2.ControlEvent
+	eventsDone      chan struct{}
+	eventsExited    chan struct{} // closed when the event loop exits
+	eventsStarted   bool
+	subscriptions   []eventSubscription // subscribed again on Reopen
+
+	bufOutput          chan v4l2.Frame
+	bufOutputForwarded bool
+	exportedFds        []int // dma-bufs exported when streaming (see WithDMABufExport)
+
+	cancel        context.CancelFunc // cancels the stream loop
+	loopDone      chan struct{}      // closed when the stream loop exits
+	pauseRequests chan pauseRequest
+	paused        bool
+	outputNext    uint32 // next output buffer handed out by Write, until all are queued
+
+	errs     chan error // streaming errors (see Errors)
+	errsOpen bool
 }
 
 // Open creates opens the underlying device at specified path for streaming.
 // It returns a *Device or an error if unable to open device.
 func Open(path string, options ...Option) (*Device, error) {
-	fd, err := v4l2.OpenDevice(path, sys.O_RDWR|sys.O_NONBLOCK, 0)
-	if err != nil {
-		return nil, fmt.Errorf("device open: %w", err)
-	}
-
-	dev := &Device{path: path, config: config{}, fd: fd}
+	dev := &Device{path: path, config: config{}}
 	// apply options
 	if len(options) > 0 {
 		for _, o := range options {
@@ -40,6 +75,12 @@ func Open(path string, options ...Option) (*Device, error) {
 		}
 	}
 
+	fd, readOnly, err := dev.openFile()
+	if err != nil {
+		return nil, fmt.Errorf("device open: %w", err)
+	}
+	dev.fd, dev.readOnly = fd, readOnly
+
 	// get capability
 	cap, err := v4l2.GetCapability(dev.fd)
 	if err != nil {
@@ -50,23 +91,76 @@ func Open(path string, options ...Option) (*Device, error) {
 	}
 	dev.cap = cap
 
+	// the device is closed when failing past this point
+	fail := func(err error) (*Device, error) {
+		v4l2.CloseDevice(dev.fd)
+		return nil, err
+	}
+
 	// set preferred device buffer size
 	if dev.config.bufSize == 0 {
 		dev.config.bufSize = 2
 	}
 
-	// only supports streaming IO model right now
-	if !dev.cap.IsStreamingSupported() {
-		return nil, fmt.Errorf("device open: device does not support streamingIO")
+	// set IO type, defaults to memory mapped streaming IO, or to the read/write IO
+	// method for devices that do not support streaming
+	switch dev.config.ioType {
+	case 0:
+		switch {
+		case dev.cap.IsStreamingSupported():
+			dev.config.ioType = v4l2.IOTypeMMAP
+		case dev.cap.IsReadWriteSupported():
+			dev.config.ioType = v4l2.IOTypeReadWrite
+		default:
+			return fail(fmt.Errorf("device open: %s: device supports neither streaming nor read/write IO: %w", path, v4l2.ErrorUnsupportedFeature))
+		}
+	case v4l2.IOTypeMMAP:
+		if !dev.cap.IsStreamingSupported() {
+			return fail(fmt.Errorf("device open: device does not support streamingIO"))
+		}
+	case v4l2.IOTypeDMABuf:
+		if !dev.cap.IsStreamingSupported() {
+			return fail(fmt.Errorf("device open: device does not support streamingIO"))
+		}
+		if len(dev.config.dmabufFds) == 0 {
+			return fail(fmt.Errorf("device open: %s: DMABUF IO requires imported buffers (see WithDMABufImport)", path))
+		}
+		dev.config.bufSize = uint32(len(dev.config.dmabufFds))
+	case v4l2.IOTypeUserPtr:
+		if !dev.cap.IsStreamingSupported() {
+			return fail(fmt.Errorf("device open: device does not support streamingIO"))
+		}
+		if len(dev.config.userBufs) == 0 {
+			return fail(fmt.Errorf("device open: %s: USERPTR IO requires user buffers (see WithUserBuffers)", path))
+		}
+		dev.config.bufSize = uint32(len(dev.config.userBufs))
+	case v4l2.IOTypeReadWrite:
+		if !dev.cap.IsReadWriteSupported() {
+			return fail(fmt.Errorf("device open: device does not support read/write IO"))
+		}
+	default:
+		return fail(fmt.Errorf("device open: %s: IO type: %w", path, v4l2.ErrorUnsupportedFeature))
 	}
 
 	switch {
+	case dev.config.bufType == v4l2.BufTypeVideoOutput && cap.IsVideoOutputSupported():
+		// output requested from a device also supporting capture (i.e. v4l2loopback)
+		dev.bufType = v4l2.BufTypeVideoOutput
 	case cap.IsVideoCaptureSupported():
 		// setup capture parameters and chan for captured data
 		dev.bufType = v4l2.BufTypeVideoCapture
 		dev.output = make(chan []byte, dev.config.bufSize)
+		dev.bufOutput = make(chan v4l2.Frame, dev.config.bufSize)
+		dev.images = make(chan image.Image, dev.config.bufSize)
+		dev.frames = make(chan v4l2.Frame, dev.config.bufSize)
 	case cap.IsVideoOutputSupported():
 		dev.bufType = v4l2.BufTypeVideoOutput
+	case cap.IsVideoCaptureMultiplanarSupported():
+		dev.bufType = v4l2.BufTypeVideoCaptureMPlane
+		dev.output = make(chan []byte, dev.config.bufSize)
+		dev.bufOutput = make(chan v4l2.Frame, dev.config.bufSize)
+		dev.images = make(chan image.Image, dev.config.bufSize)
+		dev.frames = make(chan v4l2.Frame, dev.config.bufSize)
 	default:
 		if err := v4l2.CloseDevice(dev.fd); err != nil {
 			return nil, fmt.Errorf("device open: %s: closing after failure: %s", path, err)
@@ -75,52 +169,164 @@ func Open(path string, options ...Option) (*Device, error) {
 	}
 
 	if dev.config.bufType != 0 && dev.config.bufType != dev.bufType {
-		return nil, fmt.Errorf("device open: does not support buffer stream type")
+		return fail(fmt.Errorf("device open: does not support buffer stream type"))
+	}
+
+	// a read-only device is only queried, its configuration is left untouched
+	if dev.readOnly {
+		return dev, nil
 	}
 
-	// ensures IOType is set, only MemMap supported now
-	dev.config.ioType = v4l2.IOTypeMMAP
+	if err := dev.configure(); err != nil {
+		return fail(fmt.Errorf("device open: %s: %w", path, err))
+	}
+
+	return dev, nil
+}
+
+// openFile opens the device path with the configured flags (see WithOpenFlags), always
+// adding O_CLOEXEC so the descriptor does not leak to child processes. With
+// WithReadOnlyFallback, a read-write open denied with EACCES is retried read-only, in
+// which case readOnly is true.
+func (d *Device) openFile() (fd uintptr, readOnly bool, err error) {
+	flags := d.config.deviceOpenFlags()
+	fd, err = v4l2.OpenDevice(d.path, flags, 0)
+	if err != nil && errors.Is(err, sys.EACCES) && d.config.readOnlyFallback && flags&sys.O_ACCMODE == sys.O_RDWR {
+		fd, err = v4l2.OpenDevice(d.path, flags&^sys.O_ACCMODE|sys.O_RDONLY, 0)
+		if err == nil {
+			log.Printf("device: %s: opened read-only (permission denied), streaming is not available", d.path)
+			readOnly = true
+		}
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return fd, readOnly, nil
+}
+
+// configure applies the configured input, crop, format, frame rate and controls to the
+// opened device. Unset format and frame rate are read back from the device.
+func (d *Device) configure() error {
+	// select input first, switching inputs may reset the format
+	if d.config.inputSet {
+		if err := v4l2.SetVideoInputIndex(d.fd, d.config.input); err != nil {
+			return fmt.Errorf("set input: %w", err)
+		}
+	}
 
 	// reset crop, only if cropping supported
-	if cropcap, err := v4l2.GetCropCapability(dev.fd, dev.bufType); err == nil {
-		if err := v4l2.SetCropRect(dev.fd, cropcap.DefaultRect); err != nil {
+	if cropcap, err := v4l2.GetCropCapability(d.fd, d.bufType); err == nil {
+		if err := v4l2.SetCropRectForBufType(d.fd, d.bufType, cropcap.DefaultRect); err != nil {
 			// ignore errors
 		}
 	}
 
 	// set pix format
-	if dev.config.pixFormat != (v4l2.PixFormat{}) {
-		if err := dev.SetPixFormat(dev.config.pixFormat); err != nil {
-			return nil, fmt.Errorf("device open: %s: set format: %w", path, err)
+	switch {
+	case d.config.pixFormat != (v4l2.PixFormat{}) && d.isMultiPlanar():
+		pixFmt := d.config.pixFormat
+		mpFmt := v4l2.PixFormatMPlane{Width: pixFmt.Width, Height: pixFmt.Height, PixelFormat: pixFmt.PixelFormat, Field: pixFmt.Field}
+		if _, err := d.SetPixFormatMPlane(mpFmt); err != nil {
+			return fmt.Errorf("set format: %w", err)
 		}
-	} else {
-		dev.config.pixFormat, err = v4l2.GetPixFormat(dev.fd)
+	case d.config.pixFormat != (v4l2.PixFormat{}):
+		if err := d.SetPixFormat(d.config.pixFormat); err != nil {
+			return fmt.Errorf("set format: %w", err)
+		}
+	default:
+		pixFmt, err := d.currentPixFormat()
 		if err != nil {
-			return nil, fmt.Errorf("device open: %s: get default format: %w", path, err)
+			return fmt.Errorf("get default format: %w", err)
 		}
+		d.config.pixFormat = pixFmt
 	}
 
 	// set fps
-	if dev.config.fps != 0 {
-		if err := dev.SetFrameRate(dev.config.fps); err != nil {
-			return nil, fmt.Errorf("device open: %s: set fps: %w", path, err)
+	if d.config.fps != 0 {
+		if err := d.SetFrameRate(d.config.fps); err != nil {
+			return fmt.Errorf("set fps: %w", err)
 		}
 	} else {
-		if dev.config.fps, err = dev.GetFrameRate(); err != nil {
-			return nil, fmt.Errorf("device open: %s: get fps: %w", path, err)
+		fps, err := d.GetFrameRate()
+		if err != nil {
+			return fmt.Errorf("get fps: %w", err)
 		}
+		d.config.fps = fps
 	}
 
-	return dev, nil
+	return d.applyControls()
+}
+
+// modeControls are the auto/mode controls, which select whether their manual counterparts
+// (i.e. CtrlCameraFocusAbsolute for CtrlCameraFocusAuto) can be set
+var modeControls = map[v4l2.CtrlID]bool{
+	v4l2.CtrlAutoWhiteBalance:              true,
+	v4l2.CtrlAutogain:                      true,
+	v4l2.CtrlHueAuto:                       true,
+	v4l2.CtrlAutoBrightness:                true,
+	v4l2.CtrlCameraExposureAuto:            true,
+	v4l2.CtrlCameraExposureAutoPriority:    true,
+	v4l2.CtrlCameraFocusAuto:               true,
+	v4l2.CtrlCameraAutoNPresetWhiteBalance: true,
+	v4l2.CtrlCameraIsoSensitivityAuto:      true,
+}
+
+// sortControlIDs sorts the control IDs in the order they are set: auto/mode controls first,
+// since manual values are rejected while their auto mode is on, then in ascending ID order.
+func sortControlIDs(ids []v4l2.CtrlID) {
+	sort.Slice(ids, func(i, j int) bool {
+		if modeControls[ids[i]] != modeControls[ids[j]] {
+			return modeControls[ids[i]]
+		}
+		return ids[i] < ids[j]
+	})
+}
+
+// applyControls sets the configured controls (see WithControls) along with, when
+// persisted (see WithPersistControls), the control values set since the device was opened.
+// Auto/mode controls are set first, then the other controls in ID order for reproducible setups.
+func (d *Device) applyControls() error {
+	controls := make(map[v4l2.CtrlID]v4l2.CtrlValue, len(d.config.controls))
+	for id, val := range d.config.controls {
+		controls[id] = val
+	}
+	d.mu.Lock()
+	for id, val := range d.controls {
+		controls[id] = val
+	}
+	d.mu.Unlock()
+
+	ctrlIDs := make([]v4l2.CtrlID, 0, len(controls))
+	for id := range controls {
+		ctrlIDs = append(ctrlIDs, id)
+	}
+	sortControlIDs(ctrlIDs)
+	for _, id := range ctrlIDs {
+		if err := d.SetControlValue(id, controls[id]); err != nil {
+			return fmt.Errorf("set control: %w", err)
+		}
+	}
+	return nil
+}
+
+// OpenConfig opens the device at path and applies the declarative configuration. It is
+// equivalent to calling Open with cfg.Options().
+func OpenConfig(path string, cfg Config) (*Device, error) {
+	return Open(path, cfg.Options()...)
 }
 
 // Close closes the underlying device associated with `d` .
 func (d *Device) Close() error {
-	if d.streaming {
+	if d.IsStreaming() {
 		if err := d.Stop(); err != nil {
 			return err
 		}
 	}
+	// output buffers are allocated by the first Write, before the stream is on
+	if d.isOutput() && d.buffers != nil {
+		d.releaseBuffers()
+	}
+	d.stopEvents()
 	return v4l2.CloseDevice(d.fd)
 }
 
@@ -129,11 +335,47 @@ func (d *Device) Name() string {
 	return d.path
 }
 
+// IsReadOnly returns true if the device was opened read-only (see WithReadOnlyFallback),
+// in which case it can be queried but not streamed from.
+func (d *Device) IsReadOnly() bool {
+	return d.readOnly
+}
+
 // Fd returns the file descriptor value for the device
 func (d *Device) Fd() uintptr {
 	return d.fd
 }
 
+// IsStreaming returns true if the stream has been started (VIDIOC_STREAMON)
+// and not stopped since (VIDIOC_STREAMOFF).
+func (d *Device) IsStreaming() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.streaming
+}
+
+// Err returns the error that caused the stream to stop, if any. For instance,
+// it returns an error wrapping v4l2.ErrFormatChanged when the driver changed the
+// format mid-stream. The error is reset when the stream is started.
+func (d *Device) Err() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
+
+// Errors returns a channel delivering the errors that occur while streaming: non-fatal
+// errors, after which the stream goes on (i.e. frames dequeued with an error flag, wrapping
+// v4l2.ErrBufferCorrupted), and the fatal error that stopped the stream, also returned by Err,
+// after which the channel is closed. A fatal error wraps v4l2.ErrDeviceGone when the device
+// is disconnected (i.e. unplugged), the stream is then stopped and the device should be
+// closed, then opened again once it is back (see Enumerate). Errors are dropped rather than blocking the stream when the
+// channel is full. A new channel is created each time the stream is started.
+func (d *Device) Errors() <-chan error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.errs
+}
+
 // Buffers returns the internal mapped buffers. This method should be
 // called after streaming has been started otherwise it may return nil.
 func (d *Device) Buffers() [][]byte {
@@ -151,8 +393,9 @@ func (d *Device) BufferType() v4l2.BufType {
 	return d.bufType
 }
 
-// BufferCount returns configured number of buffers to be used during streaming.
-// If called after streaming start, this value could be updated by the driver.
+// BufferCount returns the number of buffers used during streaming. Before the stream is
+// started, it is the requested number (see WithBufferSize), afterwards it is the number
+// of buffers actually allocated by the driver, which may differ.
 func (d *Device) BufferCount() v4l2.BufType {
 	return d.config.bufSize
 }
@@ -163,44 +406,121 @@ func (d *Device) MemIOType() v4l2.IOType {
 }
 
 // GetOutput returns the channel that outputs streamed data that is
-// captured from the underlying device driver.
+// captured from the underlying device driver. GetOutput shares the stream
+// with Frames: each captured frame is delivered to only one of the channels.
 func (d *Device) GetOutput() <-chan []byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.streaming && !d.outputForwarded {
+		go func(frames <-chan v4l2.Frame, output chan<- []byte) {
+			defer close(output)
+			for frame := range frames {
+				output <- frame.Data
+			}
+		}(d.frames, d.output)
+		d.outputForwarded = true
+	}
 	return d.output
 }
 
+// GetOutputBuffers returns the channel that outputs the captured frames along with their
+// complete buffer information reported by the driver (Frame.Raw: timestamp, sequence, bytes
+// used, flags), i.e. to measure frame intervals or detect dropped frames.
+// For devices using dma-bufs (see WithDMABufImport and WithDMABufExport), Raw.Info.FD is the
+// dma-buf file descriptor holding the image, valid until the next frames are captured into it.
+// GetOutputBuffers shares the stream with GetOutput and Frames: each captured frame is
+// delivered to only one of the channels.
+func (d *Device) GetOutputBuffers() <-chan v4l2.Frame {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.streaming && !d.bufOutputForwarded {
+		go func(frames <-chan v4l2.Frame, output chan<- v4l2.Frame) {
+			defer close(output)
+			for frame := range frames {
+				frame.Raw = frame.Buffer()
+				output <- frame
+			}
+		}(d.frames, d.bufOutput)
+		d.bufOutputForwarded = true
+	}
+	return d.bufOutput
+}
+
+// Frames returns the channel that outputs captured frames along with the
+// buffer information (sequence, timestamp, field, etc) reported by the driver.
+func (d *Device) Frames() <-chan v4l2.Frame {
+	return d.frames
+}
+
 // SetInput sets up an input channel for data this sent for output to the
-// underlying device driver.
+// underlying device driver. Each data received is written (see Write) until
+// the channel is closed, a write failure stops the stream (see Err).
 func (d *Device) SetInput(in <-chan []byte) {
-
+	go func() {
+		for data := range in {
+			if err := d.Write(data); err != nil {
+				d.setErr(err)
+				d.Stop()
+				return
+			}
+		}
+	}()
 }
 
-// GetCropCapability returns cropping info for device
+// GetCropCapability returns cropping info for device.
+//
+// Deprecated: use GetCropCapabilities.
 func (d *Device) GetCropCapability() (v4l2.CropCapability, error) {
-	if !d.cap.IsVideoCaptureSupported() {
-		return v4l2.CropCapability{}, v4l2.ErrorUnsupportedFeature
-	}
-	return d.cropCap, nil
+	return d.GetCropCapabilities()
 }
 
-// SetCropRect crops the video dimension for the device
+// SetCropRect crops the video dimension for the device.
+//
+// Deprecated: use SetCrop.
 func (d *Device) SetCropRect(r v4l2.Rect) error {
-	if !d.cap.IsVideoCaptureSupported() {
-		return v4l2.ErrorUnsupportedFeature
+	return d.SetCrop(r)
+}
+
+// GetCropCapabilities queries the cropping capabilities of the device: the bounds of the
+// area that can be cropped, the default rectangle, and the pixel aspect. It returns an error
+// wrapping v4l2.ErrorUnsupportedFeature if the driver does not support cropping.
+func (d *Device) GetCropCapabilities() (v4l2.CropCapability, error) {
+	cropCap, err := v4l2.GetCropCapability(d.fd, d.bufType)
+	if err != nil {
+		return v4l2.CropCapability{}, fmt.Errorf("device: %s: %w", d.path, err)
 	}
-	if err := v4l2.SetCropRect(d.fd, r); err != nil {
-		return fmt.Errorf("device: %w", err)
+	return cropCap, nil
+}
+
+// GetCrop returns the current cropping rectangle of the device. It returns an error
+// wrapping v4l2.ErrorUnsupportedFeature if the driver does not support cropping.
+func (d *Device) GetCrop() (v4l2.Rect, error) {
+	r, err := v4l2.GetCropRect(d.fd, d.bufType)
+	if err != nil {
+		return v4l2.Rect{}, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return r, nil
+}
+
+// SetCrop sets the cropping rectangle of the device (i.e. a 640x480 window of a 1920x1080
+// sensor), within the bounds reported by GetCropCapabilities. The driver may adjust the
+// rectangle, GetCrop returns the rectangle applied. It returns an error wrapping
+// v4l2.ErrorUnsupportedFeature if the driver does not support cropping.
+func (d *Device) SetCrop(r v4l2.Rect) error {
+	if err := v4l2.SetCropRectForBufType(d.fd, d.bufType, r); err != nil {
+		return fmt.Errorf("device: %s: %w", d.path, err)
 	}
 	return nil
 }
 
 // GetPixFormat retrieves pixel format info for device
 func (d *Device) GetPixFormat() (v4l2.PixFormat, error) {
-	if !d.cap.IsVideoCaptureSupported() {
+	if !d.cap.IsVideoCaptureSupported() && !d.isOutput() {
 		return v4l2.PixFormat{}, v4l2.ErrorUnsupportedFeature
 	}
 
 	if d.config.pixFormat == (v4l2.PixFormat{}) {
-		pixFmt, err := v4l2.GetPixFormat(d.fd)
+		pixFmt, err := d.currentPixFormat()
 		if err != nil {
 			return v4l2.PixFormat{}, fmt.Errorf("device: %w", err)
 		}
@@ -210,19 +530,66 @@ func (d *Device) GetPixFormat() (v4l2.PixFormat, error) {
 	return d.config.pixFormat, nil
 }
 
-// SetPixFormat sets the pixel format for the associated device.
-func (d *Device) SetPixFormat(pixFmt v4l2.PixFormat) error {
+// EffectiveResolution re-reads the current format from the driver and returns the actual
+// dimensions of the delivered frames. Drivers may adjust the dimensions when the format or
+// the selection (crop, compose) changes, call it after such changes rather than relying on
+// the requested dimensions. The format returned by GetPixFormat is refreshed as well.
+func (d *Device) EffectiveResolution() (width, height uint32, err error) {
 	if !d.cap.IsVideoCaptureSupported() {
+		return 0, 0, v4l2.ErrorUnsupportedFeature
+	}
+
+	pixFmt, err := v4l2.GetPixFormat(d.fd)
+	if err != nil {
+		return 0, 0, fmt.Errorf("device: %s: effective resolution: %w", d.path, err)
+	}
+	d.config.pixFormat = pixFmt
+	return pixFmt.Width, pixFmt.Height, nil
+}
+
+// SetPixFormat sets the pixel format for the associated device. The colorimetry fields
+// (Colorspace, YcbcrEnc, Quantization, XferFunc) are requested when not the default; the
+// driver may adjust them, use GetPixFormat to read the values applied.
+func (d *Device) SetPixFormat(pixFmt v4l2.PixFormat) error {
+	if !d.cap.IsVideoCaptureSupported() && !d.isOutput() {
 		return v4l2.ErrorUnsupportedFeature
 	}
 
-	if err := v4l2.SetPixFormat(d.fd, pixFmt); err != nil {
+	if err := v4l2.ValidatePixFormatAlignment(pixFmt); err != nil {
+		if !d.config.autoAlign {
+			return fmt.Errorf("device: %s: %w", d.path, err)
+		}
+		aligned := v4l2.AlignPixFormat(pixFmt)
+		log.Printf("device: %s: format %dx%d aligned to %dx%d: %s", d.path, pixFmt.Width, pixFmt.Height, aligned.Width, aligned.Height, err)
+		pixFmt = aligned
+	}
+
+	// capture drivers only honor the requested colorimetry with the CSC flag
+	if pixFmt.HasColorimetry() && !d.isOutput() {
+		pixFmt.Flags |= v4l2.PixFmtFlagSetCSC
+	}
+
+	if err := v4l2.SetPixFormatForBufType(d.fd, d.bufType, pixFmt); err != nil {
 		return fmt.Errorf("device: %w", err)
 	}
 	d.config.pixFormat = pixFmt
 	return nil
 }
 
+// TryPixFormat returns the pixel format the driver would adjust pixFmt to (i.e. the closest
+// supported size), including the BytesPerLine and SizeImage it would use, without changing
+// the device format. It can be used to probe several candidate formats.
+func (d *Device) TryPixFormat(pixFmt v4l2.PixFormat) (v4l2.PixFormat, error) {
+	if !d.cap.IsVideoCaptureSupported() {
+		return v4l2.PixFormat{}, v4l2.ErrorUnsupportedFeature
+	}
+	tried, err := v4l2.TryPixFormat(d.fd, pixFmt)
+	if err != nil {
+		return v4l2.PixFormat{}, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return tried, nil
+}
+
 // GetFormatDescription returns a format description for the device at specified format index
 func (d *Device) GetFormatDescription(idx uint32) (v4l2.FormatDescription, error) {
 	if !d.cap.IsVideoCaptureSupported() {
@@ -241,6 +608,35 @@ func (d *Device) GetFormatDescriptions() ([]v4l2.FormatDescription, error) {
 	return v4l2.GetAllFormatDescriptions(d.fd)
 }
 
+// GetFormatFrameIntervals returns the frame intervals (1/fps) supported for the pixel format
+// at the specified frame size: one entry per discrete interval, or a single entry holding the
+// range of a stepwise or continuous interval (see v4l2.FrameIntervalEnum.SupportsFPS).
+func (d *Device) GetFormatFrameIntervals(pixFmt v4l2.FourCCType, width, height uint32) ([]v4l2.FrameIntervalEnum, error) {
+	if !d.cap.IsVideoCaptureSupported() {
+		return nil, v4l2.ErrorUnsupportedFeature
+	}
+
+	intervals, err := v4l2.GetFormatFrameIntervals(d.fd, pixFmt, width, height)
+	if err != nil {
+		return intervals, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return intervals, nil
+}
+
+// AllFrameRates returns every (format, frame size, frame rate) combination supported by the
+// device, as a flat list (see v4l2.GetAllFormatSizeRates).
+func (d *Device) AllFrameRates() ([]v4l2.FormatSizeRate, error) {
+	if !d.cap.IsVideoCaptureSupported() {
+		return nil, v4l2.ErrorUnsupportedFeature
+	}
+
+	rates, err := v4l2.GetAllFormatSizeRates(d.fd)
+	if err != nil {
+		return rates, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return rates, nil
+}
+
 // GetVideoInputIndex returns current video input index for device
 func (d *Device) GetVideoInputIndex() (int32, error) {
 	if !d.cap.IsVideoCaptureSupported() {
@@ -259,9 +655,103 @@ func (d *Device) GetVideoInputInfo(index uint32) (v4l2.InputInfo, error) {
 	return v4l2.GetVideoInputInfo(d.fd, index)
 }
 
-// GetStreamParam returns streaming parameter information for device
+// EnumInputs returns the information of all the video inputs of the device (i.e. the
+// HDMI, composite and S-Video inputs of a capture card), see v4l2.InputInfo.HasSignal
+// to check whether a source is connected to the current input.
+func (d *Device) EnumInputs() ([]v4l2.InputInfo, error) {
+	inputs, err := v4l2.GetAllVideoInputInfo(d.fd)
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return inputs, nil
+}
+
+// GetInput returns the index of the current video input.
+func (d *Device) GetInput() (int32, error) {
+	index, err := v4l2.GetCurrentVideoInputIndex(d.fd)
+	if err != nil {
+		return -1, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return index, nil
+}
+
+// SelectInput selects the video input at index (see WithVideoInput to select it when the
+// device is opened). Switching inputs may change the format, which is read back from the
+// driver. It fails while streaming.
+func (d *Device) SelectInput(index int32) error {
+	if d.IsStreaming() {
+		return fmt.Errorf("device: %s: select input: stream started", d.path)
+	}
+	if err := v4l2.SetVideoInputIndex(d.fd, index); err != nil {
+		return fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	d.config.input, d.config.inputSet = index, true
+	if pixFmt, err := d.currentPixFormat(); err == nil {
+		d.config.pixFormat = pixFmt
+	}
+	return nil
+}
+
+// EnumStandards returns the analog video standards (PAL, NTSC, SECAM...) supported by the
+// current input. Inputs that are not analog support no standard.
+func (d *Device) EnumStandards() ([]v4l2.StandardInfo, error) {
+	stds, err := v4l2.GetAllStandards(d.fd)
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return stds, nil
+}
+
+// GetStandard returns the video standard selected on the current input.
+func (d *Device) GetStandard() (v4l2.StdID, error) {
+	id, err := v4l2.GetStandard(d.fd)
+	if err != nil {
+		return v4l2.StdUnknown, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return id, nil
+}
+
+// SetStandard selects the video standard of the current input. The standard determines the
+// frame size and rate, the format is read back from the driver. It fails while streaming.
+func (d *Device) SetStandard(id v4l2.StdID) error {
+	if d.IsStreaming() {
+		return fmt.Errorf("device: %s: set standard: stream started", d.path)
+	}
+	if err := v4l2.SetStandard(d.fd, id); err != nil {
+		return fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	if pixFmt, err := d.currentPixFormat(); err == nil {
+		d.config.pixFormat = pixFmt
+	}
+	return nil
+}
+
+// GetEDID returns the EDID presented by the receiver pad (i.e. the HDMI input of a capture
+// card) to the source, nil when none is set.
+func (d *Device) GetEDID(pad uint32) ([]byte, error) {
+	edid, err := v4l2.GetEDID(d.fd, pad)
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return edid, nil
+}
+
+// SetEDID sets the EDID presented by the receiver pad to the source, which selects the modes
+// the source outputs. Its size must be a multiple of v4l2.EDIDBlockSize, an empty EDID
+// clears it.
+func (d *Device) SetEDID(pad uint32, edid []byte) error {
+	if err := v4l2.SetEDID(d.fd, pad, edid); err != nil {
+		return fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return nil
+}
+
+// GetStreamParam returns the streaming parameters of the device (VIDIOC_G_PARM): the exact
+// frame interval (see StreamParam.TimePerFrame), the capability flags telling whether the
+// interval can be set (see StreamParam.IsTimePerFrameSupported) and, for capture devices,
+// the number of buffers used with the read I/O method (Capture.ReadBuffers).
 func (d *Device) GetStreamParam() (v4l2.StreamParam, error) {
-	if !d.cap.IsVideoCaptureSupported() && d.cap.IsVideoOutputSupported() {
+	if !d.cap.IsVideoCaptureSupported() && !d.cap.IsVideoOutputSupported() && !d.isMultiPlanar() {
 		return v4l2.StreamParam{}, v4l2.ErrorUnsupportedFeature
 	}
 	return v4l2.GetStreamParam(d.fd, d.bufType)
@@ -269,48 +759,56 @@ func (d *Device) GetStreamParam() (v4l2.StreamParam, error) {
 
 // SetStreamParam saves stream parameters for device
 func (d *Device) SetStreamParam(param v4l2.StreamParam) error {
-	if !d.cap.IsVideoCaptureSupported() && d.cap.IsVideoOutputSupported() {
+	if !d.cap.IsVideoCaptureSupported() && !d.cap.IsVideoOutputSupported() && !d.isMultiPlanar() {
 		return v4l2.ErrorUnsupportedFeature
 	}
 	return v4l2.SetStreamParam(d.fd, d.bufType, param)
 }
 
-// SetFrameRate sets the FPS rate value of the device
+// SetFrameRate sets the frame rate of the device, in frames per second, with VIDIOC_S_PARM.
+// It fails with ErrorUnsupportedFeature when the driver does not support setting the frame
+// interval (no V4L2_CAP_TIMEPERFRAME capability). Drivers apply the closest rate they support,
+// or keep the current one: the rate applied is read back and is returned by GetFrameRate.
+// The frame rate can be changed at runtime, without reopening the device.
 func (d *Device) SetFrameRate(fps uint32) error {
-	if !d.cap.IsStreamingSupported() {
-		return fmt.Errorf("set frame rate: %w", v4l2.ErrorUnsupportedFeature)
+	if fps == 0 {
+		return fmt.Errorf("device: %s: set fps: %w: zero frame rate", d.path, v4l2.ErrorBadArgument)
+	}
+	param, err := d.GetStreamParam()
+	if err != nil {
+		return fmt.Errorf("device: %s: set fps: %w", d.path, err)
+	}
+	if !param.IsTimePerFrameSupported() {
+		return fmt.Errorf("device: %s: set fps: %w: frame interval cannot be set", d.path, v4l2.ErrorUnsupportedFeature)
 	}
 
-	var param v4l2.StreamParam
-	switch {
-	case d.cap.IsVideoCaptureSupported():
-		param.Capture = v4l2.CaptureParam{TimePerFrame: v4l2.Fract{Numerator: 1, Denominator: fps}}
-	case d.cap.IsVideoOutputSupported():
-		param.Output = v4l2.OutputParam{TimePerFrame: v4l2.Fract{Numerator: 1, Denominator: fps}}
-	default:
-		return v4l2.ErrorUnsupportedFeature
+	interval := v4l2.Fract{Numerator: 1, Denominator: fps}
+	if d.isOutput() || d.bufType == v4l2.BufTypeVideoOutputMPlane {
+		param.Output.TimePerFrame = interval
+	} else {
+		param.Capture.TimePerFrame = interval
 	}
 	if err := d.SetStreamParam(param); err != nil {
-		return fmt.Errorf("device: set fps: %w", err)
+		return fmt.Errorf("device: %s: set fps: %w", d.path, err)
+	}
+
+	d.config.fps = 0
+	if _, err := d.GetFrameRate(); err != nil {
+		return fmt.Errorf("device: %s: set fps: %w", d.path, err)
 	}
-	d.config.fps = fps
 	return nil
 }
 
-// GetFrameRate returns the FPS value for the device
+// GetFrameRate returns the frame rate of the device, in frames per second, rounded to the
+// nearest integer (i.e. 30 for 30000/1001).
 func (d *Device) GetFrameRate() (uint32, error) {
 	if d.config.fps == 0 {
 		param, err := d.GetStreamParam()
 		if err != nil {
 			return 0, fmt.Errorf("device: frame rate: %w", err)
 		}
-		switch {
-		case d.cap.IsVideoCaptureSupported():
-			d.config.fps = param.Capture.TimePerFrame.Denominator
-		case d.cap.IsVideoOutputSupported():
-			d.config.fps = param.Output.TimePerFrame.Denominator
-		default:
-			return 0, v4l2.ErrorUnsupportedFeature
+		if interval := param.TimePerFrame(); interval.Numerator != 0 {
+			d.config.fps = uint32(math.Round(float64(interval.Denominator) / float64(interval.Numerator)))
 		}
 	}
 
@@ -327,61 +825,240 @@ func (d *Device) Start(ctx context.Context) error {
 		return ctx.Err()
 	}
 
-	if !d.cap.IsStreamingSupported() {
-		return fmt.Errorf("device: start stream: %s", v4l2.ErrorUnsupportedFeature)
-	}
+	d.streamMu.Lock()
+	defer d.streamMu.Unlock()
 
-	if d.streaming {
+	if d.IsStreaming() {
 		return fmt.Errorf("device: stream already started")
 	}
 
+	if d.readOnly {
+		return fmt.Errorf("device: start: %s: opened read-only", d.path)
+	}
+
+	if err := d.runPreStreamHooks(); err != nil {
+		return err
+	}
+
+	if d.config.healthCallback != nil && d.health == nil {
+		d.health = newHealthMonitor(d.config.healthCallback, d.config.watchdogTimeout)
+	}
+
+	if d.config.autoExposure {
+		tuning := d.config.autoExposureTuning
+		if tuning == (AutoExposureTuning{}) {
+			tuning = DefaultAutoExposureTuning
+		}
+		var err error
+		if d.autoExposure, err = newAutoExposure(d.fd, d.config.autoExposureTarget, tuning, d.config.pixFormat); err != nil {
+			return fmt.Errorf("device: start: %w", err)
+		}
+	}
+
+	// the stream loop runs until ctx is done or the stream is stopped
+	ctx, cancel := context.WithCancel(ctx)
+	d.mu.Lock()
+	d.cancel = cancel
+	d.mu.Unlock()
+
+	if d.config.ioType == v4l2.IOTypeReadWrite {
+		if err := d.startReadLoop(ctx); err != nil {
+			cancel()
+			return fmt.Errorf("device: start read loop: %w", err)
+		}
+		d.startHealthMonitor(ctx)
+		d.mu.Lock()
+		d.streaming = true
+		d.mu.Unlock()
+		return nil
+	}
+
+	if !d.cap.IsStreamingSupported() {
+		cancel()
+		return fmt.Errorf("device: start stream: %s", v4l2.ErrorUnsupportedFeature)
+	}
+
 	// allocate device buffers
 	bufReq, err := v4l2.InitBuffers(d)
 	if err != nil {
+		cancel()
+		if errors.Is(err, v4l2.ErrStreamingUnsupported) {
+			return fmt.Errorf("device: start: %s: %w", d.path, err)
+		}
 		return fmt.Errorf("device: requested buffer type not be supported: %w", err)
 	}
 
+	// once allocated, the buffers are released if the stream fails to start
+	fail := func(err error) error {
+		d.releaseBuffers()
+		cancel()
+		return err
+	}
+
+	if bufReq.Count < d.config.minBufs {
+		return fail(fmt.Errorf("device: start: %s: %w: driver granted %d buffers, %d required", d.path, v4l2.ErrInsufficientBuffers, bufReq.Count, d.config.minBufs))
+	}
+	if d.config.ioType == v4l2.IOTypeDMABuf && int(bufReq.Count) > len(d.config.dmabufFds) {
+		return fail(fmt.Errorf("device: start: %s: driver requires %d buffers, %d dmabufs imported", d.path, bufReq.Count, len(d.config.dmabufFds)))
+	}
+	if d.config.ioType == v4l2.IOTypeUserPtr {
+		if err := d.validateUserBuffers(bufReq.Count); err != nil {
+			return fail(fmt.Errorf("device: start: %s: %w", d.path, err))
+		}
+	}
 	d.config.bufSize = bufReq.Count
 	d.requestedBuf = bufReq
 
-	// for each allocated device buf, map into local space
-	if d.buffers, err = v4l2.MapMemoryBuffers(d); err != nil {
-		return fmt.Errorf("device: make mapped buffers: %s", err)
+	// for each allocated device buf, map into local space (imported dmabufs are not mapped)
+	if d.config.ioType == v4l2.IOTypeMMAP {
+		if d.isMultiPlanar() {
+			if err := d.mapPlanes(); err != nil {
+				return fail(fmt.Errorf("device: make mapped buffers: %s", err))
+			}
+		} else if d.buffers, err = v4l2.MapMemoryBuffers(d); err != nil {
+			return fail(fmt.Errorf("device: make mapped buffers: %s", err))
+		}
+		if d.config.dmabufExport {
+			if err := d.exportBuffers(); err != nil {
+				return fail(fmt.Errorf("device: start: %s: %w", d.path, err))
+			}
+		}
 	}
 
 	if err := d.startStreamLoop(ctx); err != nil {
-		return fmt.Errorf("device: start stream loop: %s", err)
+		return fail(fmt.Errorf("device: start stream loop: %s", err))
 	}
+	d.startHealthMonitor(ctx)
 
+	d.mu.Lock()
 	d.streaming = true
+	d.mu.Unlock()
 
 	return nil
 }
 
+// Stop stops the stream and releases everything allocated by Start: the stream loop exits,
+// closing the frame channels, and the device buffers are unmapped and freed. The device stays
+// open, its format can be changed (i.e. with SetPixFormat) before the stream is started again
+// with new buffers and channels (see Restart).
 func (d *Device) Stop() error {
-	if !d.streaming {
+	d.mu.Lock()
+	cancel, loopDone := d.cancel, d.loopDone
+	d.cancel, d.loopDone = nil, nil
+	d.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if loopDone != nil {
+		<-loopDone
+	}
+	return d.stop()
+}
+
+// Restart stops the stream, sets the format (unless pixFmt is zero-valued) and starts the
+// stream again with newly allocated buffers, without closing the device: control values and
+// the device priority are kept. The output channels must be retrieved again (i.e. GetOutput)
+// since the channels of the stopped stream are closed.
+func (d *Device) Restart(ctx context.Context, pixFmt v4l2.PixFormat) error {
+	if err := d.Stop(); err != nil {
+		return fmt.Errorf("device: restart: %w", err)
+	}
+	if pixFmt != (v4l2.PixFormat{}) {
+		if d.isMultiPlanar() {
+			mpFmt := v4l2.PixFormatMPlane{Width: pixFmt.Width, Height: pixFmt.Height, PixelFormat: pixFmt.PixelFormat, Field: pixFmt.Field}
+			if _, err := d.SetPixFormatMPlane(mpFmt); err != nil {
+				return fmt.Errorf("device: restart: %w", err)
+			}
+		} else if err := d.SetPixFormat(pixFmt); err != nil {
+			return fmt.Errorf("device: restart: %w", err)
+		}
+	}
+	if err := d.Start(ctx); err != nil {
+		return fmt.Errorf("device: restart: %w", err)
+	}
+	return nil
+}
+
+// stop turns the stream off and releases the device buffers, it is called by the stream
+// loop when it exits. The stream loop and Stop may both stop the stream, the buffers are
+// released once.
+func (d *Device) stop() error {
+	d.streamMu.Lock()
+	defer d.streamMu.Unlock()
+
+	if !d.IsStreaming() {
+		return nil
+	}
+	if d.health != nil {
+		d.health.halt()
+	}
+	if d.config.ioType == v4l2.IOTypeReadWrite {
+		d.mu.Lock()
+		d.streaming = false
+		d.mu.Unlock()
 		return nil
 	}
-	if err := v4l2.UnmapMemoryBuffers(d); err != nil {
+	if d.config.ioType == v4l2.IOTypeMMAP {
+		if err := v4l2.UnmapMemoryBuffers(d); err != nil {
+			return fmt.Errorf("device: stop: %w", err)
+		}
+		d.buffers, d.planes = nil, nil
+		d.closeExportedBuffers()
+	}
+	// a disconnected device is stopped already, its buffers are released when it is closed
+	if err := v4l2.StreamOff(d); err != nil && !errors.Is(err, v4l2.ErrDeviceGone) {
 		return fmt.Errorf("device: stop: %w", err)
 	}
-	if err := v4l2.StreamOff(d); err != nil {
+	// free the buffers, so that the format can be changed
+	if _, err := v4l2.ResetBuffers(d); err != nil && !errors.Is(err, v4l2.ErrDeviceGone) {
 		return fmt.Errorf("device: stop: %w", err)
 	}
+	d.mu.Lock()
 	d.streaming = false
+	d.paused = false
+	d.mu.Unlock()
 	return nil
 }
 
+// releaseBuffers releases the device buffers when the stream fails to start: mapped buffers
+// are unmapped, exported dma-bufs closed, the stream turned off and the buffers freed. Errors
+// are ignored, the start error is reported instead.
+func (d *Device) releaseBuffers() {
+	if d.config.ioType == v4l2.IOTypeMMAP && d.buffers != nil {
+		_ = v4l2.UnmapMemoryBuffers(d)
+	}
+	d.buffers, d.planes = nil, nil
+	d.closeExportedBuffers()
+	_ = v4l2.StreamOff(d)
+	_, _ = v4l2.ResetBuffers(d)
+}
+
+// resetStreamChannels creates the output channels of a new stream, for the read loop and
+// the stream loop alike, and clears the error and statistics of the previous stream
+func (d *Device) resetStreamChannels() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.output = make(chan []byte, d.config.bufSize)
+	d.frames = make(chan v4l2.Frame, d.config.bufSize)
+	d.outputForwarded = false
+	d.bufOutput = make(chan v4l2.Frame, d.config.bufSize)
+	d.bufOutputForwarded = false
+	d.images = make(chan image.Image, d.config.bufSize)
+	d.imagesForwarded = false
+	d.err = nil
+	d.errs, d.errsOpen = make(chan error, errorsBufSize), true
+	d.stats = Stats{}
+}
+
 // startStreamLoop sets up the loop to run until context is cancelled, and returns immediately
 // and report any errors. The loop runs in a separate goroutine and uses the sys.Select to trigger
 // capture events.
 func (d *Device) startStreamLoop(ctx context.Context) error {
-	d.output = make(chan []byte, d.config.bufSize)
+	d.resetStreamChannels()
 
 	// Initial enqueue of buffers for capture
 	for i := 0; i < int(d.config.bufSize); i++ {
-		_, err := v4l2.QueueBuffer(d.fd, d.config.ioType, d.bufType, uint32(i))
-		if err != nil {
+		if err := d.queueBuffer(uint32(i)); err != nil {
 			return fmt.Errorf("device: buffer queueing: %w", err)
 		}
 	}
@@ -390,47 +1067,322 @@ func (d *Device) startStreamLoop(ctx context.Context) error {
 		return fmt.Errorf("device: stream on: %w", err)
 	}
 
-	go func() {
-		defer close(d.output)
+	// for uncompressed formats, each buffer should hold exactly one image of the negotiated
+	// size, a mismatch may indicate the driver changed the format without notice.
+	streamFmt, err := d.currentPixFormat()
+	if err != nil {
+		return fmt.Errorf("device: stream format: %w", err)
+	}
+	checkFormat := streamFmt.SizeImage > 0
+	if desc, err := v4l2.GetFormatDescriptionByEncoding(d.fd, streamFmt.PixelFormat); err == nil && desc.Flags&v4l2.FmtDescFlagCompressed != 0 {
+		checkFormat = false
+	}
+
+	loopDone := make(chan struct{})
+	pauseRequests := make(chan pauseRequest)
+	d.mu.Lock()
+	d.loopDone = loopDone
+	d.pauseRequests = pauseRequests
+	d.paused = false
+	d.mu.Unlock()
+
+	go func(frames chan<- v4l2.Frame) {
+		defer close(loopDone)
+		defer close(frames)
+		defer d.closeErrors()
 
 		fd := d.Fd()
-		var frame []byte
 		ioMemType := d.MemIOType()
 		bufType := d.BufferType()
-		waitForRead := v4l2.WaitForRead(d)
+		waitForRead := v4l2.WaitForReadDone(d, loopDone)
+		skip := d.config.startupSkip
+		multiPlanar := d.isMultiPlanar()
+		timer := newCaptureTimer(d.config.captureTimeout)
+		defer timer.stop()
+		var lastSequence, lastDequeued uint32
+		var delivered, dequeued bool
 		for {
+			// no buffers are dequeued (nor expected) while paused
+			wait, expired := waitForRead, timer.expired()
+			if d.IsPaused() {
+				wait, expired = nil, nil
+			}
+
 			select {
 			// handle stream capture (read from driver)
-			case <-waitForRead:
-				buff, err := v4l2.DequeueBuffer(fd, ioMemType, bufType)
+			case <-wait:
+				var buff v4l2.Buffer
+				var data []byte
+				var err error
+				if multiPlanar {
+					// planes are assembled into data when dequeued
+					buff, data, err = d.dequeuePlanes()
+				} else {
+					buff, err = v4l2.DequeueBuffer(fd, ioMemType, bufType)
+				}
 				if err != nil {
 					if errors.Is(err, sys.EAGAIN) {
 						continue
 					}
-					panic(fmt.Sprintf("device: stream loop dequeue: %s", err))
+					d.fail(fmt.Errorf("device: %s: stream loop dequeue: %w", d.path, err))
+					return
+				}
+				if buff.Flags&v4l2.BufFlagError != 0 {
+					d.reportErr(fmt.Errorf("device: %s: buffer %d: sequence %d: %w", d.path, buff.Index, buff.Sequence, v4l2.ErrBufferCorrupted))
+				}
+				if dequeued {
+					if dropped := sequenceGap(lastDequeued, buff.Sequence); dropped > 0 {
+						atomic.AddUint64(&d.stats.FramesDropped, uint64(dropped))
+					}
+				}
+				lastDequeued, dequeued = buff.Sequence, true
+
+				// re-queue startup frames without delivering them (lets exposure/white balance settle)
+				if skip > 0 {
+					skip--
+					if err := d.queueBuffer(buff.Index); err != nil {
+						d.fail(fmt.Errorf("device: %s: stream loop queue: buffer %d: %w", d.path, buff.Index, err))
+						return
+					}
+					timer.reset()
+					continue
+				}
+
+				if checkFormat && buff.Flags&v4l2.BufFlagError == 0 && buff.BytesUsed != streamFmt.SizeImage {
+					if err := d.checkFormatChange(streamFmt); err != nil {
+						d.fail(err)
+						return
+					}
 				}
 
 				// copy mapped buffer (copying avoids polluted data from subsequent dequeue ops)
-				if buff.Flags&v4l2.BufFlagMapped != 0 && buff.Flags&v4l2.BufFlagError == 0 {
-					frame = make([]byte, buff.BytesUsed)
-					if n := copy(frame, d.buffers[buff.Index][:buff.BytesUsed]); n == 0 {
-						d.output <- []byte{}
+				// (multi-planar data is already copied from its planes)
+				switch {
+				case multiPlanar:
+				case d.config.dmabufExport:
+					// exported buffers are shared, not copied
+					data = []byte{}
+				case ioMemType == v4l2.IOTypeUserPtr && buff.Flags&v4l2.BufFlagError == 0:
+					userBuf, ok := d.userBuffer(buff)
+					if !ok || int(buff.BytesUsed) > len(userBuf) {
+						d.fail(fmt.Errorf("device: %s: stream loop: unknown user buffer %d: %w", d.path, buff.Index, v4l2.ErrorSystem))
+						return
 					}
-					d.output <- frame
-					frame = nil
-				} else {
-					d.output <- []byte{}
+					data = make([]byte, buff.BytesUsed)
+					copy(data, userBuf[:buff.BytesUsed])
+				case buff.Flags&v4l2.BufFlagMapped != 0 && buff.Flags&v4l2.BufFlagError == 0:
+					data = make([]byte, buff.BytesUsed)
+					copy(data, d.buffers[buff.Index][:buff.BytesUsed])
+				default:
+					data = []byte{}
+				}
+
+				frame := v4l2.NewFrame(buff, data).WithFormat(streamFmt)
+				dmabufFd, dmabuf := d.dmabufFd(buff.Index)
+				if dmabuf {
+					buff.Info.FD = int32(dmabufFd)
+				}
+				if d.config.rawBufferInfo || dmabuf {
+					frame.Raw = buff
+				}
+				if d.config.lumaStats {
+					frame.LumaMean, frame.LumaHistogram, _ = v4l2.LumaStats(frame.Data, streamFmt)
+				}
+				if d.config.outOfOrderCheck {
+					if delivered && sequenceBefore(frame.Sequence, lastSequence) {
+						frame.OutOfOrder = true
+						atomic.AddUint64(&d.stats.FramesOutOfOrder, 1)
+					}
+					lastSequence, delivered = frame.Sequence, true
+				}
+				if d.autoExposure != nil {
+					d.autoExposure.update(frame)
+				}
+
+				select {
+				case frames <- frame:
+					d.countDelivered(frame)
+				case <-ctx.Done():
+					d.stop()
+					return
 				}
 
-				if _, err := v4l2.QueueBuffer(fd, ioMemType, bufType, buff.Index); err != nil {
-					panic(fmt.Sprintf("device: stream loop queue: %s: buff: %#v", err, buff))
+				if err := d.queueBuffer(buff.Index); err != nil {
+					d.fail(fmt.Errorf("device: %s: stream loop queue: buffer %d: %w", d.path, buff.Index, err))
+					return
 				}
+				timer.reset()
+			case <-expired:
+				d.captureTimedOut(d.config.captureTimeout)
+				return
+			case req := <-pauseRequests:
+				req.err <- d.pauseStream(ctx, req.pause)
+				timer.reset()
 			case <-ctx.Done():
-				d.Stop()
+				d.stop()
 				return
 			}
 		}
-	}()
+	}(d.frameSink(ctx, streamFmt))
 
 	return nil
 }
+
+// startReadLoop sets up the loop that captures frames using the read/write IO method
+// until the context is cancelled. Each read requests the configured read size (see
+// WithReadSize), or the image size of the current format by default.
+func (d *Device) startReadLoop(ctx context.Context) error {
+	pixFmt, err := v4l2.GetPixFormat(d.fd)
+	if err != nil {
+		return fmt.Errorf("read loop: %w", err)
+	}
+	readSize := d.config.readSize
+	if readSize == 0 {
+		readSize = int(pixFmt.SizeImage)
+	}
+	if readSize <= 0 {
+		return fmt.Errorf("read loop: invalid read size %d", readSize)
+	}
+	if readSize < int(pixFmt.SizeImage) {
+		return fmt.Errorf("read loop: read size %d smaller than image size %d", readSize, pixFmt.SizeImage)
+	}
+
+	d.resetStreamChannels()
+
+	d.mu.Lock()
+	loopDone := make(chan struct{})
+	d.loopDone = loopDone
+	d.mu.Unlock()
+
+	go func(frames chan<- v4l2.Frame) {
+		defer close(loopDone)
+		defer close(frames)
+		defer d.closeErrors()
+
+		buf := make([]byte, readSize)
+		waitForRead := v4l2.WaitForReadDone(d, loopDone)
+		skip := d.config.startupSkip
+		timer := newCaptureTimer(d.config.captureTimeout)
+		defer timer.stop()
+		var sequence uint32
+		for {
+			select {
+			case <-timer.expired():
+				d.captureTimedOut(d.config.captureTimeout)
+				return
+			case <-waitForRead:
+				n, err := v4l2.ReadDevice(d.fd, buf)
+				if err != nil {
+					if errors.Is(err, sys.EAGAIN) {
+						continue
+					}
+					d.fail(fmt.Errorf("device: %s: read loop: %w", d.path, err))
+					return
+				}
+				timer.reset()
+				if skip > 0 {
+					skip--
+					continue
+				}
+
+				data := make([]byte, n)
+				copy(data, buf[:n])
+				frame := v4l2.Frame{Data: data, Sequence: sequence, Timestamp: time.Now(), Field: pixFmt.Field}.WithFormat(pixFmt)
+				sequence++
+				if d.config.lumaStats {
+					frame.LumaMean, frame.LumaHistogram, _ = v4l2.LumaStats(frame.Data, pixFmt)
+				}
+				if d.autoExposure != nil {
+					d.autoExposure.update(frame)
+				}
+
+				select {
+				case frames <- frame:
+					d.countDelivered(frame)
+				case <-ctx.Done():
+					d.stop()
+					return
+				}
+				timer.reset()
+			case <-ctx.Done():
+				d.stop()
+				return
+			}
+		}
+	}(d.frameSink(ctx, pixFmt))
+
+	return nil
+}
+
+// checkFormatChange re-reads the current format from the driver and returns an error
+// wrapping v4l2.ErrFormatChanged if it no longer matches the negotiated format.
+func (d *Device) checkFormatChange(negotiated v4l2.PixFormat) error {
+	current, err := d.currentPixFormat()
+	if err != nil {
+		return fmt.Errorf("device: %s: format check: %w", d.path, err)
+	}
+	if current.Width != negotiated.Width ||
+		current.Height != negotiated.Height ||
+		current.PixelFormat != negotiated.PixelFormat ||
+		current.SizeImage != negotiated.SizeImage {
+		return fmt.Errorf("device: %s: %w: negotiated %dx%d, current %dx%d", d.path, v4l2.ErrFormatChanged,
+			negotiated.Width, negotiated.Height, current.Width, current.Height)
+	}
+	return nil
+}
+
+func (d *Device) setErr(err error) {
+	d.mu.Lock()
+	d.err = err
+	d.mu.Unlock()
+	if err != nil {
+		d.reportErr(err)
+	}
+	if d.health != nil && err != nil {
+		d.health.set(false, err.Error())
+	}
+}
+
+// errorsBufSize is the capacity of the streaming error channel (see Errors)
+const errorsBufSize = 8
+
+// fail stops the stream on a fatal error, reported by Err and Errors
+func (d *Device) fail(err error) {
+	d.setErr(d.checkDisconnected(err))
+	d.stop()
+}
+
+// checkDisconnected returns an error wrapping v4l2.ErrDeviceGone when err is an I/O error
+// (EIO) caused by the removal of the device (its node is gone), err otherwise.
+func (d *Device) checkDisconnected(err error) error {
+	if errors.Is(err, v4l2.ErrDeviceGone) || !errors.Is(err, sys.EIO) {
+		return err
+	}
+	if _, statErr := os.Stat(d.path); !os.IsNotExist(statErr) {
+		return err
+	}
+	return fmt.Errorf("%v: %w", err, v4l2.ErrDeviceGone)
+}
+
+// reportErr delivers err on the streaming error channel, unless it is closed or full
+func (d *Device) reportErr(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.errsOpen {
+		return
+	}
+	select {
+	case d.errs <- err:
+	default:
+	}
+}
+
+// closeErrors closes the streaming error channel, when the stream loop exits
+func (d *Device) closeErrors() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.errsOpen {
+		close(d.errs)
+		d.errsOpen = false
+	}
+}
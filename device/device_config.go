@@ -1,19 +1,93 @@
 package device
 
 import (
+	"time"
+
 	"github.com/vladimirvivien/go4vl/v4l2"
+	sys "golang.org/x/sys/unix"
 )
 
 type config struct {
+	openFlags        int
+	readOnlyFallback bool
+	nonBlocking      bool
+
 	ioType    v4l2.IOType
 	pixFormat v4l2.PixFormat
 	bufSize   uint32
+	minBufs   uint32
 	fps       uint32
 	bufType   uint32
+
+	startupSkip     int
+	readSize        int
+	rawBufferInfo   bool
+	lumaStats       bool
+	autoAlign       bool
+	outOfOrderCheck bool
+
+	dmabufFds    []int
+	dmabufExport bool
+	cacheSync    CacheSyncMode
+	userBufs     [][]byte
+
+	inputSet        bool
+	input           int32
+	controls        map[v4l2.CtrlID]v4l2.CtrlValue
+	persistControls bool
+
+	squareOutput int
+	pacingFPS    float64
+	weaveFields  bool
+	imageReuse   bool
+
+	preStreamHook PreStreamHook
+
+	healthCallback  HealthFunc
+	watchdogTimeout time.Duration
+	captureTimeout  time.Duration
+
+	autoExposure       bool
+	autoExposureTarget uint8
+	autoExposureTuning AutoExposureTuning
 }
 
 type Option func(*config)
 
+// WithOpenFlags sets the flags used to open the device (i.e. syscall.O_RDONLY), instead of
+// the default O_RDWR|O_NONBLOCK. O_CLOEXEC is always added so that the device descriptor
+// is not inherited by child processes (i.e. an ffmpeg subprocess). Combine it with
+// WithNonBlocking to keep the device non-blocking.
+func WithOpenFlags(flags int) Option {
+	return func(o *config) {
+		o.openFlags = flags
+	}
+}
+
+// WithNonBlocking adds O_NONBLOCK to the flags used to open the device, including flags set
+// with WithOpenFlags, so that opening a device held by another process does not hang. In
+// non-blocking mode, dequeuing a buffer that is not ready fails with EAGAIN instead of
+// waiting: the stream loops (and CaptureOne, Write) poll the device for readiness before
+// dequeuing, the memory mapped buffers and Fd are unaffected.
+func WithNonBlocking() Option {
+	return func(o *config) {
+		o.nonBlocking = true
+	}
+}
+
+// WithReadOnlyFallback, when enabled, opens the device read-only if opening it read-write
+// is denied (EACCES), i.e. in containers with restricted device access. A device opened
+// read-only can be queried (capability, formats, controls) but not configured nor streamed
+// from, see Device.IsReadOnly.
+func WithReadOnlyFallback(enabled bool) Option {
+	return func(o *config) {
+		o.readOnlyFallback = enabled
+	}
+}
+
+// WithIOType sets the IO method used to capture frames. When not set, the device uses memory
+// mapped streaming IO (v4l2.IOTypeMMAP), or falls back to the read/write IO method
+// (v4l2.IOTypeReadWrite) if the device does not support streaming.
 func WithIOType(ioType v4l2.IOType) Option {
 	return func(o *config) {
 		o.ioType = ioType
@@ -26,18 +100,238 @@ func WithPixFormat(pixFmt v4l2.PixFormat) Option {
 	}
 }
 
+// WithFormatAutoAlign rounds the dimensions of formats set on the device (see WithPixFormat
+// and Device.SetPixFormat) down to the alignment required by chroma subsampled formats (even
+// widths for YUYV, even widths and heights for NV12), logging the adjustment. Without it,
+// misaligned dimensions are rejected with an error wrapping v4l2.ErrFormatAlignment.
+func WithFormatAutoAlign() Option {
+	return func(o *config) {
+		o.autoAlign = true
+	}
+}
+
+// WithBufferSize sets the number of streaming buffers requested from the driver, which may
+// grant a different number (see Device.BufferCount).
 func WithBufferSize(size uint32) Option {
 	return func(o *config) {
 		o.bufSize = size
 	}
 }
 
+// WithMinimumBuffers sets the minimum number of streaming buffers the driver must grant:
+// Start fails with v4l2.ErrInsufficientBuffers when fewer buffers are allocated, rather
+// than streaming with a starved queue.
+func WithMinimumBuffers(n uint32) Option {
+	return func(o *config) {
+		o.minBufs = n
+	}
+}
+
 func WithFPS(fps uint32) Option {
 	return func(o *config) {
 		o.fps = fps
 	}
 }
 
+// WithStartupSkip drops the first n frames dequeued after the stream starts. The buffers
+// are re-queued without being delivered, giving the sensor auto exposure and white balance
+// time to settle so that the first delivered frame is usable.
+func WithStartupSkip(n int) Option {
+	return func(o *config) {
+		o.startupSkip = n
+	}
+}
+
+// WithReadSize sets the number of bytes requested by each read when the device uses the
+// read/write IO method (see v4l2.IOTypeReadWrite). It defaults to the image size of the
+// current format and cannot be smaller than that size.
+func WithReadSize(n int) Option {
+	return func(o *config) {
+		o.readSize = n
+	}
+}
+
+// WithVideoInput selects the video input (see Device.GetVideoInputInfo) when the device
+// is opened. The input is selected before the format is set since switching inputs may
+// reset the format.
+func WithVideoInput(index uint32) Option {
+	return func(o *config) {
+		o.inputSet = true
+		o.input = int32(index)
+	}
+}
+
+// WithControls sets control values when the device is opened, after the format and
+// frame rate are applied. Auto/mode controls (i.e. CtrlCameraExposureAuto, CtrlCameraFocusAuto,
+// CtrlAutoWhiteBalance) are set first, so that their manual counterparts can then be set,
+// the other controls follow in ascending ID order.
+func WithControls(ctrls map[v4l2.CtrlID]v4l2.CtrlValue) Option {
+	return func(o *config) {
+		if o.controls == nil {
+			o.controls = make(map[v4l2.CtrlID]v4l2.CtrlValue, len(ctrls))
+		}
+		for id, val := range ctrls {
+			o.controls[id] = val
+		}
+	}
+}
+
+// WithPersistControls, when enabled, remembers the control values set on the device
+// (see Device.SetControlValue) and sets them again, after the controls of WithControls,
+// when the device is reconfigured or reopened (see Device.Reconfigure and Device.Reopen).
+// Drivers may reset controls to their defaults on a format change or when reconnected.
+func WithPersistControls(enabled bool) Option {
+	return func(o *config) {
+		o.persistControls = enabled
+	}
+}
+
+// WithRawBufferInfo, when enabled, populates v4l2.Frame.Raw with the complete buffer
+// information (see v4l2_buffer) dequeued for each delivered frame.
+func WithRawBufferInfo(enabled bool) Option {
+	return func(o *config) {
+		o.rawBufferInfo = enabled
+	}
+}
+
+// WithLumaStats, when enabled, computes the mean luma and a coarse luma histogram of each
+// delivered frame (v4l2.Frame.LumaMean and LumaHistogram) by sampling the luma plane of raw
+// YUV and greyscale formats, without decoding the frame, or of decoded JPEG frames (see v4l2.LumaStats).
+func WithLumaStats(enabled bool) Option {
+	return func(o *config) {
+		o.lumaStats = enabled
+	}
+}
+
+// WithDMABufImport uses the external dma-buf file descriptors (i.e. exported by a GPU or
+// an encoder) as capture buffers, one device buffer per descriptor, and selects the
+// v4l2.IOTypeDMABuf IO type. Frames are captured directly into the dma-bufs: delivered
+// frames carry no data, their Index identifies the descriptor (fds[frame.Index]) holding
+// the image until the next frames are captured into it.
+func WithDMABufImport(fds ...int) Option {
+	return func(o *config) {
+		o.ioType = v4l2.IOTypeDMABuf
+		o.dmabufFds = fds
+	}
+}
+
+// WithDMABufExport, when enabled, exports the memory mapped capture buffers as dma-buf file
+// descriptors when the stream starts (see Device.ExportedBuffers), for zero-copy sharing with
+// a GPU or an encoder. As with WithDMABufImport, delivered frames carry no data: their Index
+// identifies the descriptor holding the image, also reported by Device.GetOutputBuffers.
+func WithDMABufExport(enabled bool) Option {
+	return func(o *config) {
+		o.dmabufExport = enabled
+	}
+}
+
+// WithUserBuffers uses the user allocated memory buffers (i.e. from a pre-allocated pool)
+// as capture buffers, one device buffer per memory buffer, and selects the v4l2.IOTypeUserPtr
+// IO type. Each buffer must hold an image of the negotiated format and be page aligned (see
+// v4l2.ValidateUserBuffer), which is checked when the stream starts. Frames are captured
+// directly into the buffers, the data of delivered frames is copied from them.
+func WithUserBuffers(bufs ...[]byte) Option {
+	return func(o *config) {
+		o.ioType = v4l2.IOTypeUserPtr
+		o.userBufs = bufs
+	}
+}
+
+// WithDMABufCacheSync sets the cache synchronization performed by the kernel when imported
+// dma-bufs are queued (see WithDMABufImport). Skipping synchronization is faster on platforms
+// with coherent memory, but required for correctness on others. Drivers may ignore the hints.
+func WithDMABufCacheSync(mode CacheSyncMode) Option {
+	return func(o *config) {
+		o.cacheSync = mode
+	}
+}
+
+// WithSquareOutput center-crops the images delivered by Device.Images to a square, then
+// resizes them to size x size (see v4l2.CenterCropResize), as expected by most vision models.
+func WithSquareOutput(size int) Option {
+	return func(o *config) {
+		o.squareOutput = size
+	}
+}
+
+// WithImageReuse, when enabled, converts the images delivered by Device.Images to RGBA
+// into two preallocated *image.RGBA used alternately, instead of allocating an image per
+// frame. An image received from the channel is only valid until the next image is received:
+// it is then overwritten with the following frame. Consumers that need to keep an image
+// longer must copy it.
+func WithImageReuse(enabled bool) Option {
+	return func(o *config) {
+		o.imageReuse = enabled
+	}
+}
+
+// WithWeaveFields, when enabled and the device streams alternating fields (v4l2.FieldAlternate),
+// pairs the top and bottom field buffers of each frame (same sequence) and delivers a single
+// full-height frame with the fields interleaved (see v4l2.WeaveFields). A field whose pair was
+// dropped is discarded.
+func WithWeaveFields(enabled bool) Option {
+	return func(o *config) {
+		o.weaveFields = enabled
+	}
+}
+
+// WithPacing delivers frames evenly spaced at the target rate (frames per second), smoothing
+// drivers that deliver frames in bursts. Frames are briefly held and released on schedule:
+// when frames arrive faster than the target rate the oldest are dropped, and when no frame
+// arrived in time the last frame is delivered again (sharing its data). Unlike a rate
+// limit, pacing aims at even spacing between delivered frames.
+func WithPacing(fps float64) Option {
+	return func(o *config) {
+		o.pacingFPS = fps
+	}
+}
+
+// WithPreStreamHook calls hook each time the stream is started, before the buffers are
+// allocated and the stream turned on, after the workaround registered for the device, if
+// any (see RegisterQuirk). It lets users prepare quirky devices for streaming.
+func WithPreStreamHook(hook PreStreamHook) Option {
+	return func(o *config) {
+		o.preStreamHook = hook
+	}
+}
+
+// WithHealthCallback calls fn when the stream transitions between healthy (frames are
+// delivered) and unhealthy: no frame was delivered within the watchdog timeout (see
+// WithWatchdogTimeout), or the stream stopped on an error (see Device.Err). The callback
+// can back a liveness probe. It is called from the streaming goroutines and should not block.
+func WithHealthCallback(fn HealthFunc) Option {
+	return func(o *config) {
+		o.healthCallback = fn
+	}
+}
+
+// WithWatchdogTimeout sets the time without a delivered frame after which the stream
+// is reported unhealthy, DefaultWatchdogTimeout if not set.
+func WithWatchdogTimeout(timeout time.Duration) Option {
+	return func(o *config) {
+		o.watchdogTimeout = timeout
+	}
+}
+
+// WithCaptureTimeout stops the stream when no frame is dequeued within timeout (i.e. a
+// stalled USB camera): the frame channels are closed and Device.Err returns an error wrapping
+// v4l2.ErrTimeout, so that a supervisor can restart the device. The timeout does not elapse
+// while the stream is paused or blocked on a slow consumer. Disabled (zero) by default.
+func WithCaptureTimeout(timeout time.Duration) Option {
+	return func(o *config) {
+		o.captureTimeout = timeout
+	}
+}
+
+// WithOutOfOrderCheck compares the sequence of each dequeued frame with the previously
+// delivered frame, flagging v4l2.Frame.OutOfOrder and counting Stats.FramesOutOfOrder
+// when the sequence goes backward. This helps diagnose misbehaving drivers.
+func WithOutOfOrderCheck() Option {
+	return func(o *config) {
+		o.outOfOrderCheck = true
+	}
+}
+
 func WithVideoCaptureEnabled() Option {
 	return func(o *config) {
 		o.bufType = v4l2.BufTypeVideoCapture
@@ -49,3 +343,165 @@ func WithVideoOutputEnabled() Option {
 		o.bufType = v4l2.BufTypeVideoOutput
 	}
 }
+
+// WithSoftwareAutoExposure enables a software control loop that computes the mean luma
+// of each captured frame and adjusts the exposure (and gain) controls to reach the target
+// brightness (0-255). It is meant for sensors without hardware auto exposure and requires
+// the device to support control CtrlCameraExposureAbsolute. Mean luma is computed for the
+// formats supported by v4l2.LumaStats (packed and planar YUV, greyscale and JPEG frames).
+func WithSoftwareAutoExposure(target uint8) Option {
+	return func(o *config) {
+		o.autoExposure = true
+		o.autoExposureTarget = target
+	}
+}
+
+// WithSoftwareAutoExposureTuning sets the gains and rate limit of the software auto
+// exposure control loop (see DefaultAutoExposureTuning).
+func WithSoftwareAutoExposureTuning(tuning AutoExposureTuning) Option {
+	return func(o *config) {
+		o.autoExposureTuning = tuning
+	}
+}
+
+// deviceOpenFlags returns the flags used to open the device: the flags set with WithOpenFlags
+// (O_RDWR|O_NONBLOCK by default), with O_NONBLOCK when set with WithNonBlocking, and O_CLOEXEC.
+func (c config) deviceOpenFlags() int {
+	flags := c.openFlags
+	if flags == 0 {
+		flags = sys.O_RDWR | sys.O_NONBLOCK
+	}
+	if c.nonBlocking {
+		flags |= sys.O_NONBLOCK
+	}
+	return flags | sys.O_CLOEXEC
+}
+
+// Config is a declarative equivalent of the functional options, meant to be loaded from
+// configuration files (i.e. JSON or YAML). Zero-valued fields are left to the driver
+// defaults. See OpenConfig.
+type Config struct {
+	IOType       v4l2.IOType                    `json:"ioType,omitempty" yaml:"ioType,omitempty"`
+	PixFormat    v4l2.PixFormat                 `json:"pixFormat,omitempty" yaml:"pixFormat,omitempty"`
+	FPS          uint32                         `json:"fps,omitempty" yaml:"fps,omitempty"`
+	BufferSize   uint32                         `json:"bufferSize,omitempty" yaml:"bufferSize,omitempty"`
+	MinBuffers   uint32                         `json:"minBuffers,omitempty" yaml:"minBuffers,omitempty"`
+	Input        *uint32                        `json:"input,omitempty" yaml:"input,omitempty"`
+	Controls     map[v4l2.CtrlID]v4l2.CtrlValue `json:"controls,omitempty" yaml:"controls,omitempty"`
+	StartupSkip  int                            `json:"startupSkip,omitempty" yaml:"startupSkip,omitempty"`
+	ReadSize     int                            `json:"readSize,omitempty" yaml:"readSize,omitempty"`
+	Pacing       float64                        `json:"pacing,omitempty" yaml:"pacing,omitempty"`
+	SquareOutput int                            `json:"squareOutput,omitempty" yaml:"squareOutput,omitempty"`
+	OpenFlags    int                            `json:"openFlags,omitempty" yaml:"openFlags,omitempty"`
+
+	// SoftwareAutoExposure is the target brightness of the software auto exposure
+	// (see WithSoftwareAutoExposure), which is disabled when nil
+	SoftwareAutoExposure *uint8 `json:"softwareAutoExposure,omitempty" yaml:"softwareAutoExposure,omitempty"`
+	// AutoExposureTuning tunes the software auto exposure (see WithSoftwareAutoExposureTuning),
+	// DefaultAutoExposureTuning is used when nil
+	AutoExposureTuning *AutoExposureTuning `json:"autoExposureTuning,omitempty" yaml:"autoExposureTuning,omitempty"`
+
+	// DMABufCacheSync is the cache synchronization of imported dma-bufs (see WithDMABufCacheSync)
+	DMABufCacheSync CacheSyncMode `json:"dmabufCacheSync,omitempty" yaml:"dmabufCacheSync,omitempty"`
+
+	CaptureTimeout  time.Duration `json:"captureTimeout,omitempty" yaml:"captureTimeout,omitempty"`
+	WatchdogTimeout time.Duration `json:"watchdogTimeout,omitempty" yaml:"watchdogTimeout,omitempty"`
+
+	ReadOnlyFallback bool `json:"readOnlyFallback,omitempty" yaml:"readOnlyFallback,omitempty"`
+	NonBlocking      bool `json:"nonBlocking,omitempty" yaml:"nonBlocking,omitempty"`
+	RawBufferInfo    bool `json:"rawBufferInfo,omitempty" yaml:"rawBufferInfo,omitempty"`
+	ImageReuse       bool `json:"imageReuse,omitempty" yaml:"imageReuse,omitempty"`
+	WeaveFields      bool `json:"weaveFields,omitempty" yaml:"weaveFields,omitempty"`
+	LumaStats        bool `json:"lumaStats,omitempty" yaml:"lumaStats,omitempty"`
+	FormatAutoAlign  bool `json:"formatAutoAlign,omitempty" yaml:"formatAutoAlign,omitempty"`
+	OutOfOrderCheck  bool `json:"outOfOrderCheck,omitempty" yaml:"outOfOrderCheck,omitempty"`
+	PersistControls  bool `json:"persistControls,omitempty" yaml:"persistControls,omitempty"`
+	DMABufExport     bool `json:"dmabufExport,omitempty" yaml:"dmabufExport,omitempty"`
+}
+
+// Options returns the functional options equivalent to the configuration.
+func (c Config) Options() []Option {
+	var opts []Option
+	if c.OpenFlags != 0 {
+		opts = append(opts, WithOpenFlags(c.OpenFlags))
+	}
+	if c.ReadOnlyFallback {
+		opts = append(opts, WithReadOnlyFallback(true))
+	}
+	if c.NonBlocking {
+		opts = append(opts, WithNonBlocking())
+	}
+	if c.IOType != 0 {
+		opts = append(opts, WithIOType(c.IOType))
+	}
+	if c.PixFormat != (v4l2.PixFormat{}) {
+		opts = append(opts, WithPixFormat(c.PixFormat))
+	}
+	if c.FPS != 0 {
+		opts = append(opts, WithFPS(c.FPS))
+	}
+	if c.BufferSize != 0 {
+		opts = append(opts, WithBufferSize(c.BufferSize))
+	}
+	if c.MinBuffers != 0 {
+		opts = append(opts, WithMinimumBuffers(c.MinBuffers))
+	}
+	if c.Input != nil {
+		opts = append(opts, WithVideoInput(*c.Input))
+	}
+	if len(c.Controls) > 0 {
+		opts = append(opts, WithControls(c.Controls))
+	}
+	if c.StartupSkip != 0 {
+		opts = append(opts, WithStartupSkip(c.StartupSkip))
+	}
+	if c.ReadSize != 0 {
+		opts = append(opts, WithReadSize(c.ReadSize))
+	}
+	if c.Pacing != 0 {
+		opts = append(opts, WithPacing(c.Pacing))
+	}
+	if c.SquareOutput != 0 {
+		opts = append(opts, WithSquareOutput(c.SquareOutput))
+	}
+	if c.SoftwareAutoExposure != nil {
+		opts = append(opts, WithSoftwareAutoExposure(*c.SoftwareAutoExposure))
+	}
+	if c.AutoExposureTuning != nil {
+		opts = append(opts, WithSoftwareAutoExposureTuning(*c.AutoExposureTuning))
+	}
+	if c.DMABufCacheSync != CacheSyncFull {
+		opts = append(opts, WithDMABufCacheSync(c.DMABufCacheSync))
+	}
+	if c.CaptureTimeout != 0 {
+		opts = append(opts, WithCaptureTimeout(c.CaptureTimeout))
+	}
+	if c.WatchdogTimeout != 0 {
+		opts = append(opts, WithWatchdogTimeout(c.WatchdogTimeout))
+	}
+	if c.RawBufferInfo {
+		opts = append(opts, WithRawBufferInfo(true))
+	}
+	if c.ImageReuse {
+		opts = append(opts, WithImageReuse(true))
+	}
+	if c.WeaveFields {
+		opts = append(opts, WithWeaveFields(true))
+	}
+	if c.LumaStats {
+		opts = append(opts, WithLumaStats(true))
+	}
+	if c.FormatAutoAlign {
+		opts = append(opts, WithFormatAutoAlign())
+	}
+	if c.OutOfOrderCheck {
+		opts = append(opts, WithOutOfOrderCheck())
+	}
+	if c.PersistControls {
+		opts = append(opts, WithPersistControls(true))
+	}
+	if c.DMABufExport {
+		opts = append(opts, WithDMABufExport(true))
+	}
+	return opts
+}
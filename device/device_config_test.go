@@ -0,0 +1,121 @@
+package device
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+	sys "golang.org/x/sys/unix"
+)
+
+func TestConfigOptions(t *testing.T) {
+	data := []byte(`{
+		"pixFormat": {"Width": 640, "Height": 480, "PixelFormat": 1196444237},
+		"fps": 30,
+		"input": 1,
+		"controls": {"9963776": 128},
+		"openFlags": 2,
+		"softwareAutoExposure": 110,
+		"autoExposureTuning": {"ExposureGain": 0.5, "Tolerance": 4},
+		"dmabufCacheSync": 3,
+		"watchdogTimeout": 2000000000,
+		"dmabufExport": true
+	}`)
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	var c config
+	for _, o := range cfg.Options() {
+		o(&c)
+	}
+
+	if c.pixFormat.Width != 640 || c.pixFormat.Height != 480 || c.pixFormat.PixelFormat != v4l2.PixelFmtMJPEG {
+		t.Errorf("unexpected format: %#v", c.pixFormat)
+	}
+	if c.fps != 30 {
+		t.Errorf("expecting fps 30, got %d", c.fps)
+	}
+	if !c.inputSet || c.input != 1 {
+		t.Errorf("expecting input 1, got %d (set: %t)", c.input, c.inputSet)
+	}
+	if c.controls[v4l2.CtrlBrightness] != 128 {
+		t.Errorf("expecting brightness 128, got %v", c.controls)
+	}
+	if c.openFlags != sys.O_RDWR {
+		t.Errorf("expecting O_RDWR open flags, got %#x", c.openFlags)
+	}
+	if !c.autoExposure || c.autoExposureTarget != 110 {
+		t.Errorf("expecting software auto exposure to 110, got %d (enabled: %t)", c.autoExposureTarget, c.autoExposure)
+	}
+	if c.autoExposureTuning.ExposureGain != 0.5 || c.autoExposureTuning.Tolerance != 4 {
+		t.Errorf("unexpected auto exposure tuning: %#v", c.autoExposureTuning)
+	}
+	if c.cacheSync != CacheSyncNone {
+		t.Errorf("expecting no dma-buf cache sync, got %d", c.cacheSync)
+	}
+	if c.watchdogTimeout != 2*time.Second {
+		t.Errorf("expecting 2s watchdog timeout, got %v", c.watchdogTimeout)
+	}
+	if !c.dmabufExport {
+		t.Error("expecting DMABUF export")
+	}
+	if c.ioType != 0 || c.bufSize != 0 {
+		t.Errorf("expecting unset fields to stay zero: %#v", c)
+	}
+}
+
+func TestBufferCountReadback(t *testing.T) {
+	dev := openVivid(t, WithBufferSize(3))
+	defer dev.Close()
+
+	if count := dev.BufferCount(); count != 3 {
+		t.Fatalf("expecting requested count 3 before start, got %d", count)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := dev.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+	if count := dev.BufferCount(); count == 0 || count != dev.requestedBuf.Count {
+		t.Errorf("expecting granted count %d, got %d", dev.requestedBuf.Count, count)
+	}
+}
+
+func TestMinimumBuffers(t *testing.T) {
+	dev := openVivid(t, WithBufferSize(2), WithMinimumBuffers(1000))
+	defer dev.Close()
+
+	err := dev.Start(context.Background())
+	if !errors.Is(err, v4l2.ErrInsufficientBuffers) {
+		t.Fatalf("expecting ErrInsufficientBuffers, got %v", err)
+	}
+}
+
+func TestNonBlockingOpenFlags(t *testing.T) {
+	c := config{openFlags: sys.O_RDWR}
+	if c.deviceOpenFlags()&sys.O_NONBLOCK != 0 {
+		t.Error("expecting blocking open flags")
+	}
+	WithNonBlocking()(&c)
+	if flags := c.deviceOpenFlags(); flags&sys.O_NONBLOCK == 0 || flags&sys.O_ACCMODE != sys.O_RDWR {
+		t.Errorf("unexpected non-blocking open flags: %#x", flags)
+	}
+}
+
+func TestControlOrder(t *testing.T) {
+	ids := []v4l2.CtrlID{v4l2.CtrlHue, v4l2.CtrlCameraFocusAbsolute, v4l2.CtrlHueAuto, v4l2.CtrlCameraFocusAuto}
+	sortControlIDs(ids)
+	want := []v4l2.CtrlID{v4l2.CtrlHueAuto, v4l2.CtrlCameraFocusAuto, v4l2.CtrlHue, v4l2.CtrlCameraFocusAbsolute}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("unexpected control order: %v, want %v", ids, want)
+		}
+	}
+}
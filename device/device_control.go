@@ -1,12 +1,16 @@
 package device
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/vladimirvivien/go4vl/v4l2"
 )
 
-// GetControl queries the device for information about the specified control id.
+// GetControl queries the device for information about the specified control id, along
+// with its current value. It returns an error wrapping v4l2.ErrControlNotSupported when
+// the device does not support the control, which lets callers probe for controls.
 func (d *Device) GetControl(ctrlID v4l2.CtrlID) (v4l2.Control, error) {
 	ctlr, err := v4l2.GetControl(d.fd, ctrlID)
 	if err != nil {
@@ -15,15 +19,50 @@ func (d *Device) GetControl(ctrlID v4l2.CtrlID) (v4l2.Control, error) {
 	return ctlr, nil
 }
 
-// SetControlValue updates the value of the specified control id.
+// SetControlValue updates the value of the specified control id. With WithPersistControls,
+// the value is remembered and set again after Reconfigure or Reopen.
 func (d *Device) SetControlValue(ctrlID v4l2.CtrlID, val v4l2.CtrlValue) error {
 	err := v4l2.SetControlValue(d.fd, ctrlID, val)
 	if err != nil {
 		return fmt.Errorf("device: %s: %w", d.path, err)
 	}
+	if d.config.persistControls {
+		d.mu.Lock()
+		if d.controls == nil {
+			d.controls = make(map[v4l2.CtrlID]v4l2.CtrlValue)
+		}
+		d.controls[ctrlID] = val
+		d.mu.Unlock()
+	}
 	return nil
 }
 
+// GetControlInfo returns the type, range (minimum, maximum and step) and default value of
+// the control, without its current value, which can be used to validate a value before
+// setting it (see also v4l2.ControlInfo.ValueFromFraction).
+func (d *Device) GetControlInfo(id v4l2.CtrlID) (v4l2.ControlInfo, error) {
+	info, err := v4l2.QueryControlInfo(d.fd, id)
+	if err != nil {
+		return v4l2.ControlInfo{}, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return info, nil
+}
+
+// QueryControlMenu returns the items of a menu (or integer menu) control, for each valid
+// index between the control minimum and maximum. Items of integer menus are named after
+// their value (see also v4l2.GetIntegerMenuValue).
+func (d *Device) QueryControlMenu(id v4l2.CtrlID) ([]v4l2.ControlMenuItem, error) {
+	ctrl, err := v4l2.QueryControlInfo(d.fd, id)
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: control menu: %w", d.path, err)
+	}
+	items, err := ctrl.GetMenuItems()
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: control menu: %w", d.path, err)
+	}
+	return items, nil
+}
+
 // QueryAllControls fetches all supported device controls and their current values.
 func (d *Device) QueryAllControls() ([]v4l2.Control, error) {
 	ctrls, err := v4l2.QueryAllControls(d.fd)
@@ -33,6 +72,77 @@ func (d *Device) QueryAllControls() ([]v4l2.Control, error) {
 	return ctrls, nil
 }
 
+// QueryControls returns information about every control supported by the device, including
+// disabled and inactive controls (see v4l2.ControlInfo.IsDisabled and IsInactive), without
+// their current values. Menu items of menu controls can be listed with GetMenuItems.
+// Controls are queried with the extended control API so that compound and array controls
+// (see v4l2.ControlInfo.IsArray) are also reported.
+func (d *Device) QueryControls() ([]v4l2.ControlInfo, error) {
+	var result []v4l2.ControlInfo
+	next := v4l2.CtrlFlagNextControl | v4l2.CtrlFlagNextCompound
+	for cid := next; ; {
+		ctrl, err := v4l2.QueryExtControlInfo(d.fd, cid)
+		if err != nil {
+			if errors.Is(err, v4l2.ErrControlNotSupported) && len(result) > 0 {
+				break
+			}
+			return result, fmt.Errorf("device: %s: query controls: %w", d.path, err)
+		}
+		result = append(result, ctrl)
+		cid = ctrl.ID | next
+	}
+	return result, nil
+}
+
+// GetExtControls retrieves the values of several controls at once, storing them in ctrls.
+// The which argument selects the current (v4l2.CtrlWhichCurVal) or default (v4l2.CtrlWhichDefVal)
+// values. A failure attributed to a control can be inspected with v4l2.ExtControlError.
+func (d *Device) GetExtControls(which v4l2.CtrlWhich, ctrls []v4l2.ExtControl) error {
+	if err := v4l2.GetExtControls(d.fd, which, ctrls); err != nil {
+		return fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return nil
+}
+
+// SetExtControls applies the current values of several controls atomically, in a single
+// ioctl, so that related controls (i.e. codec bitrate and GOP size) change together. A failure
+// attributed to a control can be inspected with v4l2.ExtControlError. With WithPersistControls,
+// the values of 32-bit controls are remembered like with SetControlValue.
+func (d *Device) SetExtControls(ctrls []v4l2.ExtControl) error {
+	if err := v4l2.SetExtControls(d.fd, v4l2.CtrlWhichCurVal, ctrls); err != nil {
+		return fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	if !d.config.persistControls {
+		return nil
+	}
+
+	// only 32-bit controls are persisted, they are set again with SetControlValue
+	values := make(map[v4l2.CtrlID]v4l2.CtrlValue)
+	for _, ctrl := range ctrls {
+		if ctrl.Payload != nil {
+			continue
+		}
+		info, err := v4l2.QueryExtControlInfo(d.fd, ctrl.ID)
+		if err != nil || info.Type == v4l2.CtrlTypeInt64 {
+			continue
+		}
+		val := ctrl.Value
+		if ctrl.Value64 != 0 {
+			val = v4l2.CtrlValue(ctrl.Value64)
+		}
+		values[ctrl.ID] = val
+	}
+	d.mu.Lock()
+	if d.controls == nil {
+		d.controls = make(map[v4l2.CtrlID]v4l2.CtrlValue)
+	}
+	for id, val := range values {
+		d.controls[id] = val
+	}
+	d.mu.Unlock()
+	return nil
+}
+
 // SetControlBrightness is a convenience method for setting value for control v4l2.CtrlBrightness
 func (d *Device) SetControlBrightness(val v4l2.CtrlValue) error {
 	return d.SetControlValue(v4l2.CtrlBrightness, val)
@@ -52,3 +162,261 @@ func (d *Device) SetControlSaturation(val v4l2.CtrlValue) error {
 func (d *Device) SetControlHue(val v4l2.CtrlValue) error {
 	return d.SetControlValue(v4l2.CtrlHue, val)
 }
+
+// SetControlWhiteBalanceAuto enables or disables automatic white balance (control
+// v4l2.CtrlAutoWhiteBalance). It must be disabled to set the white balance temperature.
+func (d *Device) SetControlWhiteBalanceAuto(enabled bool) error {
+	return d.SetControlValue(v4l2.CtrlAutoWhiteBalance, boolCtrlValue(enabled))
+}
+
+// GetControlWhiteBalanceAuto returns whether automatic white balance is enabled.
+func (d *Device) GetControlWhiteBalanceAuto() (bool, error) {
+	val, err := v4l2.GetControlValue(d.fd, v4l2.CtrlAutoWhiteBalance)
+	if err != nil {
+		return false, fmt.Errorf("device: %s: white balance auto: %w", d.path, err)
+	}
+	return val != 0, nil
+}
+
+// SetControlWhiteBalanceTemperature sets the white balance temperature, in Kelvin (control
+// v4l2.CtrlWhiteBalanceTemperature). It returns an error wrapping v4l2.ErrControlAutoEnabled
+// if automatic white balance is enabled, since most drivers ignore (or reject) the value then
+// (see SetControlWhiteBalanceManual).
+func (d *Device) SetControlWhiteBalanceTemperature(val int32) error {
+	if auto, err := d.GetControlWhiteBalanceAuto(); err == nil && auto {
+		return fmt.Errorf("device: %s: white balance temperature: %w: disable auto white balance first", d.path, v4l2.ErrControlAutoEnabled)
+	}
+	return d.SetControlValue(v4l2.CtrlWhiteBalanceTemperature, val)
+}
+
+// SetControlWhiteBalanceManual disables automatic white balance, if enabled, then sets the
+// white balance temperature, in Kelvin (see SetControlWhiteBalanceTemperature).
+func (d *Device) SetControlWhiteBalanceManual(val int32) error {
+	if auto, err := d.GetControlWhiteBalanceAuto(); err == nil && auto {
+		if err := d.SetControlWhiteBalanceAuto(false); err != nil {
+			return err
+		}
+	}
+	return d.SetControlValue(v4l2.CtrlWhiteBalanceTemperature, val)
+}
+
+// GetControlWhiteBalanceTemperature returns the white balance temperature, in Kelvin.
+func (d *Device) GetControlWhiteBalanceTemperature() (int32, error) {
+	val, err := v4l2.GetControlValue(d.fd, v4l2.CtrlWhiteBalanceTemperature)
+	if err != nil {
+		return 0, fmt.Errorf("device: %s: white balance temperature: %w", d.path, err)
+	}
+	return val, nil
+}
+
+// SetControlExposureAuto selects the exposure mode (control v4l2.CtrlCameraExposureAuto),
+// i.e. v4l2.ExposureAutoManual for a deterministic exposure time.
+func (d *Device) SetControlExposureAuto(mode v4l2.ExposureAuto) error {
+	return d.SetControlValue(v4l2.CtrlCameraExposureAuto, v4l2.CtrlValue(mode))
+}
+
+// GetControlExposureAuto returns the current exposure mode.
+func (d *Device) GetControlExposureAuto() (v4l2.ExposureAuto, error) {
+	val, err := v4l2.GetControlValue(d.fd, v4l2.CtrlCameraExposureAuto)
+	if err != nil {
+		return 0, fmt.Errorf("device: %s: exposure auto: %w", d.path, err)
+	}
+	return v4l2.ExposureAuto(val), nil
+}
+
+// SetControlExposureAbsolute sets the exposure time, in units of 100µs (control
+// v4l2.CtrlCameraExposureAbsolute). It returns an error wrapping v4l2.ErrControlAutoEnabled
+// unless the exposure mode is v4l2.ExposureAutoManual or v4l2.ExposureAutoShutterPriority, the
+// modes in which the exposure time takes effect.
+func (d *Device) SetControlExposureAbsolute(val int32) error {
+	if mode, err := d.GetControlExposureAuto(); err == nil && mode != v4l2.ExposureAutoManual && mode != v4l2.ExposureAutoShutterPriority {
+		return fmt.Errorf("device: %s: exposure absolute: %w: select manual or shutter priority exposure first", d.path, v4l2.ErrControlAutoEnabled)
+	}
+	return d.SetControlValue(v4l2.CtrlCameraExposureAbsolute, val)
+}
+
+// GetControlExposureAbsolute returns the exposure time, in units of 100µs.
+func (d *Device) GetControlExposureAbsolute() (int32, error) {
+	val, err := v4l2.GetControlValue(d.fd, v4l2.CtrlCameraExposureAbsolute)
+	if err != nil {
+		return 0, fmt.Errorf("device: %s: exposure absolute: %w", d.path, err)
+	}
+	return val, nil
+}
+
+// SetControlExposureAutoPriority allows (true) or prevents (false) the device from lowering
+// the frame rate to lengthen the exposure time in automatic exposure modes (control
+// v4l2.CtrlCameraExposureAutoPriority). Disable it for a constant frame rate.
+func (d *Device) SetControlExposureAutoPriority(enabled bool) error {
+	return d.SetControlValue(v4l2.CtrlCameraExposureAutoPriority, boolCtrlValue(enabled))
+}
+
+// SetControlFocusAuto enables or disables continuous autofocus (control v4l2.CtrlCameraFocusAuto).
+// It must be disabled to set the focus distance.
+func (d *Device) SetControlFocusAuto(enabled bool) error {
+	return d.SetControlValue(v4l2.CtrlCameraFocusAuto, boolCtrlValue(enabled))
+}
+
+// SetControlFocusAbsolute sets the focus distance (control v4l2.CtrlCameraFocusAbsolute), in
+// driver-specific units (larger values focus further away). If the driver rejects the value
+// while continuous autofocus is enabled, the error wraps v4l2.ErrControlAutoEnabled.
+func (d *Device) SetControlFocusAbsolute(val int32) error {
+	err := d.SetControlValue(v4l2.CtrlCameraFocusAbsolute, val)
+	if err == nil || !(errors.Is(err, v4l2.ErrBusy) || errors.Is(err, v4l2.ErrorBadArgument)) {
+		return err
+	}
+	if auto, autoErr := v4l2.GetControlValue(d.fd, v4l2.CtrlCameraFocusAuto); autoErr == nil && auto != 0 {
+		return fmt.Errorf("device: %s: focus absolute: %w: disable autofocus first: %v", d.path, v4l2.ErrControlAutoEnabled, err)
+	}
+	return err
+}
+
+// GetControlFocusAbsolute returns the focus distance, in driver-specific units.
+func (d *Device) GetControlFocusAbsolute() (int32, error) {
+	val, err := v4l2.GetControlValue(d.fd, v4l2.CtrlCameraFocusAbsolute)
+	if err != nil {
+		return 0, fmt.Errorf("device: %s: focus absolute: %w", d.path, err)
+	}
+	return val, nil
+}
+
+// SetControlExposureMetering is a convenience method for setting the exposure metering
+// mode (control v4l2.CtrlCameraExposureMetering), i.e. v4l2.ExposureMeteringSpot.
+func (d *Device) SetControlExposureMetering(mode v4l2.ExposureMetering) error {
+	return d.SetControlValue(v4l2.CtrlCameraExposureMetering, v4l2.CtrlValue(mode))
+}
+
+// ListExposureMetering returns the exposure metering modes supported by the device
+// as the menu items of control v4l2.CtrlCameraExposureMetering.
+func (d *Device) ListExposureMetering() ([]v4l2.ControlMenuItem, error) {
+	ctrl, err := d.GetControl(v4l2.CtrlCameraExposureMetering)
+	if err != nil {
+		return nil, err
+	}
+	items, err := ctrl.GetMenuItems()
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: exposure metering: %w", d.path, err)
+	}
+	return items, nil
+}
+
+// GetControlLinkFreq returns the current CSI-2 link frequency [Hz] of the sensor, the
+// value of the selected item of integer menu control v4l2.CtrlImgProcLinkFreq.
+func (d *Device) GetControlLinkFreq() (int64, error) {
+	index, err := v4l2.GetControlValue(d.fd, v4l2.CtrlImgProcLinkFreq)
+	if err != nil {
+		return 0, fmt.Errorf("device: %s: link freq: %w", d.path, err)
+	}
+	freq, err := v4l2.GetIntegerMenuValue(d.fd, v4l2.CtrlImgProcLinkFreq, uint32(index))
+	if err != nil {
+		return 0, fmt.Errorf("device: %s: link freq: %w", d.path, err)
+	}
+	return freq, nil
+}
+
+// SetControlLinkFreqIndex selects the CSI-2 link frequency by its menu index (see the
+// menu items of control v4l2.CtrlImgProcLinkFreq). A faster link allows higher frame rates.
+func (d *Device) SetControlLinkFreqIndex(index uint32) error {
+	return d.SetControlValue(v4l2.CtrlImgProcLinkFreq, v4l2.CtrlValue(index))
+}
+
+// GetControlPixelRate returns the pixel rate [pixels/s] of the sensor, read from 64-bit
+// control v4l2.CtrlImgProcPixelRate.
+func (d *Device) GetControlPixelRate() (int64, error) {
+	rate, err := v4l2.GetExtControlValue64(d.fd, v4l2.CtrlImgProcPixelRate)
+	if err != nil {
+		return 0, fmt.Errorf("device: %s: pixel rate: %w", d.path, err)
+	}
+	return rate, nil
+}
+
+// SetControlIrisAbsolute is a convenience method for setting the iris aperture
+// (control v4l2.CtrlCameraIrisAbsolute), in units of 0.01 f-number.
+func (d *Device) SetControlIrisAbsolute(val int32) error {
+	return d.SetControlValue(v4l2.CtrlCameraIrisAbsolute, val)
+}
+
+// SetControlIrisRelative is a convenience method for opening (positive) or closing (negative)
+// the iris by the specified number of steps (control v4l2.CtrlCameraIrisRelative).
+func (d *Device) SetControlIrisRelative(val int32) error {
+	return d.SetControlValue(v4l2.CtrlCameraIrisRelative, val)
+}
+
+// SetControlAutoIris enables or disables automatic iris control. V4L2 has no dedicated
+// control for it: the iris is automatic in the v4l2.ExposureAutoAuto and
+// v4l2.ExposureAutoShutterPriority modes of control v4l2.CtrlCameraExposureAuto. The
+// exposure mode is switched accordingly, preserving whether the exposure time is automatic.
+func (d *Device) SetControlAutoIris(enabled bool) error {
+	mode, err := v4l2.GetControlValue(d.fd, v4l2.CtrlCameraExposureAuto)
+	if err != nil {
+		return fmt.Errorf("device: %s: auto iris: %w", d.path, err)
+	}
+
+	autoExposureTime := v4l2.ExposureAuto(mode) == v4l2.ExposureAutoAuto || v4l2.ExposureAuto(mode) == v4l2.ExposureAutoAperturePriority
+	var next v4l2.ExposureAuto
+	switch {
+	case enabled && autoExposureTime:
+		next = v4l2.ExposureAutoAuto
+	case enabled:
+		next = v4l2.ExposureAutoShutterPriority
+	case autoExposureTime:
+		next = v4l2.ExposureAutoAperturePriority
+	default:
+		next = v4l2.ExposureAutoManual
+	}
+	return d.SetControlValue(v4l2.CtrlCameraExposureAuto, v4l2.CtrlValue(next))
+}
+
+// SetControlWideDynamicRange enables or disables the wide dynamic range mode of the
+// camera (control v4l2.CtrlCameraWideDynamicRange), which improves backlit scenes.
+func (d *Device) SetControlWideDynamicRange(enabled bool) error {
+	return d.SetControlValue(v4l2.CtrlCameraWideDynamicRange, boolCtrlValue(enabled))
+}
+
+// GetHDRModeControl returns the menu control selecting the HDR mode of the sensor. V4L2 has
+// no standard control for it in the supported headers, the control is looked up by name:
+// the first menu control whose name contains "HDR" (i.e. "HDR Sensor Mode", or a vendor
+// control). It returns an error wrapping v4l2.ErrorUnsupportedFeature if there is none.
+func (d *Device) GetHDRModeControl() (v4l2.Control, error) {
+	ctrls, err := v4l2.QueryAllControls(d.fd)
+	if err != nil && len(ctrls) == 0 {
+		return v4l2.Control{}, fmt.Errorf("device: %s: hdr mode: %w", d.path, err)
+	}
+	for _, ctrl := range ctrls {
+		if ctrl.IsMenu() && strings.Contains(strings.ToUpper(ctrl.Name), "HDR") {
+			return ctrl, nil
+		}
+	}
+	return v4l2.Control{}, fmt.Errorf("device: %s: hdr mode: %w", d.path, v4l2.ErrorUnsupportedFeature)
+}
+
+// ListHDRModes returns the HDR modes supported by the sensor, the menu items of the HDR
+// mode control (see GetHDRModeControl).
+func (d *Device) ListHDRModes() ([]v4l2.ControlMenuItem, error) {
+	ctrl, err := d.GetHDRModeControl()
+	if err != nil {
+		return nil, err
+	}
+	items, err := ctrl.GetMenuItems()
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: hdr mode: %w", d.path, err)
+	}
+	return items, nil
+}
+
+// SetHDRMode selects the HDR mode of the sensor by menu item index (see ListHDRModes).
+func (d *Device) SetHDRMode(index uint32) error {
+	ctrl, err := d.GetHDRModeControl()
+	if err != nil {
+		return err
+	}
+	return d.SetControlValue(ctrl.ID, v4l2.CtrlValue(index))
+}
+
+// boolCtrlValue returns the value of a boolean control
+func boolCtrlValue(enabled bool) v4l2.CtrlValue {
+	if enabled {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,184 @@
+package device
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// openVivid opens the first vivid (virtual video test driver) capture device, skipping
+// the test when none is loaded.
+func openVivid(t *testing.T, options ...Option) *Device {
+	paths, err := GetAllDevicePaths()
+	if err != nil {
+		t.Skipf("no devices: %s", err)
+	}
+	for _, path := range paths {
+		info, err := v4l2.GetDeviceInfo(path)
+		if err != nil || info.Driver != "vivid" || !info.IsVideoCaptureSupported() {
+			continue
+		}
+		dev, err := Open(path, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dev
+	}
+	t.Skip("vivid driver not loaded")
+	return nil
+}
+
+func TestControlRoundTrip(t *testing.T) {
+	dev := openVivid(t)
+	defer dev.Close()
+
+	ctrl, err := dev.GetControl(v4l2.CtrlBrightness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	val := ctrl.Minimum
+	if ctrl.Value == val {
+		val = ctrl.Maximum
+	}
+	if err := dev.SetControlValue(v4l2.CtrlBrightness, val); err != nil {
+		t.Fatal(err)
+	}
+	if ctrl, err = dev.GetControl(v4l2.CtrlBrightness); err != nil || ctrl.Value != val {
+		t.Errorf("expecting brightness %d, got %d (%v)", val, ctrl.Value, err)
+	}
+
+	if _, err := dev.GetControl(v4l2.CtrlID(0x00980fff)); !errors.Is(err, v4l2.ErrControlNotSupported) {
+		t.Errorf("expecting control not supported, got %v", err)
+	}
+}
+
+func TestQueryControlsArray(t *testing.T) {
+	dev := openVivid(t)
+	defer dev.Close()
+
+	ctrls, err := dev.QueryControls()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ctrl := range ctrls {
+		if ctrl.IsArray() {
+			if len(ctrl.Dimensions()) == 0 || ctrl.ElementCount() < ctrl.Dimensions()[0] {
+				t.Errorf("control %s: unexpected layout %v (%d elements)", ctrl.Name, ctrl.Dimensions(), ctrl.ElementCount())
+			}
+			return
+		}
+	}
+	t.Error("expecting vivid to report an array control")
+}
+
+func TestExtControlsBatch(t *testing.T) {
+	dev := openVivid(t)
+	defer dev.Close()
+
+	defaults := []v4l2.ExtControl{{ID: v4l2.CtrlBrightness}, {ID: v4l2.CtrlContrast}}
+	if err := dev.GetExtControls(v4l2.CtrlWhichDefVal, defaults); err != nil {
+		t.Fatal(err)
+	}
+	if err := dev.SetExtControls(defaults); err != nil {
+		t.Fatal(err)
+	}
+
+	var ctrlErr *v4l2.ExtControlError
+	err := dev.SetExtControls([]v4l2.ExtControl{{ID: v4l2.CtrlBrightness}, {ID: v4l2.CtrlID(0x00980fff)}})
+	if !errors.As(err, &ctrlErr) {
+		t.Fatalf("expecting ext control error, got %v", err)
+	}
+}
+
+func TestExtControlString(t *testing.T) {
+	dev := openVivid(t)
+	defer dev.Close()
+
+	ctrls, err := dev.QueryControls()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range ctrls {
+		if info.Type != v4l2.CtrlTypeString || info.IsReadOnly() {
+			continue
+		}
+		val := strings.Repeat("a", int(info.Minimum))
+		ctrl, err := v4l2.NewExtControlString(info, val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := dev.SetExtControls([]v4l2.ExtControl{ctrl}); err != nil {
+			t.Fatal(err)
+		}
+		got := []v4l2.ExtControl{{ID: info.ID}}
+		if err := dev.GetExtControls(v4l2.CtrlWhichCurVal, got); err != nil {
+			t.Fatal(err)
+		}
+		if got[0].String() != val {
+			t.Errorf("expecting string %q, got %q", val, got[0].String())
+		}
+		return
+	}
+	t.Skip("no writable string control")
+}
+
+func TestExposureControls(t *testing.T) {
+	dev := openVivid(t)
+	defer dev.Close()
+
+	if err := dev.SetControlExposureAuto(v4l2.ExposureAutoManual); err != nil {
+		if errors.Is(err, v4l2.ErrControlNotSupported) {
+			t.Skip("exposure auto not supported")
+		}
+		t.Fatal(err)
+	}
+	if mode, err := dev.GetControlExposureAuto(); err != nil || mode != v4l2.ExposureAutoManual {
+		t.Fatalf("expecting manual exposure, got %d (%v)", mode, err)
+	}
+	ctrl, err := dev.GetControlInfo(v4l2.CtrlCameraExposureAbsolute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dev.SetControlExposureAbsolute(ctrl.Default); err != nil {
+		t.Fatal(err)
+	}
+	if val, err := dev.GetControlExposureAbsolute(); err != nil || val != ctrl.Default {
+		t.Errorf("expecting exposure %d, got %d (%v)", ctrl.Default, val, err)
+	}
+}
+
+func TestControlEvents(t *testing.T) {
+	dev := openVivid(t)
+	defer dev.Close()
+
+	// changes made through another file descriptor are reported
+	other := openVivid(t)
+	defer other.Close()
+
+	if err := dev.SubscribeControlEvent(v4l2.CtrlBrightness); err != nil {
+		t.Fatal(err)
+	}
+	ctrl, err := other.GetControl(v4l2.CtrlBrightness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	val := ctrl.Minimum
+	if ctrl.Value == val {
+		val = ctrl.Maximum
+	}
+	if err := other.SetControlValue(v4l2.CtrlBrightness, val); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-dev.ControlEvents():
+		if event.ID != v4l2.CtrlBrightness || event.Value != val {
+			t.Errorf("unexpected control event: %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("expecting a control event")
+	}
+}
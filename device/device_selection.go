@@ -0,0 +1,121 @@
+package device
+
+import (
+	"fmt"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// The capture pipeline of a sensor is controlled with two distinct selection rectangles:
+//
+//   - the analogue crop (v4l2.SelectionTargetCrop) selects the area of the sensor that is
+//     read out. Shrinking it narrows the field of view.
+//   - the digital compose (v4l2.SelectionTargetCompose) selects the area of the output
+//     frame the cropped image is scaled into. Changing it rescales the image while the
+//     field of view stays the same.
+//
+// For instance, a "zoom" that keeps the output size reduces the crop, while a downscale
+// that keeps the full field of view reduces the compose rectangle.
+
+// GetAnalogueCrop returns the sensor area currently read out by the device (its field of view).
+func (d *Device) GetAnalogueCrop() (v4l2.Rect, error) {
+	return d.GetSelection(v4l2.SelectionTargetCrop)
+}
+
+// SetAnalogueCrop selects the sensor area that is read out, changing the field of view.
+// It returns the rectangle as adjusted by the driver.
+func (d *Device) SetAnalogueCrop(r v4l2.Rect) (v4l2.Rect, error) {
+	return d.setSelection(v4l2.SelectionTargetCrop, r)
+}
+
+// GetDigitalCompose returns the area of the output frame the cropped image is scaled into.
+func (d *Device) GetDigitalCompose() (v4l2.Rect, error) {
+	return d.GetSelection(v4l2.SelectionTargetCompose)
+}
+
+// SetDigitalCompose selects the area of the output frame the cropped image is scaled
+// into, changing the scaling without affecting the field of view. It returns the
+// rectangle as adjusted by the driver.
+func (d *Device) SetDigitalCompose(r v4l2.Rect) (v4l2.Rect, error) {
+	return d.setSelection(v4l2.SelectionTargetCompose, r)
+}
+
+// GetSelection returns the rectangle of the selection target, i.e. the crop rectangle
+// (v4l2.SelectionTargetCrop), its bounds (v4l2.SelectionTargetCropBounds) and default
+// (v4l2.SelectionTargetCropDefault), or the compose rectangle and its bounds.
+func (d *Device) GetSelection(target v4l2.SelectionTarget) (v4l2.Rect, error) {
+	if !d.cap.IsVideoCaptureSupported() {
+		return v4l2.Rect{}, v4l2.ErrorUnsupportedFeature
+	}
+	r, err := v4l2.GetSelection(d.fd, d.bufType, target)
+	if err != nil {
+		return v4l2.Rect{}, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return r, nil
+}
+
+func (d *Device) setSelection(target v4l2.SelectionTarget, r v4l2.Rect) (v4l2.Rect, error) {
+	return d.SetSelection(target, r, 0)
+}
+
+// SetSelection sets the rectangle of the selection target, constrained by the flags (i.e.
+// v4l2.SelectionFlagGE to get at least the requested rectangle, v4l2.SelectionFlagLE to get
+// at most the requested rectangle). It returns the rectangle as adjusted by the driver, or
+// an error when the driver cannot satisfy the constraints.
+func (d *Device) SetSelection(target v4l2.SelectionTarget, r v4l2.Rect, flags v4l2.SelectionFlag) (v4l2.Rect, error) {
+	if !d.cap.IsVideoCaptureSupported() {
+		return v4l2.Rect{}, v4l2.ErrorUnsupportedFeature
+	}
+	adjusted, err := v4l2.SetSelection(d.fd, d.bufType, target, r, flags)
+	if err != nil {
+		return v4l2.Rect{}, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return adjusted, nil
+}
+
+// SetCompose sets the compose rectangle, the area of the output frame the cropped image
+// is scaled into (same as SetDigitalCompose). It returns the rectangle as adjusted by the driver.
+func (d *Device) SetCompose(r v4l2.Rect) (v4l2.Rect, error) {
+	return d.SetDigitalCompose(r)
+}
+
+// SetLetterbox sets the compose rectangle so that the cropped image (see GetAnalogueCrop)
+// fills the output frame. When keepAspect is true, the image is scaled to the largest
+// rectangle with the aspect ratio of the crop that fits the output, centered (letterbox or
+// pillarbox), otherwise it is stretched to the whole output frame. It returns the rectangle
+// as adjusted by the driver.
+func (d *Device) SetLetterbox(keepAspect bool) (v4l2.Rect, error) {
+	pixFmt, err := v4l2.GetPixFormat(d.fd)
+	if err != nil {
+		return v4l2.Rect{}, fmt.Errorf("device: %s: letterbox: %w", d.path, err)
+	}
+	output := v4l2.Rect{Width: pixFmt.Width, Height: pixFmt.Height}
+	if !keepAspect {
+		return d.SetCompose(output)
+	}
+
+	crop, err := d.GetAnalogueCrop()
+	if err != nil {
+		return v4l2.Rect{}, err
+	}
+	return d.SetCompose(letterboxRect(crop, pixFmt.Width, pixFmt.Height))
+}
+
+// letterboxRect returns the largest rectangle, centered within width x height, with the
+// aspect ratio of src. Dimensions and offsets are kept even for subsampled formats.
+func letterboxRect(src v4l2.Rect, width, height uint32) v4l2.Rect {
+	if src.Width == 0 || src.Height == 0 {
+		return v4l2.Rect{Width: width, Height: height}
+	}
+	w, h := width, uint32(uint64(width)*uint64(src.Height)/uint64(src.Width))
+	if h > height {
+		w, h = uint32(uint64(height)*uint64(src.Width)/uint64(src.Height)), height
+	}
+	w, h = w&^1, h&^1
+	return v4l2.Rect{
+		Left:   int32((width-w)/2) &^ 1,
+		Top:    int32((height-h)/2) &^ 1,
+		Width:  w,
+		Height: h,
+	}
+}
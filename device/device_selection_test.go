@@ -0,0 +1,28 @@
+package device
+
+import (
+	"testing"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+func TestLetterboxRect(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    v4l2.Rect
+		width  uint32
+		height uint32
+		want   v4l2.Rect
+	}{
+		{"letterbox", v4l2.Rect{Width: 1920, Height: 1080}, 640, 480, v4l2.Rect{Left: 0, Top: 60, Width: 640, Height: 360}},
+		{"pillarbox", v4l2.Rect{Width: 640, Height: 480}, 1280, 720, v4l2.Rect{Left: 160, Top: 0, Width: 960, Height: 720}},
+		{"same aspect", v4l2.Rect{Width: 1280, Height: 960}, 640, 480, v4l2.Rect{Width: 640, Height: 480}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := letterboxRect(test.src, test.width, test.height); got != test.want {
+				t.Errorf("expecting %+v, got %+v", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	sys "syscall"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+func TestRestart(t *testing.T) {
+	dev := openVivid(t, WithPixFormat(v4l2.PixFormat{Width: 640, Height: 480, PixelFormat: v4l2.PixelFmtYUYV}))
+	defer dev.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := dev.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := dev.Restart(ctx, v4l2.PixFormat{Width: 320, Height: 240, PixelFormat: v4l2.PixelFmtYUYV}); err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	select {
+	case frame := <-dev.GetOutput():
+		if len(frame) != 320*240*2 {
+			t.Errorf("expecting frame of the restarted format, got %d bytes", len(frame))
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no frame after restart")
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	dev := openVivid(t)
+	defer dev.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := dev.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+	output := dev.GetOutput()
+	<-output
+
+	if err := dev.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	if !dev.IsPaused() {
+		t.Fatal("expecting paused stream")
+	}
+	delivered := dev.Stats().FramesDelivered
+	time.Sleep(200 * time.Millisecond)
+	if got := dev.Stats().FramesDelivered; got != delivered {
+		t.Fatalf("%d frames delivered while paused", got-delivered)
+	}
+
+	if err := dev.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-output:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no frame after resume")
+	}
+}
+
+func TestSelectInput(t *testing.T) {
+	dev := openVivid(t)
+	defer dev.Close()
+
+	inputs, err := dev.EnumInputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) < 2 {
+		t.Skipf("vivid exposes %d inputs", len(inputs))
+	}
+	if err := dev.SelectInput(1); err != nil {
+		t.Fatal(err)
+	}
+	defer dev.SelectInput(0)
+	if index, err := dev.GetInput(); err != nil || index != 1 {
+		t.Errorf("expecting input 1, got %d (%v)", index, err)
+	}
+}
+
+func TestSetFrameRate(t *testing.T) {
+	dev := openVivid(t)
+	defer dev.Close()
+
+	fps, err := dev.GetFrameRate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.SetFrameRate(fps)
+	if err := dev.SetFrameRate(15); err != nil {
+		t.Fatal(err)
+	}
+	if applied, err := dev.GetFrameRate(); err != nil || applied == 0 {
+		t.Errorf("unexpected frame rate read back: %d (%v)", applied, err)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	dev := openVivid(t)
+	defer dev.Close()
+	other := openVivid(t)
+	defer other.Close()
+
+	if err := dev.SetPriority(v4l2.PriorityRecord); err != nil {
+		t.Fatal(err)
+	}
+	if prio, err := other.GetPriority(); err != nil || prio != v4l2.PriorityRecord {
+		t.Errorf("expecting record priority, got %d (%v)", prio, err)
+	}
+	if err := other.SetFrameRate(15); !errors.Is(err, v4l2.ErrBusy) {
+		t.Errorf("expecting ErrBusy for a lower priority, got %v", err)
+	}
+}
+
+func TestCheckDisconnected(t *testing.T) {
+	ioErr := fmt.Errorf("dequeue: %w", sys.EIO)
+
+	gone := &Device{path: filepath.Join(t.TempDir(), "video0")}
+	if err := gone.checkDisconnected(ioErr); !errors.Is(err, v4l2.ErrDeviceGone) {
+		t.Errorf("expecting ErrDeviceGone for EIO without device node, got %v", err)
+	}
+
+	present := &Device{path: t.TempDir()}
+	if err := present.checkDisconnected(ioErr); errors.Is(err, v4l2.ErrDeviceGone) {
+		t.Errorf("expecting EIO with device node, got %v", err)
+	}
+	if err := gone.checkDisconnected(v4l2.ErrTimeout); errors.Is(err, v4l2.ErrDeviceGone) {
+		t.Errorf("expecting timeout unchanged, got %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	sys "syscall"
+	"time"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// diagnosticCtrlKeywords are name fragments used to identify driver controls
+// that report error or overrun counters.
+var diagnosticCtrlKeywords = []string{"error", "overrun", "overflow", "drop", "crc", "ecc"}
+
+// isDiagnosticControl returns true if the control looks like a driver diagnostic counter:
+// a read-only (or volatile) integer control with an error-related name.
+func isDiagnosticControl(ctrl v4l2.Control) bool {
+	if ctrl.Type != v4l2.CtrlTypeInt && ctrl.Type != v4l2.CtrlTypeInt64 {
+		return false
+	}
+	if !ctrl.IsReadOnly() && !ctrl.IsVolatile() {
+		return false
+	}
+	name := strings.ToLower(ctrl.Name)
+	for _, keyword := range diagnosticCtrlKeywords {
+		if strings.Contains(name, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// DriverErrorCounters returns the values of the diagnostic counters (CRC errors, overruns,
+// dropped frames, etc) that the driver advertises as controls, keyed by control name.
+// There is no standard control for these counters, so this is a best-effort lookup of
+// read-only integer controls with error-related names. An empty map is returned when the
+// driver exposes no such controls. Counters whose value cannot be read are skipped.
+func (d *Device) DriverErrorCounters() (map[string]uint64, error) {
+	ctrls, err := v4l2.QueryAllControls(d.fd)
+	if err != nil && len(ctrls) == 0 {
+		return nil, fmt.Errorf("device: %s: error counters: %w", d.path, err)
+	}
+
+	counters := make(map[string]uint64)
+	for _, ctrl := range ctrls {
+		if !isDiagnosticControl(ctrl) {
+			continue
+		}
+		val, err := v4l2.GetControlValue(d.fd, ctrl.ID)
+		if err != nil || val < 0 {
+			continue
+		}
+		counters[ctrl.Name] = uint64(val)
+	}
+	return counters, nil
+}
+
+// ClearDriverErrorCounters resets the diagnostic counters found by DriverErrorCounters
+// to their default value. Only counters that the driver allows to be written are cleared,
+// read-only counters are left untouched.
+func (d *Device) ClearDriverErrorCounters() error {
+	ctrls, err := v4l2.QueryAllControls(d.fd)
+	if err != nil && len(ctrls) == 0 {
+		return fmt.Errorf("device: %s: clear error counters: %w", d.path, err)
+	}
+
+	for _, ctrl := range ctrls {
+		if !isDiagnosticControl(ctrl) || ctrl.IsReadOnly() {
+			continue
+		}
+		if err := v4l2.SetControlValue(d.fd, ctrl.ID, ctrl.Default); err != nil {
+			return fmt.Errorf("device: %s: clear error counter %s: %w", d.path, ctrl.Name, err)
+		}
+	}
+	return nil
+}
+
+// ModuleParameters returns the parameters of the kernel module of the device driver
+// (i.e. the uvcvideo quirks), read from /sys/module/<driver>/parameters, keyed by
+// parameter name. The module name is the driver name reported by the device capability.
+// An empty map is returned when the module exposes no parameters (or is built into the
+// kernel without them). Parameters that cannot be read (i.e. restricted) are skipped.
+func (d *Device) ModuleParameters() (map[string]string, error) {
+	dir := filepath.Join("/sys/module", d.cap.Driver, "parameters")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("device: %s: module parameters: %w", d.path, err)
+	}
+
+	params := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		val, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		params[entry.Name()] = strings.TrimSpace(string(val))
+	}
+	return params, nil
+}
+
+// LogStatus asks the driver to write its status to the kernel log (see v4l2.LogStatus).
+func (d *Device) LogStatus() error {
+	if err := v4l2.LogStatus(d.fd); err != nil {
+		return fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return nil
+}
+
+// logStatusSettle is how long the kernel log is read after LogStatus for the status lines
+const logStatusSettle = 100 * time.Millisecond
+
+// DriverStatusText triggers LogStatus and returns the status logged by the driver, read
+// back from the kernel log (/dev/kmsg), for environments without access to dmesg. Only
+// the lines between the driver START STATUS and END STATUS markers are returned, without
+// the kernel log record prefixes. This is best-effort: reading /dev/kmsg may require
+// privileges (see the kernel.dmesg_restrict sysctl) and an error is returned when the
+// driver logged no status.
+func (d *Device) DriverStatusText() (string, error) {
+	kmsg, err := os.OpenFile("/dev/kmsg", os.O_RDONLY|sys.O_NONBLOCK, 0)
+	if err != nil {
+		return "", fmt.Errorf("device: %s: driver status: %w", d.path, err)
+	}
+	defer kmsg.Close()
+
+	// skip the existing records, only the records logged from now on are read
+	if _, err := kmsg.Seek(0, io.SeekEnd); err != nil {
+		return "", fmt.Errorf("device: %s: driver status: %w", d.path, err)
+	}
+	if err := d.LogStatus(); err != nil {
+		return "", err
+	}
+
+	var lines []string
+	var inStatus, done bool
+	buf := make([]byte, 8192) // one record per read, records are at most 8KiB
+	deadline := time.Now().Add(logStatusSettle)
+	// kmsg is handled by the runtime poller: reads wait for new records instead of failing
+	// with EAGAIN, the deadline ends them once the status has settled. Without poller support,
+	// reads fail with EAGAIN and the deadline is checked by the loop.
+	_ = kmsg.SetReadDeadline(deadline)
+	for !done && time.Now().Before(deadline) {
+		n, err := kmsg.Read(buf)
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				break
+			}
+			if errors.Is(err, sys.EAGAIN) {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			if errors.Is(err, sys.EPIPE) {
+				continue // records were overwritten, keep reading
+			}
+			return "", fmt.Errorf("device: %s: driver status: %w", d.path, err)
+		}
+		msg := kmsgMessage(string(buf[:n]))
+		switch {
+		case strings.Contains(msg, "START STATUS"):
+			inStatus = true
+		case strings.Contains(msg, "END STATUS"):
+			done = inStatus
+		case inStatus:
+			lines = append(lines, msg)
+		}
+	}
+	if !inStatus {
+		return "", fmt.Errorf("device: %s: driver status: no status logged", d.path)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// kmsgMessage returns the message of a /dev/kmsg record (prio,seq,timestamp,flags;message),
+// without the continuation (key=value) lines.
+func kmsgMessage(record string) string {
+	if i := strings.IndexByte(record, ';'); i >= 0 {
+		record = record[i+1:]
+	}
+	if i := strings.IndexByte(record, '\n'); i >= 0 {
+		record = record[:i]
+	}
+	return record
+}
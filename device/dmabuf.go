@@ -0,0 +1,135 @@
+package device
+
+import (
+	"fmt"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+	sys "golang.org/x/sys/unix"
+)
+
+// CacheSyncMode selects the cache synchronization performed by the kernel when a
+// buffer is queued (see WithDMABufCacheSync).
+type CacheSyncMode int
+
+const (
+	// CacheSyncFull lets the kernel invalidate and clean caches (default)
+	CacheSyncFull CacheSyncMode = iota
+	// CacheSyncSkipInvalidate skips cache invalidation (v4l2.BufFlagNoCacheInvalidate)
+	CacheSyncSkipInvalidate
+	// CacheSyncSkipClean skips cache cleaning (v4l2.BufFlagNoCacheClean)
+	CacheSyncSkipClean
+	// CacheSyncNone skips all cache synchronization, for coherent memory
+	CacheSyncNone
+)
+
+// flags returns the buffer flags passed to VIDIOC_QBUF for the mode
+func (m CacheSyncMode) flags() v4l2.BufFlag {
+	switch m {
+	case CacheSyncSkipInvalidate:
+		return v4l2.BufFlagNoCacheInvalidate
+	case CacheSyncSkipClean:
+		return v4l2.BufFlagNoCacheClean
+	case CacheSyncNone:
+		return v4l2.BufFlagNoCacheInvalidate | v4l2.BufFlagNoCacheClean
+	default:
+		return 0
+	}
+}
+
+// queueBuffer enqueues the device buffer at index, using the imported dma-buf
+// (or the user buffer) for that index when the device uses v4l2.IOTypeDMABuf
+// (or v4l2.IOTypeUserPtr).
+func (d *Device) queueBuffer(index uint32) error {
+	var err error
+	if d.config.ioType == v4l2.IOTypeDMABuf {
+		_, err = v4l2.QueueDMABuffer(d.fd, d.bufType, index, d.config.dmabufFds[index], d.config.cacheSync.flags())
+	} else if d.config.ioType == v4l2.IOTypeUserPtr {
+		_, err = v4l2.QueueUserBuffer(d.fd, d.bufType, index, d.config.userBufs[index])
+	} else if d.isMultiPlanar() {
+		_, err = v4l2.QueueBufferPlanes(d.fd, d.config.ioType, d.bufType, index, d.numPlanes)
+	} else {
+		_, err = v4l2.QueueBuffer(d.fd, d.config.ioType, d.bufType, index)
+	}
+	return err
+}
+
+// SupportedMemoryTypes returns the streaming IO types (v4l2.IOTypeMMAP, v4l2.IOTypeUserPtr,
+// v4l2.IOTypeDMABuf) supported by the device, i.e. to select DMABUF when available and fall
+// back to MMAP otherwise. The read/write IO method is reported separately by the device
+// capability (see v4l2.Capability.IsReadWriteSupported). It fails while the device is streaming.
+func (d *Device) SupportedMemoryTypes() ([]v4l2.IOType, error) {
+	if d.IsStreaming() {
+		return nil, fmt.Errorf("device: %s: memory types: stream started", d.path)
+	}
+	if !d.cap.IsStreamingSupported() {
+		return nil, nil
+	}
+	types, err := v4l2.GetSupportedMemoryTypes(d.fd, d.bufType)
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return types, nil
+}
+
+// exportBuffers exports each memory mapped device buffer as a dma-buf (see WithDMABufExport)
+func (d *Device) exportBuffers() error {
+	if d.isMultiPlanar() {
+		return fmt.Errorf("dma-buf export: multi-planar buffers: %w", v4l2.ErrorUnsupportedFeature)
+	}
+	fds := make([]int, 0, d.config.bufSize)
+	for i := uint32(0); i < d.config.bufSize; i++ {
+		fd, err := v4l2.ExportBuffer(d.fd, d.bufType, i, 0)
+		if err != nil {
+			for _, fd := range fds {
+				sys.Close(fd)
+			}
+			return fmt.Errorf("dma-buf export: %w", err)
+		}
+		fds = append(fds, fd)
+	}
+	d.mu.Lock()
+	d.exportedFds = fds
+	d.mu.Unlock()
+	return nil
+}
+
+// closeExportedBuffers closes the dma-buf file descriptors exported when the stream started
+func (d *Device) closeExportedBuffers() {
+	d.mu.Lock()
+	fds := d.exportedFds
+	d.exportedFds = nil
+	d.mu.Unlock()
+	for _, fd := range fds {
+		sys.Close(fd)
+	}
+}
+
+// ExportedBuffers returns the dma-buf file descriptors of the device buffers, indexed by
+// buffer index, exported when the stream started (see WithDMABufExport). The descriptors
+// are closed when the stream stops, consumers keeping them longer must duplicate them. The
+// returned slice is a copy.
+func (d *Device) ExportedBuffers() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.exportedFds == nil {
+		return nil
+	}
+	fds := make([]int, len(d.exportedFds))
+	copy(fds, d.exportedFds)
+	return fds
+}
+
+// dmabufFd returns the dma-buf file descriptor, imported or exported, of the buffer at index
+func (d *Device) dmabufFd(index uint32) (int, bool) {
+	switch {
+	case d.config.ioType == v4l2.IOTypeDMABuf && int(index) < len(d.config.dmabufFds):
+		return d.config.dmabufFds[index], true
+	case d.config.dmabufExport:
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if int(index) < len(d.exportedFds) {
+			return d.exportedFds[index], true
+		}
+	}
+	return -1, false
+}
@@ -0,0 +1,210 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+	sys "golang.org/x/sys/unix"
+)
+
+const (
+	// eventBacklog is the number of events buffered for a slow reader, further events
+	// are dropped (the driver also drops the oldest events of a full queue)
+	eventBacklog = 16
+
+	// eventPollMillis is the poll timeout between checks for the device being closed
+	eventPollMillis = 100
+)
+
+// eventSubscription is an event subscribed with SubscribeEvent
+type eventSubscription struct {
+	eventType v4l2.EventType
+	id        uint32
+}
+
+// SubscribeEvent subscribes to the device events of the specified type (i.e. v4l2.EventEOS or
+// v4l2.EventSourceChange) and starts dispatching them: control events are delivered on
+// ControlEvents, all other events on Events. For control events, id is the control ID, for
+// source change events it is the input index.
+func (d *Device) SubscribeEvent(eventType v4l2.EventType, id uint32) error {
+	if err := v4l2.SubscribeEvent(d.fd, eventType, id, 0); err != nil {
+		return fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	d.mu.Lock()
+	d.subscriptions = append(d.subscriptions, eventSubscription{eventType: eventType, id: id})
+	d.mu.Unlock()
+	d.startEventLoop()
+	return nil
+}
+
+// UnsubscribeEvent cancels a subscription made with SubscribeEvent.
+func (d *Device) UnsubscribeEvent(eventType v4l2.EventType, id uint32) error {
+	if err := v4l2.UnsubscribeEvent(d.fd, eventType, id); err != nil {
+		return fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, sub := range d.subscriptions {
+		if sub.eventType == eventType && sub.id == id {
+			d.subscriptions = append(d.subscriptions[:i], d.subscriptions[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// SubscribeControlEvent subscribes to the changes of the control (value, flags or range),
+// including changes made by other processes sharing the device. The changes are delivered
+// on ControlEvents.
+func (d *Device) SubscribeControlEvent(id v4l2.CtrlID) error {
+	return d.SubscribeEvent(v4l2.EventCtrl, id)
+}
+
+// SubscribeSourceChange subscribes to the source change events of the current input, which
+// are delivered on Events (see v4l2.Event.SourceChangeEvent). When the change includes
+// v4l2.SourceChangeResolution, frames no longer match the negotiated format: the stream
+// should be stopped, the format read again (GetPixFormat) and negotiated, then restarted.
+func (d *Device) SubscribeSourceChange() error {
+	var input uint32
+	if index, err := v4l2.GetCurrentVideoInputIndex(d.fd); err == nil {
+		input = uint32(index)
+	}
+	return d.SubscribeEvent(v4l2.EventSourceChange, input)
+}
+
+// ControlEvents returns the channel delivering the control events subscribed to with
+// SubscribeControlEvent. The channel is closed when the device is closed. Events are
+// dropped while the channel is full.
+func (d *Device) ControlEvents() <-chan v4l2.ControlEvent {
+	d.initEvents()
+	return d.controlEvents
+}
+
+// Events returns the channel delivering the events, other than control events, subscribed
+// to with SubscribeEvent. The channel is closed when the device is closed. Events are
+// dropped while the channel is full.
+func (d *Device) Events() <-chan v4l2.Event {
+	d.initEvents()
+	return d.events
+}
+
+func (d *Device) initEvents() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.events == nil {
+		d.events = make(chan v4l2.Event, eventBacklog)
+		d.controlEvents = make(chan v4l2.ControlEvent, eventBacklog)
+		d.eventsDone = make(chan struct{})
+	}
+}
+
+// startEventLoop starts, once, the loop that waits for pending events (signaled with
+// POLLPRI) and dispatches them, independently of the stream loop, until the device is closed.
+func (d *Device) startEventLoop() {
+	d.initEvents()
+	d.mu.Lock()
+	if d.eventsStarted {
+		d.mu.Unlock()
+		return
+	}
+	d.eventsStarted = true
+	d.eventsExited = make(chan struct{})
+	events, controlEvents, done, exited := d.events, d.controlEvents, d.eventsDone, d.eventsExited
+	d.mu.Unlock()
+
+	go func() {
+		defer close(exited)
+		defer close(events)
+		defer close(controlEvents)
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			fds := []sys.PollFd{{Fd: int32(d.Fd()), Events: sys.POLLPRI}}
+			n, err := sys.Poll(fds, eventPollMillis)
+			if err != nil && !errors.Is(err, sys.EINTR) {
+				return
+			}
+			// hang up: the device is disconnected
+			if fds[0].Revents&(sys.POLLHUP|sys.POLLNVAL) != 0 {
+				return
+			}
+			if n == 0 || fds[0].Revents&sys.POLLPRI == 0 {
+				continue
+			}
+
+			// drain all pending events
+			for {
+				event, err := v4l2.DequeueEvent(d.Fd())
+				if err != nil {
+					break
+				}
+				d.dispatchEvent(event, events, controlEvents)
+				if event.Pending == 0 {
+					break
+				}
+			}
+		}
+	}()
+}
+
+func (d *Device) dispatchEvent(event v4l2.Event, events chan<- v4l2.Event, controlEvents chan<- v4l2.ControlEvent) {
+	if ctrlEvent, ok := event.ControlEvent(); ok {
+		select {
+		case controlEvents <- ctrlEvent:
+		default:
+		}
+		return
+	}
+	select {
+	case events <- event:
+	default:
+	}
+}
+
+// stopEvents stops the event loop, which closes the event channels, and waits for it
+// to exit so the descriptor is no longer polled
+func (d *Device) stopEvents() {
+	d.mu.Lock()
+	if d.eventsDone == nil {
+		d.mu.Unlock()
+		return
+	}
+	if !d.eventsStarted {
+		close(d.events)
+		close(d.controlEvents)
+	}
+	close(d.eventsDone)
+	d.eventsDone = nil
+	exited := d.eventsExited
+	d.mu.Unlock()
+
+	if exited != nil {
+		<-exited
+	}
+}
+
+// restartEvents subscribes the events subscribed so far again on the (reopened) device
+// descriptor and starts a new event loop, delivering on new event channels. The previous
+// loop must be stopped (see stopEvents).
+func (d *Device) restartEvents() error {
+	d.mu.Lock()
+	d.events, d.controlEvents = nil, nil
+	d.eventsExited = nil
+	d.eventsStarted = false
+	subs := d.subscriptions
+	d.subscriptions = nil
+	d.mu.Unlock()
+
+	for _, sub := range subs {
+		if err := d.SubscribeEvent(sub.eventType, sub.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
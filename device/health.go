@@ -0,0 +1,154 @@
+package device
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// DefaultWatchdogTimeout is the time without a delivered frame after which the stream is
+// reported unhealthy (see WithHealthCallback)
+const DefaultWatchdogTimeout = 5 * time.Second
+
+// HealthFunc is called when the stream becomes healthy (frames flowing) or unhealthy, with
+// the reason of the transition.
+type HealthFunc func(healthy bool, reason string)
+
+// startHealthMonitor starts the watchdog reporting to the health callback, if any
+func (d *Device) startHealthMonitor(ctx context.Context) {
+	if d.health != nil {
+		d.health.start(ctx)
+	}
+}
+
+// healthMonitor tracks the stream health and reports its transitions to the callback
+type healthMonitor struct {
+	callback HealthFunc
+	timeout  time.Duration
+
+	mu        sync.Mutex
+	reported  bool // whether a state was reported since the stream started
+	healthy   bool
+	lastFrame time.Time
+	stop      chan struct{}
+}
+
+func newHealthMonitor(callback HealthFunc, timeout time.Duration) *healthMonitor {
+	if timeout <= 0 {
+		timeout = DefaultWatchdogTimeout
+	}
+	return &healthMonitor{callback: callback, timeout: timeout}
+}
+
+// start runs the watchdog until ctx is done or the monitor is stopped. The stream
+// is reported unhealthy when no frame is delivered within the watchdog timeout.
+func (h *healthMonitor) start(ctx context.Context) {
+	h.mu.Lock()
+	h.lastFrame = time.Now()
+	h.stop = make(chan struct{})
+	stop := h.stop
+	h.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(h.timeout / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				h.mu.Lock()
+				elapsed := time.Since(h.lastFrame)
+				h.mu.Unlock()
+				if elapsed > h.timeout {
+					h.set(false, fmt.Sprintf("no frame within %s", h.timeout))
+				}
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// halt stops the watchdog without reporting a transition (the stream was stopped),
+// the next state is reported when the stream is started again.
+func (h *healthMonitor) halt() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.reported = false
+	if h.stop != nil {
+		close(h.stop)
+		h.stop = nil
+	}
+}
+
+// frame records a delivered frame
+func (h *healthMonitor) frame() {
+	h.mu.Lock()
+	h.lastFrame = time.Now()
+	h.mu.Unlock()
+	h.set(true, "frames flowing")
+}
+
+// set reports the state to the callback when it changes
+func (h *healthMonitor) set(healthy bool, reason string) {
+	h.mu.Lock()
+	if h.reported && h.healthy == healthy {
+		h.mu.Unlock()
+		return
+	}
+	h.reported, h.healthy = true, healthy
+	h.mu.Unlock()
+	h.callback(healthy, reason)
+}
+
+// captureTimer fires when no frame is dequeued within the capture timeout (see
+// WithCaptureTimeout). A disabled timer never fires.
+type captureTimer struct {
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+func newCaptureTimer(timeout time.Duration) *captureTimer {
+	t := &captureTimer{timeout: timeout}
+	if timeout > 0 {
+		t.timer = time.NewTimer(timeout)
+	}
+	return t
+}
+
+// expired returns the channel receiving the expiration, nil when the timer is disabled
+func (t *captureTimer) expired() <-chan time.Time {
+	if t.timer == nil {
+		return nil
+	}
+	return t.timer.C
+}
+
+// reset restarts the timeout, after a frame was dequeued or the stream resumed
+func (t *captureTimer) reset() {
+	if t.timer == nil {
+		return
+	}
+	if !t.timer.Stop() {
+		select {
+		case <-t.timer.C:
+		default:
+		}
+	}
+	t.timer.Reset(t.timeout)
+}
+
+func (t *captureTimer) stop() {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
+
+// captureTimedOut stops the stream on a capture timeout, reported by Err
+func (d *Device) captureTimedOut(timeout time.Duration) {
+	d.fail(fmt.Errorf("device: %s: no frame dequeued within %s: %w", d.path, timeout, v4l2.ErrTimeout))
+}
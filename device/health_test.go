@@ -0,0 +1,32 @@
+package device
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHealthMonitor(t *testing.T) {
+	states := make(chan bool, 4)
+	h := newHealthMonitor(func(healthy bool, reason string) { states <- healthy }, 20*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.start(ctx)
+
+	h.frame()
+	h.frame() // no transition
+	if healthy := <-states; !healthy {
+		t.Fatal("expecting healthy after a frame")
+	}
+
+	select {
+	case healthy := <-states:
+		if healthy {
+			t.Fatal("expecting unhealthy without frames")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not report unhealthy")
+	}
+	h.halt()
+}
@@ -0,0 +1,49 @@
+package device
+
+import (
+	"image"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// Images returns the channel that outputs captured frames decoded as images (see
+// v4l2.DecodeFrame), transformed by the configured options (see WithSquareOutput and
+// WithImageReuse). Images shares the stream with Frames and GetOutput: each captured frame
+// is delivered to only one of the channels. Frames that cannot be decoded are dropped.
+func (d *Device) Images() <-chan image.Image {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.streaming && !d.imagesForwarded {
+		pixFmt := d.config.pixFormat
+		square := d.config.squareOutput
+		reuse := d.config.imageReuse
+		if reuse {
+			// unbuffered, so that at most one reused image is held by the consumer
+			d.images = make(chan image.Image)
+		}
+		go func(frames <-chan v4l2.Frame, images chan<- image.Image) {
+			defer close(images)
+			var rgba [2]*image.RGBA // double buffer, used with image reuse
+			var next int
+			for frame := range frames {
+				if frame.IsErrored() {
+					continue
+				}
+				img, err := v4l2.DecodeFrame(frame, pixFmt)
+				if err != nil {
+					continue
+				}
+				if square > 0 {
+					img = v4l2.CenterCropResize(img, square)
+				}
+				if reuse {
+					rgba[next] = v4l2.ToRGBA(img, rgba[next])
+					img, next = rgba[next], 1-next
+				}
+				images <- img
+			}
+		}(d.frames, d.images)
+		d.imagesForwarded = true
+	}
+	return d.images
+}
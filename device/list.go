@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
 )
 
 var (
@@ -54,3 +58,54 @@ func GetAllDevicePaths() ([]string, error) {
 	}
 	return result, nil
 }
+
+// DeviceInfo identifies a device node along with its capabilities (see v4l2.DeviceInfo)
+type DeviceInfo = v4l2.DeviceInfo
+
+// Enumerate scans /dev for video nodes (/dev/videoN), opens each of them to query its
+// capabilities and closes it. Nodes are returned in numerical order, nodes that cannot be
+// queried (i.e. not accessible to the user) are skipped. Use GroupByBus to cluster the
+// nodes of the same physical device.
+func Enumerate() ([]DeviceInfo, error) {
+	paths, err := GetAllDevicePaths()
+	if err != nil {
+		return nil, fmt.Errorf("device: enumerate: %w", err)
+	}
+	var result []DeviceInfo
+	for _, path := range paths {
+		if !strings.HasPrefix(path, root+"/video") {
+			continue
+		}
+		info, err := v4l2.GetDeviceInfo(path)
+		if err != nil {
+			continue
+		}
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		pi, pj := result[i].Path, result[j].Path
+		if len(pi) != len(pj) {
+			return len(pi) < len(pj)
+		}
+		return pi < pj
+	})
+	return result, nil
+}
+
+// PhysicalDeviceID returns an identifier shared by all the nodes (capture, metadata, etc)
+// of the physical device, derived from the bus info reported by the driver
+// (see v4l2.DeviceInfo.PhysicalDeviceID).
+func (d *Device) PhysicalDeviceID() string {
+	return v4l2.DeviceInfo{Path: d.path, Capability: d.cap}.PhysicalDeviceID()
+}
+
+// GroupByBus clusters device nodes by physical device (see v4l2.DeviceInfo.PhysicalDeviceID),
+// i.e. to present a camera along with its capture and metadata nodes.
+func GroupByBus(infos []DeviceInfo) map[string][]DeviceInfo {
+	groups := make(map[string][]DeviceInfo)
+	for _, info := range infos {
+		id := info.PhysicalDeviceID()
+		groups[id] = append(groups[id], info)
+	}
+	return groups
+}
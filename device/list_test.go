@@ -2,6 +2,8 @@ package device
 
 import (
 	"testing"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
 )
 
 func TestList(t *testing.T) {
@@ -11,3 +13,35 @@ func TestList(t *testing.T) {
 	}
 	t.Logf("devices: %#v", devices)
 }
+
+func TestEnumerate(t *testing.T) {
+	infos, err := Enumerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range infos {
+		if !info.GetDeviceCapFlags().HasVideoCapture() && !info.GetDeviceCapFlags().HasVideoOutput() {
+			t.Logf("%s: no video capture or output: %s", info.Path, info.GetDeviceCapFlags())
+		}
+	}
+	t.Logf("devices: %v", infos)
+}
+
+func TestGroupByBus(t *testing.T) {
+	infos := []v4l2.DeviceInfo{
+		{Path: "/dev/video0", Capability: v4l2.Capability{BusInfo: "usb-0000:00:14.0-1"}},
+		{Path: "/dev/video1", Capability: v4l2.Capability{BusInfo: "usb-0000:00:14.0-1"}},
+		{Path: "/dev/video2", Capability: v4l2.Capability{BusInfo: "usb-0000:00:14.0-2"}},
+		{Path: "/dev/video3"},
+	}
+	groups := GroupByBus(infos)
+	if len(groups) != 3 {
+		t.Fatalf("expecting 3 groups, got %d: %v", len(groups), groups)
+	}
+	if len(groups["usb-0000:00:14.0-1"]) != 2 {
+		t.Errorf("expecting 2 nodes on bus usb-0000:00:14.0-1, got %v", groups["usb-0000:00:14.0-1"])
+	}
+	if len(groups["/dev/video3"]) != 1 {
+		t.Errorf("expecting node without bus info grouped by path, got %v", groups)
+	}
+}
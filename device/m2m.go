@@ -0,0 +1,367 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+	sys "golang.org/x/sys/unix"
+)
+
+const (
+	// m2mBufferCount is the number of buffers requested for each queue of a M2M device
+	m2mBufferCount = 2
+
+	// DefaultM2MTimeout is the default time Encode waits for the device to process a frame
+	DefaultM2MTimeout = 5 * time.Second
+)
+
+// M2M is a memory-to-memory device (i.e. a hardware JPEG or H.264 encoder) which processes the
+// frames queued on its output queue (raw frames written to the device) into the frames of its
+// capture queue (encoded frames read from the device), both queues sharing one file descriptor.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/dev-mem2mem.html
+type M2M struct {
+	path    string
+	fd      uintptr
+	cap     v4l2.Capability
+	output  *m2mQueue
+	capture *m2mQueue
+
+	mu      sync.Mutex
+	timeout time.Duration
+}
+
+// m2mQueue is one of the buffer queues of a M2M device
+type m2mQueue struct {
+	fd        uintptr
+	bufType   v4l2.BufType
+	count     uint32
+	pixFmt    v4l2.PixFormat
+	planeFmts []v4l2.PlaneFormat // plane sizes, multi-planar queues only
+	buffers   [][][]byte         // mapped planes of each buffer (one plane for single-planar queues)
+}
+
+func (q *m2mQueue) Fd() uintptr              { return q.fd }
+func (q *m2mQueue) BufferType() v4l2.BufType { return q.bufType }
+func (q *m2mQueue) BufferCount() uint32      { return q.count }
+func (q *m2mQueue) MemIOType() v4l2.IOType   { return v4l2.IOTypeMMAP }
+
+// OpenM2M opens the memory-to-memory device at path, sets the format of the frames written to
+// the device (inFmt, on its output queue) and of the processed frames read from the device
+// (outFmt, on its capture queue), then allocates the buffers of both queues and turns them on.
+// The formats applied by the driver are reported by InputFormat and OutputFormat.
+func OpenM2M(path string, inFmt, outFmt v4l2.PixFormat) (*M2M, error) {
+	fd, err := v4l2.OpenDevice(path, sys.O_RDWR|sys.O_NONBLOCK|sys.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("device open: %s: %w", path, err)
+	}
+	m := &M2M{path: path, fd: fd, timeout: DefaultM2MTimeout}
+	if err := m.open(inFmt, outFmt); err != nil {
+		m.Close()
+		return nil, fmt.Errorf("device open: %s: m2m: %w", path, err)
+	}
+	return m, nil
+}
+
+func (m *M2M) open(inFmt, outFmt v4l2.PixFormat) error {
+	cap, err := v4l2.GetCapability(m.fd)
+	if err != nil {
+		return err
+	}
+	m.cap = cap
+
+	switch {
+	case cap.IsVideoMem2MemSupported():
+		m.output = &m2mQueue{fd: m.fd, bufType: v4l2.BufTypeVideoOutput}
+		m.capture = &m2mQueue{fd: m.fd, bufType: v4l2.BufTypeVideoCapture}
+	case cap.IsVideoMem2MemMultiplanarSupported():
+		m.output = &m2mQueue{fd: m.fd, bufType: v4l2.BufTypeVideoOutputMPlane}
+		m.capture = &m2mQueue{fd: m.fd, bufType: v4l2.BufTypeVideoCaptureMPlane}
+	default:
+		return v4l2.ErrorUnsupportedFeature
+	}
+	if !cap.IsStreamingSupported() {
+		return v4l2.ErrStreamingUnsupported
+	}
+
+	// the output (raw) format is set first, encoders derive the capture format from it
+	if err := m.output.setFormat(inFmt); err != nil {
+		return fmt.Errorf("output format: %w", err)
+	}
+	if err := m.capture.setFormat(outFmt); err != nil {
+		return fmt.Errorf("capture format: %w", err)
+	}
+
+	for _, q := range []*m2mQueue{m.output, m.capture} {
+		if err := q.allocate(); err != nil {
+			return err
+		}
+		if err := v4l2.StreamOn(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setFormat sets the format of the queue and reads back the format applied by the driver
+func (q *m2mQueue) setFormat(pixFmt v4l2.PixFormat) error {
+	if !v4l2.IsMultiPlanar(q.bufType) {
+		if err := v4l2.SetPixFormatForBufType(q.fd, q.bufType, pixFmt); err != nil {
+			return err
+		}
+		applied, err := v4l2.GetPixFormatForBufType(q.fd, q.bufType)
+		if err != nil {
+			return err
+		}
+		q.pixFmt = applied
+		return nil
+	}
+
+	mpFmt := v4l2.PixFormatMPlane{Width: pixFmt.Width, Height: pixFmt.Height, PixelFormat: pixFmt.PixelFormat, Field: pixFmt.Field, Colorspace: pixFmt.Colorspace}
+	if pixFmt.SizeImage > 0 {
+		// i.e. the size of the encoded frames
+		mpFmt.Planes = []v4l2.PlaneFormat{{SizeImage: pixFmt.SizeImage, BytesPerLine: pixFmt.BytesPerLine}}
+	}
+	applied, err := v4l2.SetPixFormatMPlane(q.fd, q.bufType, mpFmt)
+	if err != nil {
+		return err
+	}
+	q.pixFmt = applied.PixFormat()
+	q.planeFmts = applied.Planes
+	return nil
+}
+
+// allocate requests and maps the buffers of the queue
+func (q *m2mQueue) allocate() error {
+	q.count = m2mBufferCount
+	bufReq, err := v4l2.InitBuffers(q)
+	if err != nil {
+		return err
+	}
+	q.count = bufReq.Count
+
+	if v4l2.IsMultiPlanar(q.bufType) {
+		q.buffers, err = v4l2.MapMemoryBufferPlanes(q)
+		return err
+	}
+	buffers, err := v4l2.MapMemoryBuffers(q)
+	for _, buf := range buffers {
+		q.buffers = append(q.buffers, [][]byte{buf})
+	}
+	return err
+}
+
+// release turns the queue off, then unmaps and frees its buffers
+func (q *m2mQueue) release() {
+	if q == nil || q.count == 0 {
+		return
+	}
+	v4l2.StreamOff(q)
+	for _, planes := range q.buffers {
+		for _, plane := range planes {
+			sys.Munmap(plane)
+		}
+	}
+	q.buffers = nil
+	v4l2.ResetBuffers(q)
+	q.count = 0
+}
+
+// InputFormat returns the format of the frames written to the device (output queue)
+func (m *M2M) InputFormat() v4l2.PixFormat {
+	return m.output.pixFmt
+}
+
+// OutputFormat returns the format of the processed frames read from the device (capture queue)
+func (m *M2M) OutputFormat() v4l2.PixFormat {
+	return m.capture.pixFmt
+}
+
+// Capability returns the capability of the device
+func (m *M2M) Capability() v4l2.Capability {
+	return m.cap
+}
+
+// SetTimeout sets the time Encode waits for the device to process a frame (DefaultM2MTimeout)
+func (m *M2M) SetTimeout(timeout time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timeout = timeout
+}
+
+// Encode writes the raw frame, in the input format, to the device and returns the processed
+// (i.e. encoded) frame, in the output format. It is meant for codecs producing one frame per
+// frame written (i.e. JPEG encoders), and fails with v4l2.ErrTimeout if the device produces
+// no frame in time (see SetTimeout), after which the device should be reopened. For
+// multi-planar input formats, frame holds the planes in order.
+func (m *M2M) Encode(frame []byte) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// the frame is split into the output planes before any buffer is queued, so that an
+	// oversized frame leaves the queues untouched
+	planes, err := m.outputPlanes(0, frame)
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: encode: %w", m.path, err)
+	}
+
+	// the capture buffer is queued first, to be ready for the processed frame
+	if err := m.queueCapture(0); err != nil {
+		return nil, fmt.Errorf("device: %s: encode: %w", m.path, err)
+	}
+	if err := m.queueOutput(0, planes); err != nil {
+		// turning the capture queue off and on returns the queued capture buffer
+		v4l2.StreamOff(m.capture)
+		v4l2.StreamOn(m.capture)
+		return nil, fmt.Errorf("device: %s: encode: %w", m.path, err)
+	}
+
+	data, err := m.dequeueCapture()
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: encode: %w", m.path, err)
+	}
+	// reclaim the output buffer for the next frame
+	if err := m.dequeue(m.output, sys.POLLOUT); err != nil {
+		return nil, fmt.Errorf("device: %s: encode: %w", m.path, err)
+	}
+	return data, nil
+}
+
+func (m *M2M) queueCapture(index uint32) error {
+	q := m.capture
+	if v4l2.IsMultiPlanar(q.bufType) {
+		_, err := v4l2.QueueBufferPlanes(m.fd, v4l2.IOTypeMMAP, q.bufType, index, len(q.buffers[index]))
+		return err
+	}
+	_, err := v4l2.QueueBuffer(m.fd, v4l2.IOTypeMMAP, q.bufType, index)
+	return err
+}
+
+// outputPlanes splits the frame into the data of each plane of the output buffer, it fails
+// if the frame exceeds the buffer size
+func (m *M2M) outputPlanes(index uint32, frame []byte) ([][]byte, error) {
+	q := m.output
+	planes := q.buffers[index]
+	data := make([][]byte, len(planes))
+	rest := frame
+	for i, plane := range planes {
+		size := len(plane)
+		if i < len(q.planeFmts) && q.planeFmts[i].SizeImage > 0 && int(q.planeFmts[i].SizeImage) < size {
+			size = int(q.planeFmts[i].SizeImage)
+		}
+		if i == len(planes)-1 {
+			size = len(rest)
+		}
+		if size > len(rest) {
+			size = len(rest)
+		}
+		if size > len(plane) {
+			return nil, fmt.Errorf("frame of %d bytes exceeds buffer size", len(frame))
+		}
+		data[i] = rest[:size]
+		rest = rest[size:]
+	}
+	return data, nil
+}
+
+// queueOutput copies the data of each plane (see outputPlanes) into the output buffer and
+// queues it
+func (m *M2M) queueOutput(index uint32, data [][]byte) error {
+	q := m.output
+	planes := q.buffers[index]
+	bytesUsed := make([]uint32, len(planes))
+	for i, plane := range planes {
+		bytesUsed[i] = uint32(copy(plane, data[i]))
+	}
+
+	if v4l2.IsMultiPlanar(q.bufType) {
+		_, err := v4l2.QueueOutputBufferPlanes(m.fd, v4l2.IOTypeMMAP, q.bufType, index, bytesUsed)
+		return err
+	}
+	_, err := v4l2.QueueOutputBuffer(m.fd, v4l2.IOTypeMMAP, q.bufType, index, bytesUsed[0])
+	return err
+}
+
+// dequeueCapture waits for the processed frame and copies it from the capture buffer
+func (m *M2M) dequeueCapture() ([]byte, error) {
+	q := m.capture
+	if err := m.wait(sys.POLLIN); err != nil {
+		return nil, err
+	}
+	if !v4l2.IsMultiPlanar(q.bufType) {
+		buff, err := v4l2.DequeueBuffer(m.fd, v4l2.IOTypeMMAP, q.bufType)
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, buff.BytesUsed)
+		copy(data, q.buffers[buff.Index][0])
+		return data, nil
+	}
+
+	buff, planes, err := v4l2.DequeueBufferPlanes(m.fd, v4l2.IOTypeMMAP, q.bufType, len(q.buffers[0]))
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	for i, plane := range planes {
+		if i >= len(q.buffers[buff.Index]) || plane.BytesUsed <= plane.DataOffset {
+			continue
+		}
+		data = append(data, q.buffers[buff.Index][i][plane.DataOffset:plane.BytesUsed]...)
+	}
+	return data, nil
+}
+
+// dequeue waits for the queue to release a buffer (signaled with events) and dequeues it
+func (m *M2M) dequeue(q *m2mQueue, events int16) error {
+	if err := m.wait(events); err != nil {
+		return err
+	}
+	if v4l2.IsMultiPlanar(q.bufType) {
+		_, _, err := v4l2.DequeueBufferPlanes(m.fd, v4l2.IOTypeMMAP, q.bufType, len(q.buffers[0]))
+		return err
+	}
+	_, err := v4l2.DequeueBuffer(m.fd, v4l2.IOTypeMMAP, q.bufType)
+	return err
+}
+
+// wait polls the device for the events (POLLIN for the capture queue, POLLOUT for the
+// output queue) until the timeout
+func (m *M2M) wait(events int16) error {
+	timeout := m.timeout
+	if timeout <= 0 {
+		timeout = DefaultM2MTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	fds := []sys.PollFd{{Fd: int32(m.fd), Events: events}}
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return v4l2.ErrTimeout
+		}
+		n, err := sys.Poll(fds, int(remaining/time.Millisecond)+1)
+		if err != nil {
+			if errors.Is(err, sys.EINTR) {
+				continue
+			}
+			return fmt.Errorf("poll: %w", err)
+		}
+		if n > 0 && fds[0].Revents&events != 0 {
+			return nil
+		}
+		if n > 0 && fds[0].Revents&sys.POLLERR != 0 {
+			return fmt.Errorf("poll: %w", v4l2.ErrorSystem)
+		}
+	}
+}
+
+// Close turns both queues off, releases their buffers and closes the device.
+func (m *M2M) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.output.release()
+	m.capture.release()
+	return v4l2.CloseDevice(m.fd)
+}
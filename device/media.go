@@ -0,0 +1,204 @@
+package device
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+	sys "golang.org/x/sys/unix"
+)
+
+// A camera often exposes several video nodes (capture, metadata, etc) along with a media
+// device (/dev/mediaX) describing how they relate. The media graph below is a read-only view
+// of that device, used to select the main capture node of a camera instead of guessing.
+
+// MediaEntity is an entity of a media graph, along with its pads and the path of its device
+// node (empty when the entity has no device node).
+type MediaEntity struct {
+	v4l2.MediaEntity
+	Pads       []v4l2.MediaPad
+	DevicePath string
+	DevMajor   uint32
+	DevMinor   uint32
+}
+
+// MediaLink is a data link between a source pad and a sink pad
+type MediaLink struct {
+	Source v4l2.MediaPad
+	Sink   v4l2.MediaPad
+	Flags  v4l2.MediaLinkFlag
+}
+
+// IsEnabled returns true if data flows through the link
+func (l MediaLink) IsEnabled() bool {
+	return l.Flags&v4l2.MediaLinkFlagEnabled != 0
+}
+
+// MediaGraph is the topology of a media device: its entities and the data links
+// connecting their pads.
+type MediaGraph struct {
+	Path     string
+	Info     v4l2.MediaDeviceInfo
+	Entities []MediaEntity
+	Links    []MediaLink
+}
+
+// OpenMediaGraph reads the graph of the media device at path (i.e. /dev/media0). Entities
+// and their device nodes are enumerated with MEDIA_IOC_ENUM_ENTITIES, pads and links are read
+// from the topology when the kernel supports MEDIA_IOC_G_TOPOLOGY.
+func OpenMediaGraph(path string) (MediaGraph, error) {
+	fd, err := v4l2.OpenDevice(path, sys.O_RDONLY|sys.O_NONBLOCK|sys.O_CLOEXEC, 0)
+	if err != nil {
+		return MediaGraph{}, fmt.Errorf("device: media graph: %w", err)
+	}
+	defer v4l2.CloseDevice(fd)
+
+	info, err := v4l2.GetMediaDeviceInfo(fd)
+	if err != nil {
+		return MediaGraph{}, fmt.Errorf("device: %s: media graph: %w", path, err)
+	}
+	descs, err := v4l2.GetMediaEntityDescs(fd)
+	if err != nil {
+		return MediaGraph{}, fmt.Errorf("device: %s: media graph: %w", path, err)
+	}
+	topo, err := v4l2.GetMediaTopology(fd)
+	if err != nil {
+		topo = v4l2.MediaTopology{}
+	}
+
+	graph := MediaGraph{Path: path, Info: info}
+	for _, desc := range descs {
+		entity := MediaEntity{
+			MediaEntity: v4l2.MediaEntity{ID: desc.ID, Name: desc.Name, Function: desc.Type, Flags: desc.Flags},
+			DevMajor:    desc.DevMajor,
+			DevMinor:    desc.DevMinor,
+		}
+		if e, ok := topo.Entity(desc.ID); ok {
+			entity.MediaEntity = e
+		}
+		if entity.DevMajor != 0 || entity.DevMinor != 0 {
+			entity.DevicePath, _ = devNodePath(entity.DevMajor, entity.DevMinor)
+		}
+		for _, pad := range topo.Pads {
+			if pad.EntityID == entity.ID {
+				entity.Pads = append(entity.Pads, pad)
+			}
+		}
+		graph.Entities = append(graph.Entities, entity)
+	}
+	for _, link := range topo.Links {
+		if link.Flags&v4l2.MediaLinkFlagTypeMask != v4l2.MediaLinkFlagDataLink {
+			continue
+		}
+		source, sourceOK := topo.Pad(link.SourceID)
+		sink, sinkOK := topo.Pad(link.SinkID)
+		if sourceOK && sinkOK {
+			graph.Links = append(graph.Links, MediaLink{Source: source, Sink: sink, Flags: link.Flags})
+		}
+	}
+	return graph, nil
+}
+
+// EnumerateMediaGraphs reads the graph of each media device (/dev/mediaX), media devices
+// that cannot be read are skipped.
+func EnumerateMediaGraphs() ([]MediaGraph, error) {
+	paths, err := filepath.Glob(root + "/media*")
+	if err != nil {
+		return nil, fmt.Errorf("device: media graphs: %w", err)
+	}
+	var result []MediaGraph
+	for _, path := range paths {
+		graph, err := OpenMediaGraph(path)
+		if err != nil {
+			continue
+		}
+		result = append(result, graph)
+	}
+	return result, nil
+}
+
+// Entity returns the entity with the specified ID
+func (g MediaGraph) Entity(id uint32) (MediaEntity, bool) {
+	for _, entity := range g.Entities {
+		if entity.ID == id {
+			return entity, true
+		}
+	}
+	return MediaEntity{}, false
+}
+
+// EntitiesByFunction returns the entities with the specified function
+// (i.e. v4l2.MediaEntityFunctionIOV4L for the video nodes)
+func (g MediaGraph) EntitiesByFunction(function v4l2.MediaEntityFunction) []MediaEntity {
+	var result []MediaEntity
+	for _, entity := range g.Entities {
+		if entity.Function == function {
+			result = append(result, entity)
+		}
+	}
+	return result
+}
+
+// CaptureNode returns the device path of the main capture node of the graph: the video node
+// flagged as default by the driver (i.e. UVC cameras) or else the first video node, fed by a
+// data link, whose capabilities report video capture.
+func (g MediaGraph) CaptureNode() (string, error) {
+	nodes := g.EntitiesByFunction(v4l2.MediaEntityFunctionIOV4L)
+	for _, node := range nodes {
+		if node.DevicePath != "" && node.Flags&v4l2.MediaEntityFlagDefault != 0 {
+			return node.DevicePath, nil
+		}
+	}
+	for _, node := range nodes {
+		if node.DevicePath == "" || (len(g.Links) > 0 && !g.isLinked(node.ID)) {
+			continue
+		}
+		info, err := v4l2.GetDeviceInfo(node.DevicePath)
+		if err != nil {
+			continue
+		}
+		if caps := info.GetDeviceCapFlags(); caps.HasVideoCapture() || caps.HasVideoCaptureMPlane() {
+			return node.DevicePath, nil
+		}
+	}
+	return "", fmt.Errorf("device: %s: capture node: %w", g.Path, v4l2.ErrorUnsupportedFeature)
+}
+
+// isLinked returns true if a data link feeds the entity
+func (g MediaGraph) isLinked(entityID uint32) bool {
+	for _, link := range g.Links {
+		if link.Sink.EntityID == entityID {
+			return true
+		}
+	}
+	return false
+}
+
+// MediaGraph returns the graph of the media device the video node belongs to
+func (d *Device) MediaGraph() (MediaGraph, error) {
+	graph, _, err := d.mediaEntity()
+	return graph, err
+}
+
+// mediaEntity returns the graph of the media device the video node belongs to, along with
+// the entity of the video node, matched by device number.
+func (d *Device) mediaEntity() (MediaGraph, MediaEntity, error) {
+	var stat sys.Stat_t
+	if err := sys.Fstat(int(d.fd), &stat); err != nil {
+		return MediaGraph{}, MediaEntity{}, fmt.Errorf("device: %s: media graph: %w", d.path, err)
+	}
+	major, minor := sys.Major(uint64(stat.Rdev)), sys.Minor(uint64(stat.Rdev))
+
+	graphs, err := EnumerateMediaGraphs()
+	if err != nil {
+		return MediaGraph{}, MediaEntity{}, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	for _, graph := range graphs {
+		for _, entity := range graph.Entities {
+			if entity.DevMajor == major && entity.DevMinor == minor {
+				return graph, entity, nil
+			}
+		}
+	}
+	return MediaGraph{}, MediaEntity{}, fmt.Errorf("device: %s: media graph: no media device: %w", d.path, v4l2.ErrorUnsupportedFeature)
+}
@@ -0,0 +1,103 @@
+package device
+
+import (
+	"fmt"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// Multi-planar devices (v4l2.BufTypeVideoCaptureMPlane) are selected automatically when the
+// device does not support single-planar capture. Their format is also reported as a
+// v4l2.PixFormat (see v4l2.PixFormatMPlane.PixFormat), and the planes of each captured buffer
+// are assembled, in plane order, into the data of a single frame.
+
+// isMultiPlanar returns true if the device uses the multi-planar API
+func (d *Device) isMultiPlanar() bool {
+	return v4l2.IsMultiPlanar(d.bufType)
+}
+
+// GetPixFormatMPlane returns the multi-planar format of the device, with the size
+// information of each plane.
+func (d *Device) GetPixFormatMPlane() (v4l2.PixFormatMPlane, error) {
+	if !d.isMultiPlanar() {
+		return v4l2.PixFormatMPlane{}, v4l2.ErrorUnsupportedFeature
+	}
+	pixFmt, err := v4l2.GetPixFormatMPlane(d.fd, d.bufType)
+	if err != nil {
+		return v4l2.PixFormatMPlane{}, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return pixFmt, nil
+}
+
+// SetPixFormatMPlane sets the multi-planar format of the device and returns the format as
+// adjusted by the driver. The plane sizes can be left empty for the driver to fill.
+func (d *Device) SetPixFormatMPlane(pixFmt v4l2.PixFormatMPlane) (v4l2.PixFormatMPlane, error) {
+	if !d.isMultiPlanar() {
+		return v4l2.PixFormatMPlane{}, v4l2.ErrorUnsupportedFeature
+	}
+	applied, err := v4l2.SetPixFormatMPlane(d.fd, d.bufType, pixFmt)
+	if err != nil {
+		return v4l2.PixFormatMPlane{}, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	d.config.pixFormat = applied.PixFormat()
+	d.numPlanes = len(applied.Planes)
+	return applied, nil
+}
+
+// currentPixFormat reads the current format from the driver, converting the format of a
+// multi-planar device to its single-planar equivalent
+func (d *Device) currentPixFormat() (v4l2.PixFormat, error) {
+	if !d.isMultiPlanar() {
+		return v4l2.GetPixFormatForBufType(d.fd, d.bufType)
+	}
+	pixFmt, err := v4l2.GetPixFormatMPlane(d.fd, d.bufType)
+	if err != nil {
+		return v4l2.PixFormat{}, err
+	}
+	d.numPlanes = len(pixFmt.Planes)
+	return pixFmt.PixFormat(), nil
+}
+
+// mapPlanes maps the planes of each buffer of a multi-planar device. The plane mappings are
+// also kept, flattened, as the device buffers so that they are unmapped with the buffers.
+func (d *Device) mapPlanes() error {
+	planes, err := v4l2.MapMemoryBufferPlanes(d)
+	if err != nil {
+		return err
+	}
+	d.planes = planes
+	d.buffers = nil
+	for _, bufPlanes := range planes {
+		d.buffers = append(d.buffers, bufPlanes...)
+	}
+	return nil
+}
+
+// dequeuePlanes dequeues a multi-planar buffer and copies the data of its planes, in
+// plane order, into a single slice. The BytesUsed of the returned buffer is the total size.
+func (d *Device) dequeuePlanes() (v4l2.Buffer, []byte, error) {
+	buff, planes, err := v4l2.DequeueBufferPlanes(d.fd, d.config.ioType, d.bufType, d.numPlanes)
+	if err != nil {
+		return v4l2.Buffer{}, nil, err
+	}
+
+	var size uint32
+	for _, plane := range planes {
+		if plane.BytesUsed > plane.DataOffset {
+			size += plane.BytesUsed - plane.DataOffset
+		}
+	}
+	buff.BytesUsed = size
+	if buff.Flags&v4l2.BufFlagMapped == 0 || buff.Flags&v4l2.BufFlagError != 0 || int(buff.Index) >= len(d.planes) {
+		return buff, []byte{}, nil
+	}
+
+	data := make([]byte, 0, size)
+	for i, plane := range planes {
+		if i >= len(d.planes[buff.Index]) || plane.BytesUsed <= plane.DataOffset {
+			continue
+		}
+		data = append(data, d.planes[buff.Index][i][plane.DataOffset:plane.BytesUsed]...)
+	}
+	return buff, data, nil
+}
@@ -0,0 +1,122 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+	sys "golang.org/x/sys/unix"
+)
+
+// outputPollMillis is the poll timeout between checks of the context while waiting for
+// the driver to release an output buffer
+const outputPollMillis = 100
+
+// isOutput returns true if the device was opened for video output (see WithVideoOutputEnabled)
+func (d *Device) isOutput() bool {
+	return d.bufType == v4l2.BufTypeVideoOutput
+}
+
+// Write queues the frame data for a video output device (i.e. a v4l2loopback virtual webcam)
+// to consume. The data must be encoded in the negotiated format (see SetPixFormat and
+// v4l2.EncodeImage). The first write allocates the output buffers and turns the stream on,
+// once all buffers are queued, Write blocks until the driver releases one. Stop (or Close)
+// turns the stream off and releases the buffers. Write requires memory mapped IO.
+func (d *Device) Write(frame []byte) error {
+	return d.writeFrame(context.Background(), frame)
+}
+
+func (d *Device) writeFrame(ctx context.Context, frame []byte) error {
+	if !d.isOutput() {
+		return fmt.Errorf("device: %s: write: %w", d.path, v4l2.ErrorUnsupportedFeature)
+	}
+	if d.buffers == nil {
+		if err := d.startOutput(); err != nil {
+			return fmt.Errorf("device: %s: write: %w", d.path, err)
+		}
+	}
+
+	// the buffers are allocated with the same size, an oversized frame takes no buffer
+	if size := len(d.buffers[0]); len(frame) > size {
+		return fmt.Errorf("device: %s: write: frame of %d bytes exceeds buffer of %d bytes", d.path, len(frame), size)
+	}
+
+	// buffers are handed out in order until all are queued, then reclaimed from the driver
+	index := d.outputNext
+	if d.outputNext < d.config.bufSize {
+		d.outputNext++
+	} else {
+		var err error
+		if index, err = d.reclaimOutputBuffer(ctx); err != nil {
+			return fmt.Errorf("device: %s: write: %w", d.path, err)
+		}
+	}
+
+	copy(d.buffers[index], frame)
+	if _, err := v4l2.QueueOutputBuffer(d.fd, d.config.ioType, d.bufType, index, uint32(len(frame))); err != nil {
+		return fmt.Errorf("device: %s: write: %w", d.path, err)
+	}
+
+	// the stream is turned on once a buffer is queued, a failure is retried on the next write
+	if !d.IsStreaming() {
+		if err := v4l2.StreamOn(d); err != nil {
+			return fmt.Errorf("device: %s: write: %w", d.path, err)
+		}
+		d.mu.Lock()
+		d.streaming = true
+		d.mu.Unlock()
+	}
+	return nil
+}
+
+// startOutput allocates and maps the output buffers, the stream is turned on by writeFrame
+func (d *Device) startOutput() error {
+	if d.readOnly {
+		return fmt.Errorf("opened read-only")
+	}
+	if d.config.ioType != v4l2.IOTypeMMAP {
+		return v4l2.ErrStreamingUnsupported
+	}
+	bufReq, err := v4l2.InitBuffers(d)
+	if err != nil {
+		return err
+	}
+	d.config.bufSize = bufReq.Count
+	d.requestedBuf = bufReq
+	if d.buffers, err = v4l2.MapMemoryBuffers(d); err != nil {
+		v4l2.ResetBuffers(d)
+		return err
+	}
+	d.outputNext = 0
+	return nil
+}
+
+// reclaimOutputBuffer waits for the driver to release an output buffer and returns its index
+func (d *Device) reclaimOutputBuffer(ctx context.Context) (uint32, error) {
+	fds := []sys.PollFd{{Fd: int32(d.fd), Events: sys.POLLOUT}}
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		n, err := sys.Poll(fds, outputPollMillis)
+		if err != nil {
+			if errors.Is(err, sys.EINTR) {
+				continue
+			}
+			return 0, fmt.Errorf("poll: %w", err)
+		}
+		if n == 0 {
+			continue
+		}
+
+		buff, err := v4l2.DequeueBuffer(d.fd, d.config.ioType, d.bufType)
+		if err != nil {
+			if errors.Is(err, sys.EAGAIN) {
+				continue
+			}
+			return 0, err
+		}
+		return buff.Index, nil
+	}
+}
@@ -0,0 +1,72 @@
+package device
+
+import (
+	"context"
+	"time"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// pacingBacklog is the number of frames the pacer holds before dropping the oldest,
+// which absorbs bursts while keeping the delivery close to real-time.
+const pacingBacklog = 2
+
+// frameSink returns the channel the capture loop sends frames, captured in the specified
+// format, to: the frames channel or the input of the configured stages (field weaving, then
+// pacing) that forward frames to the frames channel until ctx, the stream context, is done.
+func (d *Device) frameSink(ctx context.Context, pixFmt v4l2.PixFormat) chan v4l2.Frame {
+	sink := d.frames
+	if d.config.pacingFPS > 0 {
+		in := make(chan v4l2.Frame, d.config.bufSize)
+		go pace(ctx, in, sink, d.config.pacingFPS)
+		sink = in
+	}
+	if d.config.weaveFields && pixFmt.Field == v4l2.FieldAlternate {
+		in := make(chan v4l2.Frame, d.config.bufSize)
+		go weave(ctx, in, sink, pixFmt.BytesPerLine)
+		sink = in
+	}
+	return sink
+}
+
+// pace forwards frames from in to out evenly spaced at fps. Frames arriving in bursts are
+// held (up to pacingBacklog, dropping the oldest), and the last frame is repeated when
+// no new frame arrived in time. Frames are dropped if out is not drained in time.
+// out is closed when in is closed or ctx is done.
+func pace(ctx context.Context, in <-chan v4l2.Frame, out chan<- v4l2.Frame, fps float64) {
+	defer close(out)
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / fps))
+	defer ticker.Stop()
+
+	var pending []v4l2.Frame
+	var last v4l2.Frame
+	var started bool
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case frame, ok := <-in:
+			if !ok {
+				return
+			}
+			pending = append(pending, frame)
+			if len(pending) > pacingBacklog {
+				pending = pending[1:]
+			}
+		case <-ticker.C:
+			switch {
+			case len(pending) > 0:
+				last, pending = pending[0], pending[1:]
+				started = true
+			case !started:
+				continue
+			}
+			select {
+			case out <- last:
+			case <-ctx.Done():
+				return
+			default:
+			}
+		}
+	}
+}
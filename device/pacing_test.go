@@ -0,0 +1,36 @@
+package device
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+func TestPace(t *testing.T) {
+	in := make(chan v4l2.Frame, 4)
+	out := make(chan v4l2.Frame, 8)
+	go pace(context.Background(), in, out, 100)
+
+	// a burst of three frames: the oldest is dropped, then the last is repeated
+	for i := uint32(1); i <= 3; i++ {
+		in <- v4l2.Frame{Sequence: i}
+	}
+	var sequences []uint32
+	for len(sequences) < 3 {
+		select {
+		case frame := <-out:
+			sequences = append(sequences, frame.Sequence)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out, got sequences %v", sequences)
+		}
+	}
+	if sequences[0] != 2 || sequences[1] != 3 || sequences[2] != 3 {
+		t.Errorf("unexpected paced sequences %v", sequences)
+	}
+
+	close(in)
+	for range out {
+	}
+}
@@ -0,0 +1,89 @@
+package device
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// pauseRequest is sent to the stream loop to pause or resume the stream
+type pauseRequest struct {
+	pause bool
+	err   chan error
+}
+
+// Pause stops capturing frames while keeping the device buffers allocated and mapped, unlike
+// Stop which releases them: the stream is turned off (discarding the frames already captured)
+// and nothing is delivered on the output channels, which stay open, until Resume is called.
+// A frame being delivered when Pause is called is delivered first. Pause requires streaming IO.
+func (d *Device) Pause() error {
+	return d.requestPause(true)
+}
+
+// Resume restarts capturing frames after Pause. It only queues the existing buffers and turns
+// the stream on, which is much faster than starting the stream with Start.
+func (d *Device) Resume() error {
+	return d.requestPause(false)
+}
+
+// IsPaused returns true if the stream is paused (see Pause)
+func (d *Device) IsPaused() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.paused
+}
+
+func (d *Device) requestPause(pause bool) error {
+	if d.config.ioType == v4l2.IOTypeReadWrite {
+		return fmt.Errorf("device: %s: pause: %w", d.path, v4l2.ErrorUnsupportedFeature)
+	}
+	d.mu.Lock()
+	requests, loopDone := d.pauseRequests, d.loopDone
+	d.mu.Unlock()
+	if requests == nil || loopDone == nil {
+		return fmt.Errorf("device: %s: pause: stream not started", d.path)
+	}
+
+	req := pauseRequest{pause: pause, err: make(chan error, 1)}
+	select {
+	case requests <- req:
+	case <-loopDone:
+		return fmt.Errorf("device: %s: pause: stream stopped", d.path)
+	}
+	if err := <-req.err; err != nil {
+		return fmt.Errorf("device: %s: pause: %w", d.path, err)
+	}
+	return nil
+}
+
+// pauseStream handles a pause request in the stream loop. Pausing turns the stream off,
+// which returns all the buffers to the application without releasing them, resuming queues
+// the buffers again and turns the stream back on.
+func (d *Device) pauseStream(ctx context.Context, pause bool) error {
+	if pause == d.IsPaused() {
+		return nil
+	}
+	if pause {
+		if d.health != nil {
+			d.health.halt()
+		}
+		if err := v4l2.StreamOff(d); err != nil {
+			return err
+		}
+	} else {
+		for i := uint32(0); i < d.config.bufSize; i++ {
+			if err := d.queueBuffer(i); err != nil {
+				return fmt.Errorf("buffer queueing: %w", err)
+			}
+		}
+		if err := v4l2.StreamOn(d); err != nil {
+			return err
+		}
+		d.startHealthMonitor(ctx)
+	}
+	d.mu.Lock()
+	d.paused = pause
+	d.mu.Unlock()
+	return nil
+}
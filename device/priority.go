@@ -0,0 +1,31 @@
+package device
+
+import (
+	"fmt"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// GetPriority returns the highest access priority held on the device, by this or any other
+// process (i.e. v4l2.PriorityRecord while a capture daemon holds it).
+func (d *Device) GetPriority() (v4l2.Priority, error) {
+	prio, err := v4l2.GetPriority(d.fd)
+	if err != nil {
+		return 0, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return prio, nil
+}
+
+// SetPriority sets the access priority of the device file (v4l2.PriorityInteractive by default).
+// While the device is open with a higher priority elsewhere, the calls of this Device changing
+// the device state (SetPixFormat, SetFrameRate, SetControlValue, SelectInput...) fail with
+// v4l2.ErrBusy. Conversely, a capture process setting v4l2.PriorityRecord keeps other
+// processes, such as a preview application, from changing its settings. v4l2.PriorityRecord
+// can only be held by one file at a time: setting it fails with v4l2.ErrBusy when another
+// process holds it. The priority is released when the device is closed (or reopened).
+func (d *Device) SetPriority(prio v4l2.Priority) error {
+	if err := v4l2.SetPriority(d.fd, prio); err != nil {
+		return fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return nil
+}
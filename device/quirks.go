@@ -0,0 +1,88 @@
+package device
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// PreStreamHook is called when the stream is started, before the buffers are allocated and
+// the stream is turned on. It lets quirky devices be prepared (i.e. with a dummy read or a
+// control write) for streaming. An error aborts the start.
+type PreStreamHook func(*Device) error
+
+var (
+	quirksMu sync.RWMutex
+	// quirks are the pre-stream workarounds applied automatically, keyed by device fingerprint
+	quirks = map[string]PreStreamHook{}
+)
+
+// RegisterQuirk registers a workaround applied automatically before streaming from the
+// devices with the specified fingerprint (see Device.Fingerprint), before any hook set
+// with WithPreStreamHook.
+func RegisterQuirk(fingerprint string, hook PreStreamHook) {
+	quirksMu.Lock()
+	defer quirksMu.Unlock()
+	quirks[fingerprint] = hook
+}
+
+// DummyReadQuirk is a workaround for devices that need a read before the stream can be
+// turned on. The read is issued on the device and its result ignored.
+func DummyReadQuirk(d *Device) error {
+	buf := make([]byte, 1)
+	_, _ = v4l2.ReadDevice(d.fd, buf)
+	return nil
+}
+
+// Fingerprint identifies the device model: the USB vendor and product IDs ("usb:046d:0825")
+// when the device is a USB camera, or the driver and card names ("uvcvideo:HD Webcam")
+// otherwise.
+func (d *Device) Fingerprint() string {
+	if vendor, product, ok := usbIDs(d.path); ok {
+		return fmt.Sprintf("usb:%s:%s", vendor, product)
+	}
+	return d.cap.Driver + ":" + d.cap.Card
+}
+
+// usbIDs reads the USB vendor and product IDs of the video device from sysfs. The video
+// device belongs to a USB interface whose parent device holds the IDs.
+func usbIDs(path string) (string, string, bool) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", "", false
+	}
+	usbDev := filepath.Join("/sys/class/video4linux", filepath.Base(resolved), "device", "..")
+	vendor, err := os.ReadFile(filepath.Join(usbDev, "idVendor"))
+	if err != nil {
+		return "", "", false
+	}
+	product, err := os.ReadFile(filepath.Join(usbDev, "idProduct"))
+	if err != nil {
+		return "", "", false
+	}
+	return strings.TrimSpace(string(vendor)), strings.TrimSpace(string(product)), true
+}
+
+// runPreStreamHooks applies the registered quirk for the device, then the hook set with
+// WithPreStreamHook.
+func (d *Device) runPreStreamHooks() error {
+	quirksMu.RLock()
+	quirk := quirks[d.Fingerprint()]
+	quirksMu.RUnlock()
+
+	if quirk != nil {
+		if err := quirk(d); err != nil {
+			return fmt.Errorf("device: %s: quirk %s: %w", d.path, d.Fingerprint(), err)
+		}
+	}
+	if d.config.preStreamHook != nil {
+		if err := d.config.preStreamHook(d); err != nil {
+			return fmt.Errorf("device: %s: pre-stream hook: %w", d.path, err)
+		}
+	}
+	return nil
+}
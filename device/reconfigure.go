@@ -0,0 +1,71 @@
+package device
+
+import (
+	"fmt"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// Reconfigure sets a new pixel format on the device, then sets the frame rate and the
+// controls again since drivers may reset them on a format change. The controls set are
+// those of WithControls and, with WithPersistControls, the values set since the device
+// was opened. The format cannot be changed while streaming.
+func (d *Device) Reconfigure(pixFmt v4l2.PixFormat) error {
+	if d.IsStreaming() {
+		return fmt.Errorf("device: %s: reconfigure: stream started", d.path)
+	}
+	if err := d.SetPixFormat(pixFmt); err != nil {
+		return fmt.Errorf("device: %s: reconfigure: %w", d.path, err)
+	}
+	if d.config.fps != 0 {
+		if err := d.SetFrameRate(d.config.fps); err != nil {
+			return fmt.Errorf("device: %s: reconfigure: %w", d.path, err)
+		}
+	}
+	if err := d.applyControls(); err != nil {
+		return fmt.Errorf("device: %s: reconfigure: %w", d.path, err)
+	}
+	return nil
+}
+
+// Reopen opens the device path again (i.e. after the device was reconnected) and closes
+// the previous descriptor, then restores the input, format and frame rate last set along
+// with the controls (see Reconfigure). Subscribed events are subscribed again, on new
+// Events and ControlEvents channels (the previous ones are closed). The device cannot be
+// reopened while streaming.
+func (d *Device) Reopen() error {
+	if d.IsStreaming() {
+		return fmt.Errorf("device: %s: reopen: stream started", d.path)
+	}
+
+	// open first: on failure, the device keeps its current descriptor
+	fd, readOnly, err := d.openFile()
+	if err != nil {
+		return fmt.Errorf("device: %s: reopen: %w", d.path, err)
+	}
+
+	// the event loop polls the descriptor, stop it before swapping descriptors
+	d.stopEvents()
+
+	// the previous descriptor may be stale (device gone), close errors are ignored
+	_ = v4l2.CloseDevice(d.fd)
+	d.fd, d.readOnly = fd, readOnly
+
+	cap, err := v4l2.GetCapability(d.fd)
+	if err != nil {
+		return fmt.Errorf("device: %s: reopen: %w", d.path, err)
+	}
+	d.cap = cap
+
+	if err := d.restartEvents(); err != nil {
+		return fmt.Errorf("device: %s: reopen: %w", d.path, err)
+	}
+
+	if d.readOnly {
+		return nil
+	}
+	if err := d.configure(); err != nil {
+		return fmt.Errorf("device: %s: reopen: %w", d.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package device
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+	sys "golang.org/x/sys/unix"
+)
+
+// On media-controller based pipelines (i.e. the Raspberry Pi unicam CSI-2 receiver), the
+// video node only receives what the sensor sends on the media bus: the sensor readout mode is
+// configured on the sensor sub-device (/dev/v4l-subdevX) and the video node format must match it.
+// The helpers below locate the media device (/dev/mediaX) of the video node, walk its topology
+// upstream to the sensor, and configure each element of the pipeline consistently.
+
+// subdevPad is a pad of a sub-device along the capture pipeline
+type subdevPad struct {
+	path string
+	pad  uint32
+}
+
+// ListSensorModes returns the readout modes supported by the sensor feeding the video node.
+// A mode is reported for each media bus code and frame size supported by the sensor.
+func (d *Device) ListSensorModes() ([]v4l2.SensorMode, error) {
+	pipeline, err := d.sensorPipeline()
+	if err != nil {
+		return nil, err
+	}
+	sensor := pipeline[0]
+
+	fd, err := v4l2.OpenDevice(sensor.path, sys.O_RDWR|sys.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: sensor modes: %w", d.path, err)
+	}
+	defer v4l2.CloseDevice(fd)
+
+	codes, err := v4l2.GetSubdevMbusCodes(fd, sensor.pad)
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: sensor modes: %w", d.path, err)
+	}
+
+	var modes []v4l2.SensorMode
+	for _, code := range codes {
+		sizes, err := v4l2.GetSubdevFrameSizes(fd, sensor.pad, code)
+		if err != nil {
+			return nil, fmt.Errorf("device: %s: sensor modes: %w", d.path, err)
+		}
+		for _, size := range sizes {
+			modes = append(modes, v4l2.SensorMode{Width: size.MaxWidth, Height: size.MaxHeight, MbusCode: code})
+		}
+	}
+	return modes, nil
+}
+
+// SetSensorMode configures the sensor readout mode and propagates the resulting media bus
+// format to the sub-devices between the sensor and the video node (i.e. a CSI-2 receiver).
+// The video node format is then set to the same size, using the first pixel format the
+// driver reports for the media bus code (or the current pixel format if it reports none).
+// The mode cannot be changed while streaming.
+func (d *Device) SetSensorMode(mode v4l2.SensorMode) error {
+	if d.IsStreaming() {
+		return fmt.Errorf("device: %s: set sensor mode: stream started", d.path)
+	}
+
+	pipeline, err := d.sensorPipeline()
+	if err != nil {
+		return err
+	}
+
+	var mbusFmt v4l2.MbusFramefmt
+	for i, hop := range pipeline {
+		fd, err := v4l2.OpenDevice(hop.path, sys.O_RDWR|sys.O_CLOEXEC, 0)
+		if err != nil {
+			return fmt.Errorf("device: %s: set sensor mode: %w", d.path, err)
+		}
+		if i == 0 {
+			current, err := v4l2.GetSubdevFormat(fd, hop.pad, v4l2.SubdevFormatActive)
+			if err != nil {
+				v4l2.CloseDevice(fd)
+				return fmt.Errorf("device: %s: set sensor mode: %w", d.path, err)
+			}
+			mbusFmt = current
+			mbusFmt.Width, mbusFmt.Height, mbusFmt.Code = mode.Width, mode.Height, mode.MbusCode
+		}
+		mbusFmt, err = v4l2.SetSubdevFormat(fd, hop.pad, v4l2.SubdevFormatActive, mbusFmt)
+		v4l2.CloseDevice(fd)
+		if err != nil {
+			return fmt.Errorf("device: %s: set sensor mode: %s: %w", d.path, hop.path, err)
+		}
+	}
+
+	pixFmt, err := v4l2.GetPixFormat(d.fd)
+	if err != nil {
+		return fmt.Errorf("device: %s: set sensor mode: %w", d.path, err)
+	}
+	if descs, err := v4l2.GetFormatDescriptionsByMbusCode(d.fd, mbusFmt.Code); err == nil && len(descs) > 0 {
+		pixFmt.PixelFormat = descs[0].PixelFormat
+	}
+	pixFmt.Width, pixFmt.Height = mbusFmt.Width, mbusFmt.Height
+	pixFmt.Field = v4l2.FieldNone
+	pixFmt.BytesPerLine, pixFmt.SizeImage = 0, 0
+	if err := d.SetPixFormat(pixFmt); err != nil {
+		return fmt.Errorf("device: %s: set sensor mode: %w", d.path, err)
+	}
+	return nil
+}
+
+// sensorPipeline returns the sub-device pads between the sensor and the video node, starting
+// with the sensor source pad, followed by the sink and source pads of each intermediate
+// sub-device.
+func (d *Device) sensorPipeline() ([]subdevPad, error) {
+	graph, node, err := d.mediaEntity()
+	if err != nil {
+		return nil, err
+	}
+	topo, err := mediaTopology(graph.Path)
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: sensor pipeline: %s: %w", d.path, graph.Path, err)
+	}
+	pipeline, err := walkSensorPipeline(topo, node.ID)
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return pipeline, nil
+}
+
+func mediaTopology(path string) (v4l2.MediaTopology, error) {
+	fd, err := v4l2.OpenDevice(path, sys.O_RDWR|sys.O_CLOEXEC, 0)
+	if err != nil {
+		return v4l2.MediaTopology{}, err
+	}
+	defer v4l2.CloseDevice(fd)
+	return v4l2.GetMediaTopology(fd)
+}
+
+// walkSensorPipeline follows the enabled data links upstream, from the video node entity
+// to the sensor entity.
+func walkSensorPipeline(topo v4l2.MediaTopology, entityID uint32) ([]subdevPad, error) {
+	var pipeline []subdevPad // collected downstream first
+	path := ""               // device path of the current entity, empty for the video node
+	for hops := 0; hops < len(topo.Entities); hops++ {
+		source, sink, found := upstreamLink(topo, entityID)
+		if !found {
+			return nil, fmt.Errorf("sensor pipeline: entity %d: no upstream link: %w", entityID, v4l2.ErrorUnsupportedFeature)
+		}
+		if path != "" {
+			pipeline = append(pipeline, subdevPad{path: path, pad: sink.Index})
+		}
+
+		entity, _ := topo.Entity(source.EntityID)
+		var err error
+		if path, err = entityDevicePath(topo, entity.ID); err != nil {
+			return nil, fmt.Errorf("sensor pipeline: %s: %w", entity.Name, err)
+		}
+		pipeline = append(pipeline, subdevPad{path: path, pad: source.Index})
+
+		if entity.Function == v4l2.MediaEntityFunctionCamSensor {
+			for i, j := 0, len(pipeline)-1; i < j; i, j = i+1, j-1 {
+				pipeline[i], pipeline[j] = pipeline[j], pipeline[i]
+			}
+			return pipeline, nil
+		}
+		entityID = entity.ID
+	}
+	return nil, fmt.Errorf("sensor pipeline: no sensor found: %w", v4l2.ErrorUnsupportedFeature)
+}
+
+// upstreamLink returns the source and sink pads of the enabled data link feeding the entity
+func upstreamLink(topo v4l2.MediaTopology, entityID uint32) (v4l2.MediaPad, v4l2.MediaPad, bool) {
+	for _, link := range topo.Links {
+		if link.Flags&v4l2.MediaLinkFlagTypeMask != v4l2.MediaLinkFlagDataLink || link.Flags&v4l2.MediaLinkFlagEnabled == 0 {
+			continue
+		}
+		sink, ok := topo.Pad(link.SinkID)
+		if !ok || sink.EntityID != entityID {
+			continue
+		}
+		if source, ok := topo.Pad(link.SourceID); ok {
+			return source, sink, true
+		}
+	}
+	return v4l2.MediaPad{}, v4l2.MediaPad{}, false
+}
+
+// entityDevicePath returns the device node path of the entity, resolved from the
+// major:minor numbers of its interface using sysfs.
+func entityDevicePath(topo v4l2.MediaTopology, entityID uint32) (string, error) {
+	intf, ok := topo.EntityInterface(entityID)
+	if !ok {
+		return "", fmt.Errorf("no device node: %w", v4l2.ErrorUnsupportedFeature)
+	}
+	return devNodePath(intf.DevMajor, intf.DevMinor)
+}
+
+// devNodePath returns the path of the character device major:minor, resolved using sysfs
+func devNodePath(major, minor uint32) (string, error) {
+	file, err := os.Open(fmt.Sprintf("/sys/dev/char/%d:%d/uevent", major, minor))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if name := strings.TrimPrefix(scanner.Text(), "DEVNAME="); name != scanner.Text() {
+			return filepath.Join("/dev", name), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("device %d:%d: no device name", major, minor)
+}
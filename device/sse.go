@@ -0,0 +1,95 @@
+package device
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// sseHandler serves the frames of a device as server-sent events
+type sseHandler struct {
+	dev *Device
+
+	mu          sync.Mutex
+	broadcaster *Broadcaster
+}
+
+// SSEHandler returns an http.Handler that streams the frames captured by dev as
+// server-sent events, one "data:" event per frame holding a JPEG data URL (see
+// v4l2.FrameToDataURL) that can be assigned directly to an img element. Frames are
+// dropped for clients that fall behind, and a client stream ends when the client
+// disconnects or the device stream stops. The device stream must be started by the caller.
+// The device frames (see Device.Frames) are only read while clients are connected, they are
+// shared with other consumers of the device otherwise.
+func SSEHandler(dev *Device) http.Handler {
+	return &sseHandler{dev: dev}
+}
+
+// subscribe returns a subscription to the device frames, (re)starting the broadcast
+// when the device stream was (re)started.
+func (h *sseHandler) subscribe() (<-chan v4l2.Frame, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	frames := h.dev.Frames()
+	if h.broadcaster != nil && h.broadcaster.source != frames {
+		h.broadcaster.stop()
+		h.broadcaster = nil
+	}
+	if h.broadcaster == nil {
+		h.broadcaster = NewBroadcaster(frames)
+	} else {
+		select {
+		case <-h.broadcaster.Done():
+			h.broadcaster = NewBroadcaster(frames)
+		default:
+		}
+	}
+	return h.broadcaster.Subscribe(1)
+}
+
+func (h *sseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	if !h.dev.IsStreaming() {
+		http.Error(w, "device not streaming", http.StatusServiceUnavailable)
+		return
+	}
+	pixFmt, err := h.dev.GetPixFormat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	frames, unsubscribe := h.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case frame, ok := <-frames:
+			if !ok {
+				return
+			}
+			url, err := v4l2.FrameToDataURL(frame, pixFmt)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", url); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
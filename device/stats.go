@@ -0,0 +1,67 @@
+package device
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// Stats are counters of the frames captured since the stream was started
+type Stats struct {
+	// FramesDelivered is the number of frames delivered on the frames channel
+	FramesDelivered uint64
+
+	// FramesErrored is the number of delivered frames flagged with v4l2.BufFlagError
+	FramesErrored uint64
+
+	// FramesOutOfOrder is the number of delivered frames whose sequence went backward
+	// (only counted with WithOutOfOrderCheck)
+	FramesOutOfOrder uint64
+
+	// FramesDropped is the number of frames missed by the driver, detected from the gaps
+	// in the sequence numbers of dequeued buffers (i.e. when buffers are not re-queued in time)
+	FramesDropped uint64
+}
+
+// Stats returns the capture counters since the stream was started
+func (d *Device) Stats() Stats {
+	return Stats{
+		FramesDelivered:  atomic.LoadUint64(&d.stats.FramesDelivered),
+		FramesErrored:    atomic.LoadUint64(&d.stats.FramesErrored),
+		FramesOutOfOrder: atomic.LoadUint64(&d.stats.FramesOutOfOrder),
+		FramesDropped:    atomic.LoadUint64(&d.stats.FramesDropped),
+	}
+}
+
+// FramesDropped returns the number of frames dropped since the stream was started (see Stats)
+func (d *Device) FramesDropped() uint64 {
+	return atomic.LoadUint64(&d.stats.FramesDropped)
+}
+
+func (d *Device) countDelivered(frame v4l2.Frame) {
+	atomic.AddUint64(&d.stats.FramesDelivered, 1)
+	if frame.Flags&v4l2.BufFlagError != 0 {
+		atomic.AddUint64(&d.stats.FramesErrored, 1)
+	}
+	if d.health != nil {
+		d.health.frame()
+	}
+}
+
+// sequenceGap returns the number of frames missing between the sequence numbers of two
+// consecutively dequeued buffers. The sequence counter wraps around, and a backward jump
+// (see WithOutOfOrderCheck) is not counted as dropped frames.
+func sequenceGap(last, seq uint32) uint32 {
+	gap := seq - last
+	if gap == 0 || gap > math.MaxUint32/2 {
+		return 0
+	}
+	return gap - 1
+}
+
+// sequenceBefore returns true if sequence number seq precedes last, taking the wrap around
+// of the sequence counter into account (i.e. 0 follows math.MaxUint32).
+func sequenceBefore(seq, last uint32) bool {
+	return int32(seq-last) < 0
+}
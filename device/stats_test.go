@@ -0,0 +1,37 @@
+package device
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSequenceGap(t *testing.T) {
+	tests := []struct {
+		last, seq uint32
+		dropped   uint32
+	}{
+		{last: 4, seq: 5, dropped: 0},
+		{last: 4, seq: 8, dropped: 3},
+		{last: 4, seq: 4, dropped: 0},
+		{last: 8, seq: 4, dropped: 0}, // out of order
+		{last: math.MaxUint32, seq: 0, dropped: 0},
+		{last: math.MaxUint32 - 1, seq: 2, dropped: 3},
+	}
+	for _, test := range tests {
+		if got := sequenceGap(test.last, test.seq); got != test.dropped {
+			t.Errorf("sequenceGap(%d, %d) = %d, want %d", test.last, test.seq, got, test.dropped)
+		}
+	}
+}
+
+func TestSequenceBefore(t *testing.T) {
+	if !sequenceBefore(4, 8) {
+		t.Error("expecting 4 before 8")
+	}
+	if sequenceBefore(8, 4) || sequenceBefore(4, 4) {
+		t.Error("expecting 8 and 4 not before 4")
+	}
+	if sequenceBefore(0, math.MaxUint32) {
+		t.Error("expecting 0 to follow MaxUint32 when the counter wraps")
+	}
+}
@@ -0,0 +1,57 @@
+package device
+
+import (
+	"context"
+	"fmt"
+	"image"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// StreamImages writes the images received from the channel to a video output device (i.e. a
+// v4l2loopback virtual webcam), until the channel is closed or ctx is done. Each image is
+// converted to the negotiated pixel format with v4l2.EncodeImage (scaled to the format size
+// if needed) and written (see Write). It blocks while streaming, turns the stream off and
+// releases the buffers before returning, and requires a device opened for video output
+// (see WithVideoOutputEnabled) with memory mapped IO.
+func (d *Device) StreamImages(ctx context.Context, images <-chan image.Image) error {
+	if !d.isOutput() {
+		return fmt.Errorf("device: %s: stream images: %w", d.path, v4l2.ErrorUnsupportedFeature)
+	}
+	if d.IsStreaming() {
+		return fmt.Errorf("device: %s: stream images: stream started", d.path)
+	}
+	if d.config.ioType != v4l2.IOTypeMMAP {
+		return fmt.Errorf("device: %s: stream images: %w", d.path, v4l2.ErrStreamingUnsupported)
+	}
+
+	pixFmt, err := d.currentPixFormat()
+	if err != nil {
+		return fmt.Errorf("device: %s: stream images: %w", d.path, err)
+	}
+	defer d.Stop()
+
+	for {
+		var img image.Image
+		select {
+		case <-ctx.Done():
+			return nil
+		case i, ok := <-images:
+			if !ok {
+				return nil
+			}
+			img = i
+		}
+
+		data, err := v4l2.EncodeImage(img, pixFmt)
+		if err != nil {
+			return fmt.Errorf("device: %s: stream images: %w", d.path, err)
+		}
+		if err := d.writeFrame(ctx, data); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("device: %s: stream images: %w", d.path, err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package device
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// FrameIndexEntry describes where a frame was written by StreamToIndexedWriter. One entry
+// is written per frame, as a JSON line, to the index writer.
+type FrameIndexEntry struct {
+	Offset    int64        `json:"offset"`
+	Length    int          `json:"length"`
+	Timestamp time.Time    `json:"timestamp"`
+	Sequence  uint32       `json:"sequence"`
+	Flags     v4l2.BufFlag `json:"flags"`
+}
+
+// StreamToWriter writes the data of each frame captured by the started device to w, back to back.
+// It blocks until ctx is done or the stream stops. When the stream stopped because of an
+// error (see Err), that error is returned.
+func (d *Device) StreamToWriter(ctx context.Context, w io.Writer) error {
+	return d.StreamToIndexedWriter(ctx, w, nil)
+}
+
+// StreamToIndexedWriter writes the data of each frame captured by the started device to data,
+// and a FrameIndexEntry (JSON line) per frame to index, recording the frame byte offset in data
+// along with its timestamp and sequence. The index makes it possible to seek within, and play
+// back with accurate timing, a raw capture. A nil index only writes the frame data.
+// It blocks until ctx is done or the stream stops.
+func (d *Device) StreamToIndexedWriter(ctx context.Context, data io.Writer, index io.Writer) error {
+	var enc *json.Encoder
+	if index != nil {
+		enc = json.NewEncoder(index)
+	}
+
+	var offset int64
+	return d.streamFrames(ctx, func(frame v4l2.Frame) error {
+		n, err := data.Write(frame.Data)
+		if err != nil {
+			return fmt.Errorf("device: %s: stream to writer: %w", d.path, err)
+		}
+		if enc != nil {
+			entry := FrameIndexEntry{
+				Offset:    offset,
+				Length:    n,
+				Timestamp: frame.Timestamp,
+				Sequence:  frame.Sequence,
+				Flags:     frame.Flags,
+			}
+			if err := enc.Encode(entry); err != nil {
+				return fmt.Errorf("device: %s: stream to writer: index: %w", d.path, err)
+			}
+		}
+		offset += int64(n)
+		return nil
+	})
+}
+
+// StreamToEncodedWriter encodes each frame captured by the started device with enc
+// (i.e. v4l2.PNGEncoder) and writes the encoded images to w, back to back.
+// It blocks until ctx is done or the stream stops.
+func (d *Device) StreamToEncodedWriter(ctx context.Context, w io.Writer, enc v4l2.FrameEncoder) error {
+	pixFmt, err := d.GetPixFormat()
+	if err != nil {
+		return fmt.Errorf("device: %s: stream to writer: %w", d.path, err)
+	}
+	return d.streamFrames(ctx, func(frame v4l2.Frame) error {
+		if err := v4l2.EncodeFrame(w, frame, pixFmt, enc); err != nil {
+			return fmt.Errorf("device: %s: stream to writer: %w", d.path, err)
+		}
+		return nil
+	})
+}
+
+// streamFrames calls fn for each frame captured by the started device until ctx is done,
+// the stream stops, or fn returns an error.
+func (d *Device) streamFrames(ctx context.Context, fn func(v4l2.Frame) error) error {
+	if !d.IsStreaming() {
+		return fmt.Errorf("device: %s: stream to writer: stream not started", d.path)
+	}
+
+	frames := d.Frames()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case frame, ok := <-frames:
+			if !ok {
+				return d.Err()
+			}
+			if err := fn(frame); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+	sys "golang.org/x/sys/unix"
+)
+
+// Subdev is a V4L2 sub-device (/dev/v4l-subdevX), an element of a media controller pipeline
+// (i.e. an image sensor or a CSI-2 receiver) configured directly rather than through the
+// video node: the media bus format of each of its pads, and its controls (i.e. the sensor
+// analogue gain and exposure).
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/dev-subdev.html
+type Subdev struct {
+	path string
+	fd   uintptr
+}
+
+// OpenSubdev opens the sub-device at path
+func OpenSubdev(path string) (*Subdev, error) {
+	fd, err := v4l2.OpenDevice(path, sys.O_RDWR|sys.O_NONBLOCK|sys.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("device open: %s: %w", path, err)
+	}
+	return &Subdev{path: path, fd: fd}, nil
+}
+
+// Close closes the sub-device
+func (s *Subdev) Close() error {
+	return v4l2.CloseDevice(s.fd)
+}
+
+// Name returns the path of the sub-device
+func (s *Subdev) Name() string {
+	return s.path
+}
+
+// Fd returns the file descriptor of the sub-device
+func (s *Subdev) Fd() uintptr {
+	return s.fd
+}
+
+// GetFormat returns the media bus format of pad. The which argument selects the format
+// applied to the device (v4l2.SubdevFormatActive) or the format being negotiated
+// (v4l2.SubdevFormatTry).
+func (s *Subdev) GetFormat(pad uint32, which v4l2.SubdevFormatWhence) (v4l2.MbusFramefmt, error) {
+	mbusFmt, err := v4l2.GetSubdevFormat(s.fd, pad, which)
+	if err != nil {
+		return v4l2.MbusFramefmt{}, fmt.Errorf("device: %s: %w", s.path, err)
+	}
+	return mbusFmt, nil
+}
+
+// SetFormat sets the media bus format of pad and returns the format adjusted by the driver.
+// With v4l2.SubdevFormatTry, the format is only negotiated and the device is left unchanged.
+func (s *Subdev) SetFormat(pad uint32, which v4l2.SubdevFormatWhence, mbusFmt v4l2.MbusFramefmt) (v4l2.MbusFramefmt, error) {
+	mbusFmt, err := v4l2.SetSubdevFormat(s.fd, pad, which, mbusFmt)
+	if err != nil {
+		return v4l2.MbusFramefmt{}, fmt.Errorf("device: %s: %w", s.path, err)
+	}
+	return mbusFmt, nil
+}
+
+// GetMbusCodes returns the media bus codes supported on pad
+func (s *Subdev) GetMbusCodes(pad uint32) ([]uint32, error) {
+	codes, err := v4l2.GetSubdevMbusCodes(s.fd, pad)
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: %w", s.path, err)
+	}
+	return codes, nil
+}
+
+// GetFrameSizes returns the frame sizes supported on pad for the media bus code
+func (s *Subdev) GetFrameSizes(pad, code uint32) ([]v4l2.FrameSize, error) {
+	sizes, err := v4l2.GetSubdevFrameSizes(s.fd, pad, code)
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: %w", s.path, err)
+	}
+	return sizes, nil
+}
+
+// GetEDID returns the EDID of the receiver pad (i.e. of an HDMI to CSI-2 bridge), nil when
+// none is set (see Device.GetEDID).
+func (s *Subdev) GetEDID(pad uint32) ([]byte, error) {
+	edid, err := v4l2.GetEDID(s.fd, pad)
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: %w", s.path, err)
+	}
+	return edid, nil
+}
+
+// SetEDID sets the EDID of the receiver pad, an empty EDID clears it (see Device.SetEDID).
+func (s *Subdev) SetEDID(pad uint32, edid []byte) error {
+	if err := v4l2.SetEDID(s.fd, pad, edid); err != nil {
+		return fmt.Errorf("device: %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// GetControl returns information about the control, along with its current value. Controls
+// are accessed with the extended control API, the only one most sub-device drivers support.
+func (s *Subdev) GetControl(id v4l2.CtrlID) (v4l2.Control, error) {
+	ctrl, err := v4l2.GetExtControl(s.fd, id)
+	if err != nil {
+		return v4l2.Control{}, fmt.Errorf("device: %s: %w", s.path, err)
+	}
+	return ctrl, nil
+}
+
+// SetControlValue updates the value of the control (i.e. v4l2.CtrlImgSrcAnalogueGain)
+func (s *Subdev) SetControlValue(id v4l2.CtrlID, val v4l2.CtrlValue) error {
+	if err := v4l2.SetExtControlValue(s.fd, id, val); err != nil {
+		return fmt.Errorf("device: %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// QueryControls returns information about every control of the sub-device, without their
+// current values (see Device.QueryControls).
+func (s *Subdev) QueryControls() ([]v4l2.ControlInfo, error) {
+	var result []v4l2.ControlInfo
+	next := v4l2.CtrlFlagNextControl | v4l2.CtrlFlagNextCompound
+	for cid := next; ; {
+		ctrl, err := v4l2.QueryExtControlInfo(s.fd, cid)
+		if err != nil {
+			if errors.Is(err, v4l2.ErrControlNotSupported) && len(result) > 0 {
+				break
+			}
+			return result, fmt.Errorf("device: %s: query controls: %w", s.path, err)
+		}
+		result = append(result, ctrl)
+		cid = ctrl.ID | next
+	}
+	return result, nil
+}
+
+// GetExtControls retrieves the values of several controls at once, storing them in ctrls
+// (see Device.GetExtControls).
+func (s *Subdev) GetExtControls(which v4l2.CtrlWhich, ctrls []v4l2.ExtControl) error {
+	if err := v4l2.GetExtControls(s.fd, which, ctrls); err != nil {
+		return fmt.Errorf("device: %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// SetExtControls applies the values of several controls atomically (i.e. the analogue gain
+// and the exposure of a sensor), see Device.SetExtControls.
+func (s *Subdev) SetExtControls(ctrls []v4l2.ExtControl) error {
+	if err := v4l2.SetExtControls(s.fd, v4l2.CtrlWhichCurVal, ctrls); err != nil {
+		return fmt.Errorf("device: %s: %w", s.path, err)
+	}
+	return nil
+}
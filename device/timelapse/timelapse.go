@@ -0,0 +1,179 @@
+// Package timelapse captures single frames from a device on a wall-clock schedule.
+package timelapse
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vladimirvivien/go4vl/device"
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// DefaultSettleFrames is the default number of frames dropped after the stream
+// starts, before a frame is captured, to let auto exposure and white balance settle.
+const DefaultSettleFrames = 5
+
+// Timelapse captures one frame per interval from a device
+type Timelapse struct {
+	// SettleFrames is the number of frames dropped before each capture (see DefaultSettleFrames)
+	SettleFrames int
+
+	// KeepStreaming keeps the device streaming between captures. By default, the stream is
+	// stopped after each capture to save power, and restarted for the next capture.
+	KeepStreaming bool
+
+	dev      *device.Device
+	interval time.Duration
+	alignTo  time.Duration
+
+	mu  sync.Mutex
+	err error
+}
+
+// New creates a Timelapse that captures a frame from dev every interval. When alignTo is
+// positive, captures are aligned to wall-clock boundaries of alignTo (i.e. time.Minute to
+// capture on the minute). The device must not be streaming when the timelapse is started.
+func New(dev *device.Device, interval time.Duration, alignTo time.Duration) *Timelapse {
+	return &Timelapse{
+		SettleFrames: DefaultSettleFrames,
+		dev:          dev,
+		interval:     interval,
+		alignTo:      alignTo,
+	}
+}
+
+// Start runs the timelapse until ctx is done, delivering captured frames on the returned
+// channel. The channel is closed when the timelapse stops, if it stopped because of an
+// error, it is returned by Err.
+func (t *Timelapse) Start(ctx context.Context) (<-chan v4l2.Frame, error) {
+	if t.interval <= 0 {
+		return nil, fmt.Errorf("timelapse: invalid interval %s", t.interval)
+	}
+	if t.dev.IsStreaming() {
+		return nil, fmt.Errorf("timelapse: %s: stream already started", t.dev.Name())
+	}
+
+	frames := make(chan v4l2.Frame, 1)
+	go func() {
+		defer close(frames)
+
+		var stopStream context.CancelFunc
+		defer func() {
+			if stopStream != nil {
+				stopStream()
+			}
+		}()
+
+		start := firstCapture(time.Now(), t.alignTo)
+		for {
+			timer := time.NewTimer(time.Until(nextCapture(time.Now(), start, t.interval)))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			if stopStream == nil {
+				streamCtx, cancel := context.WithCancel(ctx)
+				if err := t.dev.Start(streamCtx); err != nil {
+					cancel()
+					t.setErr(fmt.Errorf("timelapse: %w", err))
+					return
+				}
+				stopStream = cancel
+			}
+
+			frame, err := t.capture(ctx)
+			if !t.KeepStreaming {
+				stopStream()
+				stopStream = nil
+				for range t.dev.Frames() {
+					// wait for the stream loop to stop
+				}
+			}
+			if err != nil {
+				t.setErr(err)
+				return
+			}
+
+			select {
+			case frames <- frame:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return frames, nil
+}
+
+// Err returns the error that stopped the timelapse, if any
+func (t *Timelapse) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+func (t *Timelapse) setErr(err error) {
+	t.mu.Lock()
+	t.err = err
+	t.mu.Unlock()
+}
+
+// capture drops stale and settling frames, then returns the next frame
+func (t *Timelapse) capture(ctx context.Context) (v4l2.Frame, error) {
+	frames := t.dev.Frames()
+
+	// drop frames buffered since the previous capture (when streaming continuously)
+	for drained := false; !drained; {
+		select {
+		case <-frames:
+		default:
+			drained = true
+		}
+	}
+
+	for skip := t.SettleFrames; ; skip-- {
+		select {
+		case <-ctx.Done():
+			return v4l2.Frame{}, ctx.Err()
+		case frame, ok := <-frames:
+			if !ok {
+				if err := t.dev.Err(); err != nil {
+					return v4l2.Frame{}, fmt.Errorf("timelapse: %w", err)
+				}
+				return v4l2.Frame{}, fmt.Errorf("timelapse: %s: stream stopped", t.dev.Name())
+			}
+			if skip <= 0 {
+				return frame, nil
+			}
+		}
+	}
+}
+
+// firstCapture returns the time of the first capture: now, or the next alignTo boundary
+func firstCapture(now time.Time, alignTo time.Duration) time.Time {
+	if alignTo <= 0 {
+		return now
+	}
+	first := now.Truncate(alignTo)
+	if first.Before(now) {
+		first = first.Add(alignTo)
+	}
+	return first
+}
+
+// nextCapture returns the first time of the schedule (start + k*interval) not before now
+func nextCapture(now, start time.Time, interval time.Duration) time.Time {
+	if !now.After(start) {
+		return start
+	}
+	elapsed := now.Sub(start)
+	k := elapsed / interval
+	if elapsed%interval != 0 {
+		k++
+	}
+	return start.Add(k * interval)
+}
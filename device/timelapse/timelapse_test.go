@@ -0,0 +1,34 @@
+package timelapse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	now := time.Date(2021, 6, 1, 10, 30, 20, 0, time.UTC)
+
+	if first := firstCapture(now, 0); !first.Equal(now) {
+		t.Errorf("expecting unaligned first capture at %s, got %s", now, first)
+	}
+	first := firstCapture(now, time.Minute)
+	if want := time.Date(2021, 6, 1, 10, 31, 0, 0, time.UTC); !first.Equal(want) {
+		t.Errorf("expecting first capture at %s, got %s", want, first)
+	}
+
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{now, first},
+		{first, first},
+		{first.Add(time.Second), first.Add(5 * time.Minute)},
+		{first.Add(5 * time.Minute), first.Add(5 * time.Minute)},
+		{first.Add(12 * time.Minute), first.Add(15 * time.Minute)},
+	}
+	for _, test := range tests {
+		if next := nextCapture(test.now, first, 5*time.Minute); !next.Equal(test.want) {
+			t.Errorf("now %s: expecting next capture at %s, got %s", test.now, test.want, next)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package device
+
+import (
+	"fmt"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// EnumTuners returns the tuners of the device (TV and radio receivers, SDR). Devices
+// without tuner return an empty list.
+func (d *Device) EnumTuners() ([]v4l2.TunerInfo, error) {
+	tuners, err := v4l2.GetAllTuners(d.fd)
+	if err != nil {
+		return nil, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return tuners, nil
+}
+
+// GetTuner returns the tuner at index, including its current signal strength and AFC.
+// Use TunerInfo.ToHz and TunerInfo.FromHz to convert its frequencies.
+func (d *Device) GetTuner(index uint32) (v4l2.TunerInfo, error) {
+	tuner, err := v4l2.GetTunerInfo(d.fd, index)
+	if err != nil {
+		return v4l2.TunerInfo{}, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return tuner, nil
+}
+
+// SetTuner selects the audio mode (mono, stereo, ...) of the tuner at index.
+func (d *Device) SetTuner(index uint32, mode v4l2.TunerAudioMode) error {
+	if err := v4l2.SetTunerAudioMode(d.fd, index, mode); err != nil {
+		return fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return nil
+}
+
+// GetFrequency returns the frequency of tuner, in the tuner frequency units
+// (see TunerInfo.FrequencyUnit).
+func (d *Device) GetFrequency(tuner uint32) (uint32, error) {
+	freq, err := v4l2.GetFrequency(d.fd, tuner)
+	if err != nil {
+		return 0, fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return freq.Frequency, nil
+}
+
+// SetFrequency tunes tuner to freq, expressed in the tuner frequency units
+// (see TunerInfo.FrequencyUnit). The driver clamps freq to the tuner range.
+func (d *Device) SetFrequency(tuner, freq uint32) error {
+	info, err := v4l2.GetTunerInfo(d.fd, tuner)
+	if err != nil {
+		return fmt.Errorf("device: %s: set frequency: %w", d.path, err)
+	}
+	if err := v4l2.SetFrequency(d.fd, v4l2.Frequency{Tuner: tuner, Type: info.Type, Frequency: freq}); err != nil {
+		return fmt.Errorf("device: %s: %w", d.path, err)
+	}
+	return nil
+}
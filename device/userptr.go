@@ -0,0 +1,52 @@
+package device
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// validateUserBuffers checks that the user buffers (see WithUserBuffers) can back the
+// device buffers granted by the driver for the current format.
+func (d *Device) validateUserBuffers(count uint32) error {
+	if d.isMultiPlanar() {
+		return fmt.Errorf("user buffers: multi-planar buffers: %w", v4l2.ErrorUnsupportedFeature)
+	}
+	if int(count) > len(d.config.userBufs) {
+		return fmt.Errorf("user buffers: driver requires %d buffers, %d provided", count, len(d.config.userBufs))
+	}
+	pixFmt, err := v4l2.GetPixFormat(d.fd)
+	if err != nil {
+		return fmt.Errorf("user buffers: %w", err)
+	}
+	for i, buf := range d.config.userBufs[:count] {
+		if err := v4l2.ValidateUserBuffer(buf, pixFmt.SizeImage); err != nil {
+			return fmt.Errorf("user buffers: buffer %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// userBuffer returns the user buffer the dequeued buffer was captured into. Drivers report
+// the user pointer of the buffer, which identifies the user buffer even if the driver
+// dequeued it at a different index than it was queued with.
+func (d *Device) userBuffer(buff v4l2.Buffer) ([]byte, bool) {
+	bufs := d.config.userBufs
+	if int(buff.Index) < len(bufs) && userPtr(bufs[buff.Index]) == buff.Info.UserPtr {
+		return bufs[buff.Index], true
+	}
+	for _, buf := range bufs {
+		if userPtr(buf) == buff.Info.UserPtr {
+			return buf, true
+		}
+	}
+	return nil, false
+}
+
+func userPtr(buf []byte) uintptr {
+	if len(buf) == 0 {
+		return 0
+	}
+	return uintptr(unsafe.Pointer(&buf[0]))
+}
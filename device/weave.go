@@ -0,0 +1,41 @@
+package device
+
+import (
+	"context"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// weave forwards frames from in to out, combining each pair of top and bottom fields of the
+// same sequence into a full frame (see v4l2.WeaveFields). A field that is not followed by its
+// pair (dropped by the driver) is discarded. out is closed when in is closed or ctx is done.
+func weave(ctx context.Context, in <-chan v4l2.Frame, out chan<- v4l2.Frame, bytesPerLine uint32) {
+	defer close(out)
+
+	var held v4l2.Frame
+	var holding bool
+	for field := range in {
+		if !field.IsTopField() && !field.IsBottomField() {
+			continue
+		}
+		if !holding || held.Sequence != field.Sequence || held.Field == field.Field {
+			held, holding = field, true // first field of a frame, or orphan replaced
+			continue
+		}
+
+		top, bottom := held, field
+		if held.IsBottomField() {
+			top, bottom = field, held
+		}
+		holding = false
+		frame, err := v4l2.WeaveFields(top, bottom, bytesPerLine)
+		if err != nil {
+			continue
+		}
+		select {
+		case out <- frame:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
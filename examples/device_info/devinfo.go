@@ -168,14 +168,14 @@ func printFormatInfo(dev *device2.Device) error {
 	// xferfunc
 	xfunc := v4l2.XferFunctions[pixFmt.XferFunc]
 	if pixFmt.XferFunc == v4l2.XferFuncDefault {
-		xfunc = fmt.Sprintf("%s (map to %s)", xfunc, v4l2.XferFunctions[v4l2.ColorspaceToXferFunc(pixFmt.XferFunc)])
+		xfunc = fmt.Sprintf("%s (map to %s)", xfunc, v4l2.XferFunctions[v4l2.ColorspaceToXferFunc(pixFmt.Colorspace)])
 	}
 	fmt.Printf(template, "Transfer function", xfunc)
 
 	// ycbcr
 	ycbcr := v4l2.YCbCrEncodings[pixFmt.YcbcrEnc]
 	if pixFmt.YcbcrEnc == v4l2.YCbCrEncodingDefault {
-		ycbcr = fmt.Sprintf("%s (map to %s)", ycbcr, v4l2.YCbCrEncodings[v4l2.ColorspaceToYCbCrEnc(pixFmt.YcbcrEnc)])
+		ycbcr = fmt.Sprintf("%s (map to %s)", ycbcr, v4l2.YCbCrEncodings[v4l2.ColorspaceToYCbCrEnc(pixFmt.Colorspace)])
 	}
 	fmt.Printf(template, "YCbCr/HSV encoding", ycbcr)
 
@@ -216,7 +216,7 @@ func printFormatDesc(dev *device2.Device) error {
 }
 
 func printCropInfo(dev *device2.Device) error {
-	crop, err := dev.GetCropCapability()
+	crop, err := dev.GetCropCapabilities()
 	if err != nil {
 		return fmt.Errorf("crop capability: %w", err)
 	}
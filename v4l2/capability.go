@@ -8,7 +8,10 @@ import "C"
 
 import (
 	"fmt"
+	"strings"
 	"unsafe"
+
+	sys "golang.org/x/sys/unix"
 )
 
 // V4l2 video capability constants
@@ -98,6 +101,105 @@ var (
 	}
 )
 
+// CapabilityFlags is a capability bitfield (V4L2_CAP_*) with typed accessors, see
+// Capability.GetDriverCapFlags and Capability.GetDeviceCapFlags.
+type CapabilityFlags uint32
+
+// Has returns true if all the bits of caps are set
+func (f CapabilityFlags) Has(caps uint32) bool {
+	return uint32(f)&caps == caps
+}
+
+// HasVideoCapture returns flags & CapVideoCapture
+func (f CapabilityFlags) HasVideoCapture() bool { return f.Has(CapVideoCapture) }
+
+// HasVideoCaptureMPlane returns flags & CapVideoCaptureMPlane
+func (f CapabilityFlags) HasVideoCaptureMPlane() bool { return f.Has(CapVideoCaptureMPlane) }
+
+// HasVideoOutput returns flags & CapVideoOutput
+func (f CapabilityFlags) HasVideoOutput() bool { return f.Has(CapVideoOutput) }
+
+// HasVideoOutputMPlane returns flags & CapVideoOutputMPlane
+func (f CapabilityFlags) HasVideoOutputMPlane() bool { return f.Has(CapVideoOutputMPlane) }
+
+// HasVideoOverlay returns flags & CapVideoOverlay
+func (f CapabilityFlags) HasVideoOverlay() bool { return f.Has(CapVideoOverlay) }
+
+// HasVideoOutputOverlay returns flags & CapVideoOutputOverlay
+func (f CapabilityFlags) HasVideoOutputOverlay() bool { return f.Has(CapVideoOutputOverlay) }
+
+// HasVideoM2M returns flags & CapVideoMem2Mem
+func (f CapabilityFlags) HasVideoM2M() bool { return f.Has(CapVideoMem2Mem) }
+
+// HasVideoM2MMPlane returns flags & CapVideoMem2MemMPlane
+func (f CapabilityFlags) HasVideoM2MMPlane() bool { return f.Has(CapVideoMem2MemMPlane) }
+
+// HasVBICapture returns flags & CapVBICapture
+func (f CapabilityFlags) HasVBICapture() bool { return f.Has(CapVBICapture) }
+
+// HasVBIOutput returns flags & CapVBIOutput
+func (f CapabilityFlags) HasVBIOutput() bool { return f.Has(CapVBIOutput) }
+
+// HasMetadataCapture returns flags & CapMetadataCapture
+func (f CapabilityFlags) HasMetadataCapture() bool { return f.Has(CapMetadataCapture) }
+
+// HasMetadataOutput returns flags & CapMetadataOutput
+func (f CapabilityFlags) HasMetadataOutput() bool { return f.Has(CapMetadataOutput) }
+
+// HasSDRCapture returns flags & CapSDRCapture
+func (f CapabilityFlags) HasSDRCapture() bool { return f.Has(CapSDRCapture) }
+
+// HasSDROutput returns flags & CapSDROutput
+func (f CapabilityFlags) HasSDROutput() bool { return f.Has(CapSDROutput) }
+
+// HasTuner returns flags & CapTuner
+func (f CapabilityFlags) HasTuner() bool { return f.Has(CapTuner) }
+
+// HasRadio returns flags & CapRadio
+func (f CapabilityFlags) HasRadio() bool { return f.Has(CapRadio) }
+
+// HasModulator returns flags & CapModulator
+func (f CapabilityFlags) HasModulator() bool { return f.Has(CapModulator) }
+
+// HasAudio returns flags & CapAudio
+func (f CapabilityFlags) HasAudio() bool { return f.Has(CapAudio) }
+
+// HasTouch returns flags & CapTouch
+func (f CapabilityFlags) HasTouch() bool { return f.Has(CapTouch) }
+
+// HasReadWrite returns flags & CapReadWrite
+func (f CapabilityFlags) HasReadWrite() bool { return f.Has(CapReadWrite) }
+
+// HasStreaming returns flags & CapStreaming
+func (f CapabilityFlags) HasStreaming() bool { return f.Has(CapStreaming) }
+
+// HasExtendedPixFormat returns flags & CapExtendedPixFormat
+func (f CapabilityFlags) HasExtendedPixFormat() bool { return f.Has(CapExtendedPixFormat) }
+
+// HasMediaController returns flags & CapIOMediaController, the node is configured
+// through the media controller (see GetMediaTopology)
+func (f CapabilityFlags) HasMediaController() bool { return f.Has(CapIOMediaController) }
+
+// Descriptions returns the textual descriptions of the flags
+func (f CapabilityFlags) Descriptions() []CapabilityDesc {
+	var result []CapabilityDesc
+	for _, cap := range Capabilities {
+		if f.Has(cap.Cap) {
+			result = append(result, cap)
+		}
+	}
+	return result
+}
+
+// String returns the descriptions of the flags separated by commas
+func (f CapabilityFlags) String() string {
+	var descs []string
+	for _, cap := range f.Descriptions() {
+		descs = append(descs, cap.Desc)
+	}
+	return strings.Join(descs, ", ")
+}
+
 // Capability represents capabilities retrieved for the device (see v4l2_capability).
 // Use attached methods on this type to access capabilities.
 // https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/videodev2.h#L440
@@ -176,6 +278,16 @@ func (c Capability) IsVideoOutputMultiplanerSupported() bool {
 	return c.Capabilities&CapVideoOutputMPlane != 0
 }
 
+// IsVideoMem2MemSupported returns caps & CapVideoMem2Mem
+func (c Capability) IsVideoMem2MemSupported() bool {
+	return c.Capabilities&CapVideoMem2Mem != 0
+}
+
+// IsVideoMem2MemMultiplanarSupported returns caps & CapVideoMem2MemMPlane
+func (c Capability) IsVideoMem2MemMultiplanarSupported() bool {
+	return c.Capabilities&CapVideoMem2MemMPlane != 0
+}
+
 // IsReadWriteSupported returns caps & CapReadWrite
 func (c Capability) IsReadWriteSupported() bool {
 	return c.Capabilities&CapReadWrite != 0
@@ -186,6 +298,11 @@ func (c Capability) IsStreamingSupported() bool {
 	return c.Capabilities&CapStreaming != 0
 }
 
+// IsTunerSupported returns caps & CapTuner
+func (c Capability) IsTunerSupported() bool {
+	return c.Capabilities&CapTuner != 0
+}
+
 // IsDeviceCapabilitiesProvided returns true if the device returns
 // device-specific capabilities (via CapDeviceCapabilities)
 // See notes on VL42_CAP_DEVICE_CAPS:
@@ -194,6 +311,19 @@ func (c Capability) IsDeviceCapabilitiesProvided() bool {
 	return c.Capabilities&CapDeviceCapabilities != 0
 }
 
+// GetDriverCapFlags returns the capabilities of the physical device, all its nodes
+// included (the capabilities field of v4l2_capability)
+func (c Capability) GetDriverCapFlags() CapabilityFlags {
+	return CapabilityFlags(c.Capabilities)
+}
+
+// GetDeviceCapFlags returns the capabilities of the opened node (the device_caps field of
+// v4l2_capability). Drivers that do not report per-node capabilities are assumed to expose
+// a single node, the driver capabilities are returned.
+func (c Capability) GetDeviceCapFlags() CapabilityFlags {
+	return CapabilityFlags(c.GetCapabilities())
+}
+
 // GetDriverCapDescriptions return textual descriptions of driver capabilities
 func (c Capability) GetDriverCapDescriptions() []CapabilityDesc {
 	var result []CapabilityDesc
@@ -224,3 +354,36 @@ func (c Capability) GetVersionInfo() VersionInfo {
 func (c Capability) String() string {
 	return fmt.Sprintf("driver: %s; card: %s; bus info: %s", c.Driver, c.Card, c.BusInfo)
 }
+
+// DeviceInfo identifies a device node along with its capabilities
+type DeviceInfo struct {
+	// Path is the path of the device node (i.e. /dev/video0)
+	Path string
+
+	Capability
+}
+
+// GetDeviceInfo opens the device node at path, retrieves its capabilities, and closes it
+func GetDeviceInfo(path string) (DeviceInfo, error) {
+	fd, err := OpenDevice(path, sys.O_RDONLY|sys.O_NONBLOCK|sys.O_CLOEXEC, 0)
+	if err != nil {
+		return DeviceInfo{}, fmt.Errorf("device info: %w", err)
+	}
+	defer CloseDevice(fd)
+
+	cap, err := GetCapability(fd)
+	if err != nil {
+		return DeviceInfo{}, fmt.Errorf("device info: %s: %w", path, err)
+	}
+	return DeviceInfo{Path: path, Capability: cap}, nil
+}
+
+// PhysicalDeviceID returns an identifier of the physical device the node belongs to, shared
+// by all the nodes (capture, metadata, etc) of that device: its bus info, or its path when
+// the driver reports no bus info.
+func (i DeviceInfo) PhysicalDeviceID() string {
+	if i.BusInfo == "" {
+		return i.Path
+	}
+	return i.BusInfo
+}
@@ -0,0 +1,26 @@
+package v4l2
+
+import "testing"
+
+func TestCapabilityFlags(t *testing.T) {
+	c := Capability{
+		Capabilities:       CapVideoCapture | CapVideoOutput | CapStreaming | CapReadWrite | CapDeviceCapabilities,
+		DeviceCapabilities: CapVideoCapture | CapStreaming,
+	}
+
+	driver, device := c.GetDriverCapFlags(), c.GetDeviceCapFlags()
+	if !driver.HasVideoOutput() || !driver.HasReadWrite() {
+		t.Errorf("expecting driver video output and read/write, got %s", driver)
+	}
+	if !device.HasVideoCapture() || !device.HasStreaming() {
+		t.Errorf("expecting node video capture and streaming, got %s", device)
+	}
+	if device.HasVideoOutput() || device.HasReadWrite() {
+		t.Errorf("expecting node without video output and read/write, got %s", device)
+	}
+
+	c.Capabilities &^= CapDeviceCapabilities
+	if device := c.GetDeviceCapFlags(); !device.HasVideoOutput() {
+		t.Errorf("expecting driver capabilities without device_caps, got %s", device)
+	}
+}
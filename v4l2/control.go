@@ -9,6 +9,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"unsafe"
 )
@@ -38,8 +39,14 @@ type Control struct {
 	Step    int32
 	Default int32
 	flags   uint32
+	elems   uint32
+	dims    []uint32
 }
 
+// ControlInfo describes a control as reported by the driver (ID, name, type, range, default
+// and flags) without requiring its current value. Menu items are retrieved with GetMenuItems.
+type ControlInfo = Control
+
 type ControlMenuItem struct {
 	ID    uint32
 	Index uint32
@@ -52,7 +59,85 @@ func (c Control) IsMenu() bool {
 	return c.Type == CtrlTypeMenu || c.Type == CtrlTypeIntegerMenu
 }
 
+// IsReadOnly tests whether the control is flagged with CtrlFlagReadOnly
+func (c Control) IsReadOnly() bool {
+	return c.flags&CtrlFlagReadOnly != 0
+}
+
+// IsDisabled tests whether the control is flagged with CtrlFlagDisabled (not usable, should be ignored)
+func (c Control) IsDisabled() bool {
+	return c.flags&CtrlFlagDisabled != 0
+}
+
+// IsInactive tests whether the control is flagged with CtrlFlagInactive (i.e. a manual
+// setting while the matching auto mode is on)
+func (c Control) IsInactive() bool {
+	return c.flags&CtrlFlagInactive != 0
+}
+
+// IsVolatile tests whether the control is flagged with CtrlFlagVolatile (value changed by the driver)
+func (c Control) IsVolatile() bool {
+	return c.flags&CtrlFlagVolatile != 0
+}
+
+// IsArray tests whether the control holds an array of values (it has at least one dimension).
+// The array layout is only reported by the extended control API (see QueryExtControlInfo).
+func (c Control) IsArray() bool {
+	return len(c.dims) > 0
+}
+
+// ElementCount returns the number of elements of the control value, 1 for a non-array control.
+func (c Control) ElementCount() uint32 {
+	if c.elems == 0 {
+		return 1
+	}
+	return c.elems
+}
+
+// Dimensions returns the size of each dimension of an array control, or nil if the control
+// is not an array.
+func (c Control) Dimensions() []uint32 {
+	return c.dims
+}
+
+// Flags returns the control flags reported by the driver (see CtrlFlag)
+func (c Control) Flags() CtrlFlag {
+	return c.flags
+}
+
+// ValueFromFraction maps f, from 0.0 (Minimum) to 1.0 (Maximum), into the control range.
+// The value is rounded to the nearest step and f is clamped to [0, 1], which lets a
+// normalized slider drive any integer control.
+func (c Control) ValueFromFraction(f float64) CtrlValue {
+	if math.IsNaN(f) || f < 0 {
+		f = 0
+	}
+	if f > 1 {
+		f = 1
+	}
+	span := float64(c.Maximum) - float64(c.Minimum)
+	val := float64(c.Minimum) + f*span
+	if c.Step > 1 {
+		val = float64(c.Minimum) + math.Round((val-float64(c.Minimum))/float64(c.Step))*float64(c.Step)
+		if val > float64(c.Maximum) {
+			val -= float64(c.Step)
+		}
+	}
+	return CtrlValue(math.Round(val))
+}
+
+// Fraction returns the position of val within the control range, from 0.0 (Minimum)
+// to 1.0 (Maximum), the reverse of ValueFromFraction.
+func (c Control) Fraction(val CtrlValue) float64 {
+	if c.Maximum <= c.Minimum {
+		return 0
+	}
+	f := (float64(val) - float64(c.Minimum)) / (float64(c.Maximum) - float64(c.Minimum))
+	return math.Max(0, math.Min(1, f))
+}
+
 // GetMenuItems returns control menu items if the associated control is a menu.
+// Menus can be sparse: indices rejected by the driver (EINVAL) are skipped.
 func (c Control) GetMenuItems() (result []ControlMenuItem, err error) {
 	if !c.IsMenu() {
 		return result, fmt.Errorf("control is not a menu type")
@@ -63,7 +148,10 @@ func (c Control) GetMenuItems() (result []ControlMenuItem, err error) {
 		qryMenu.id = C.uint(c.ID)
 		qryMenu.index = C.uint(idx)
 		if err = send(c.fd, C.VIDIOC_QUERYMENU, uintptr(unsafe.Pointer(&qryMenu))); err != nil {
-			continue
+			if errors.Is(err, ErrorBadArgument) {
+				continue
+			}
+			return result, fmt.Errorf("menu items: id %d: index %d: %w", c.ID, idx, err)
 		}
 		result = append(result, makeCtrlMenu(c.Type, qryMenu))
 	}
@@ -71,6 +159,19 @@ func (c Control) GetMenuItems() (result []ControlMenuItem, err error) {
 	return result, nil
 }
 
+// GetIntegerMenuValue returns the 64-bit value of the menu item at index for an integer
+// menu control (CtrlTypeIntegerMenu), i.e. the frequency of a CtrlImgProcLinkFreq item.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-queryctrl.html
+func GetIntegerMenuValue(fd uintptr, id CtrlID, index uint32) (int64, error) {
+	var qryMenu C.struct_v4l2_querymenu
+	qryMenu.id = C.uint(id)
+	qryMenu.index = C.uint(index)
+	if err := send(fd, C.VIDIOC_QUERYMENU, uintptr(unsafe.Pointer(&qryMenu))); err != nil {
+		return 0, fmt.Errorf("integer menu value: id %d: index %d: %w", id, index, err)
+	}
+	return *(*int64)(unsafe.Pointer(&qryMenu.anon0[0])), nil
+}
+
 // GetControlValue retrieves the value for a user control with the specified id.
 // See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/control.html
 // See https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/videodev2.h#L1740
@@ -79,6 +180,9 @@ func GetControlValue(fd uintptr, id CtrlID) (CtrlValue, error) {
 	ctrl.id = C.uint(id)
 
 	if err := send(fd, C.VIDIOC_G_CTRL, uintptr(unsafe.Pointer(&ctrl))); err != nil {
+		if errors.Is(err, ErrorBadArgument) {
+			return 0, fmt.Errorf("get control value: VIDIOC_G_CTRL: id %d: %w", id, ErrControlNotSupported)
+		}
 		return 0, fmt.Errorf("get control value: VIDIOC_G_CTRL: id %d: %w", id, err)
 	}
 
@@ -92,10 +196,10 @@ func GetControlValue(fd uintptr, id CtrlID) (CtrlValue, error) {
 func SetControlValue(fd uintptr, id CtrlID, val CtrlValue) error {
 	ctrlInfo, err := QueryControlInfo(fd, id)
 	if err != nil {
-		return fmt.Errorf("set control value: id %s: %w", id, err)
+		return fmt.Errorf("set control value: id %d: %w", id, err)
 	}
 	if val < ctrlInfo.Minimum || val > ctrlInfo.Maximum {
-		return fmt.Errorf("set control value: out-of-range failure: val %d: expected ctrl.Min %d, ctrl.Max %d", val, ctrlInfo.Minimum, ctrlInfo.Maximum)
+		return fmt.Errorf("set control value: out-of-range failure: val %d: expected ctrl.Min %d, ctrl.Max %d: %w", val, ctrlInfo.Minimum, ctrlInfo.Maximum, ErrorBadArgument)
 	}
 
 	var ctrl C.struct_v4l2_control
@@ -116,6 +220,9 @@ func QueryControlInfo(fd uintptr, id CtrlID) (Control, error) {
 	qryCtrl.id = C.uint(id)
 
 	if err := send(fd, C.VIDIOC_QUERYCTRL, uintptr(unsafe.Pointer(&qryCtrl))); err != nil {
+		if errors.Is(err, ErrorBadArgument) {
+			return Control{}, fmt.Errorf("query control info: VIDIOC_QUERYCTRL: id %d: %w", id, ErrControlNotSupported)
+		}
 		return Control{}, fmt.Errorf("query control info: VIDIOC_QUERYCTRL: id %d: %w", id, err)
 	}
 	control := makeControl(qryCtrl)
@@ -134,7 +241,7 @@ func GetControl(fd uintptr, id CtrlID) (Control, error) {
 	// retrieve control value
 	ctrlValue, err := GetControlValue(fd, uint32(id))
 	if err != nil {
-		return Control{}, fmt.Errorf("get control: %w", id, err)
+		return Control{}, fmt.Errorf("get control: id %d: %w", id, err)
 	}
 
 	control.Value = ctrlValue
@@ -150,7 +257,7 @@ func QueryAllControls(fd uintptr) (result []Control, err error) {
 	for {
 		control, err := QueryControlInfo(fd, cid)
 		if err != nil {
-			if errors.Is(err, ErrorBadArgument) && len(result) > 0 {
+			if errors.Is(err, ErrControlNotSupported) && len(result) > 0 {
 				break
 			}
 			return result, fmt.Errorf("query all controls: %w", err)
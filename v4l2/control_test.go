@@ -0,0 +1,27 @@
+package v4l2
+
+import "testing"
+
+func TestControlFraction(t *testing.T) {
+	ctrl := Control{Minimum: -10, Maximum: 90, Step: 20}
+
+	tests := []struct {
+		f    float64
+		want CtrlValue
+	}{
+		{-1, -10},
+		{0, -10},
+		{0.45, 30},
+		{1, 90},
+		{2, 90},
+	}
+	for _, test := range tests {
+		if got := ctrl.ValueFromFraction(test.f); got != test.want {
+			t.Errorf("ValueFromFraction(%v): expecting %d, got %d", test.f, test.want, got)
+		}
+	}
+
+	if f := ctrl.Fraction(40); f != 0.5 {
+		t.Errorf("expecting fraction 0.5, got %v", f)
+	}
+}
@@ -77,6 +77,28 @@ const (
 	CtrlTypeVP9Frame            CtrlType = C.V4L2_CTRL_TYPE_VP9_FRAME
 )
 
+// CtrlFlag control flags
+// See https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/videodev2.h#L1864
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-queryctrl.html#control-flags
+type CtrlFlag = uint32
+
+const (
+	CtrlFlagDisabled       CtrlFlag = C.V4L2_CTRL_FLAG_DISABLED
+	CtrlFlagGrabbed        CtrlFlag = C.V4L2_CTRL_FLAG_GRABBED
+	CtrlFlagReadOnly       CtrlFlag = C.V4L2_CTRL_FLAG_READ_ONLY
+	CtrlFlagUpdate         CtrlFlag = C.V4L2_CTRL_FLAG_UPDATE
+	CtrlFlagInactive       CtrlFlag = C.V4L2_CTRL_FLAG_INACTIVE
+	CtrlFlagSlider         CtrlFlag = C.V4L2_CTRL_FLAG_SLIDER
+	CtrlFlagWriteOnly      CtrlFlag = C.V4L2_CTRL_FLAG_WRITE_ONLY
+	CtrlFlagVolatile       CtrlFlag = C.V4L2_CTRL_FLAG_VOLATILE
+	CtrlFlagHasPayload     CtrlFlag = C.V4L2_CTRL_FLAG_HAS_PAYLOAD
+	CtrlFlagExecuteOnWrite CtrlFlag = C.V4L2_CTRL_FLAG_EXECUTE_ON_WRITE
+	CtrlFlagModifyLayout   CtrlFlag = C.V4L2_CTRL_FLAG_MODIFY_LAYOUT
+	CtrlFlagDynamicArray   CtrlFlag = C.V4L2_CTRL_FLAG_DYNAMIC_ARRAY
+	CtrlFlagNextControl    CtrlFlag = C.V4L2_CTRL_FLAG_NEXT_CTRL
+	CtrlFlagNextCompound   CtrlFlag = C.V4L2_CTRL_FLAG_NEXT_COMPOUND
+)
+
 // CtrlID type for control values
 type CtrlID = uint32
 
@@ -115,6 +137,31 @@ const (
 	ColorFXSetRGB       ColorFX = C.V4L2_COLORFX_SET_RGB
 )
 
+// ExposureAuto control enums (see CtrlCameraExposureAuto). Shutter priority sets the exposure
+// time manually with an automatic iris, aperture priority sets the iris manually with an
+// automatic exposure time. The exposure time (CtrlCameraExposureAbsolute) can only be set
+// in the ExposureAutoManual and ExposureAutoShutterPriority modes.
+// See https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/v4l2-controls.h#L906
+type ExposureAuto = uint32
+
+const (
+	ExposureAutoAuto             ExposureAuto = C.V4L2_EXPOSURE_AUTO
+	ExposureAutoManual           ExposureAuto = C.V4L2_EXPOSURE_MANUAL
+	ExposureAutoShutterPriority  ExposureAuto = C.V4L2_EXPOSURE_SHUTTER_PRIORITY
+	ExposureAutoAperturePriority ExposureAuto = C.V4L2_EXPOSURE_APERTURE_PRIORITY
+)
+
+// ExposureMetering control enums (see CtrlCameraExposureMetering)
+// See https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/v4l2-controls.h#L962
+type ExposureMetering = uint32
+
+const (
+	ExposureMeteringAverage        ExposureMetering = C.V4L2_EXPOSURE_METERING_AVERAGE
+	ExposureMeteringCenterWeighted ExposureMetering = C.V4L2_EXPOSURE_METERING_CENTER_WEIGHTED
+	ExposureMeteringSpot           ExposureMetering = C.V4L2_EXPOSURE_METERING_SPOT
+	ExposureMeteringMatrix         ExposureMetering = C.V4L2_EXPOSURE_METERING_MATRIX
+)
+
 // User Controls IDs (CIDs)
 // See https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/v4l2-controls.h#L74
 // See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/control.html#control-id
@@ -215,16 +262,22 @@ const (
 // See https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/v4l2-controls.h#L1127
 // See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/ext-ctrls-image-source.html
 const (
-	CtrlImgSrcClass         CtrlID = C.V4L2_CID_IMAGE_SOURCE_CLASS
-	CtrlImgSrcVerticalBlank CtrlID = C.V4L2_CID_VBLANK
+	CtrlImgSrcClass           CtrlID = C.V4L2_CID_IMAGE_SOURCE_CLASS
+	CtrlImgSrcVerticalBlank   CtrlID = C.V4L2_CID_VBLANK
+	CtrlImgSrcHorizontalBlank CtrlID = C.V4L2_CID_HBLANK
+	CtrlImgSrcAnalogueGain    CtrlID = C.V4L2_CID_ANALOGUE_GAIN
 )
 
 // Image process controls
 // See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/ext-ctrls-image-process.html
 // See https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/v4l2-controls.h#L1144
 const (
-	CtrlImgProcClass = C.V4L2_CID_IMAGE_PROC_CLASS
-	// TODO implement all image process values
+	CtrlImgProcClass             CtrlID = C.V4L2_CID_IMAGE_PROC_CLASS
+	CtrlImgProcLinkFreq          CtrlID = C.V4L2_CID_LINK_FREQ  // integer menu, CSI-2 link frequencies [Hz]
+	CtrlImgProcPixelRate         CtrlID = C.V4L2_CID_PIXEL_RATE // 64-bit, read-only pixel rate [pixels/s]
+	CtrlImgProcTestPattern       CtrlID = C.V4L2_CID_TEST_PATTERN
+	CtrlImgProcDeinterlacingMode CtrlID = C.V4L2_CID_DEINTERLACING_MODE
+	CtrlImgProcDigitalGain       CtrlID = C.V4L2_CID_DIGITAL_GAIN
 )
 
 // TODO add code for the following controls
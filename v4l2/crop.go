@@ -4,6 +4,7 @@ package v4l2
 import "C"
 
 import (
+	"errors"
 	"fmt"
 	"unsafe"
 )
@@ -26,25 +27,53 @@ func GetCropCapability(fd uintptr, bufType BufType) (CropCapability, error) {
 	cap._type = C.uint(bufType)
 
 	if err := send(fd, C.VIDIOC_CROPCAP, uintptr(unsafe.Pointer(&cap))); err != nil {
-		return CropCapability{}, fmt.Errorf("crop capability: %w", err)
+		return CropCapability{}, fmt.Errorf("crop capability: %w", cropError(err))
 	}
 
 	return *(*CropCapability)(unsafe.Pointer(&cap)), nil
 }
 
+// GetCropRect retrieves the current cropping rectangle for the buffer type of the device
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-crop.html#ioctl-vidioc-g-crop-vidioc-s-crop
+func GetCropRect(fd uintptr, bufType BufType) (Rect, error) {
+	var crop C.struct_v4l2_crop
+	crop._type = C.uint(bufType)
+
+	if err := send(fd, C.VIDIOC_G_CROP, uintptr(unsafe.Pointer(&crop))); err != nil {
+		return Rect{}, fmt.Errorf("get crop: %w", cropError(err))
+	}
+	return *(*Rect)(unsafe.Pointer(&crop.c)), nil
+}
+
 // SetCropRect sets the cropping dimension for specified device
 // See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-crop.html#ioctl-vidioc-g-crop-vidioc-s-crop
 func SetCropRect(fd uintptr, r Rect) error {
+	return SetCropRectForBufType(fd, BufTypeVideoCapture, r)
+}
+
+// SetCropRectForBufType sets the cropping dimension for the buffer type of the device. The driver may
+// adjust the rectangle, use GetCropRect to read the rectangle applied.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-crop.html#ioctl-vidioc-g-crop-vidioc-s-crop
+func SetCropRectForBufType(fd uintptr, bufType BufType, r Rect) error {
 	var crop C.struct_v4l2_crop
-	crop._type = C.uint(BufTypeVideoCapture)
+	crop._type = C.uint(bufType)
 	crop.c = *(*C.struct_v4l2_rect)(unsafe.Pointer(&r))
 
 	if err := send(fd, C.VIDIOC_S_CROP, uintptr(unsafe.Pointer(&crop))); err != nil {
-		return fmt.Errorf("set crop: %w", err)
+		return fmt.Errorf("set crop: %w", cropError(err))
 	}
 	return nil
 }
 
+// cropError reports a driver without cropping support (ENOTTY, or EINVAL for the buffer
+// type) as ErrorUnsupportedFeature
+func cropError(err error) error {
+	if errors.Is(err, ErrorUnsupported) || errors.Is(err, ErrorBadArgument) {
+		return fmt.Errorf("cropping not supported: %w", ErrorUnsupportedFeature)
+	}
+	return err
+}
+
 func (c CropCapability) String() string {
 	return fmt.Sprintf("default:{top=%d, left=%d, width=%d,height=%d};  bounds:{top=%d, left=%d, width=%d,height=%d}; pixel-aspect{%d:%d}",
 		c.DefaultRect.Top,
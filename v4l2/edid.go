@@ -0,0 +1,60 @@
+package v4l2
+
+// #include <linux/videodev2.h>
+import "C"
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+)
+
+// EDIDBlockSize is the size of an EDID block, EDIDs are made of one or more blocks
+const EDIDBlockSize = 128
+
+// GetEDID reads the EDID of pad (VIDIOC_G_EDID), the EDID presented to the source by
+// an HDMI (or DVI, DisplayPort) receiver. The number of blocks is queried first (blocks set
+// to 0), then the blocks are read. A nil EDID is returned when none is set.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-edid.html
+func GetEDID(fd uintptr, pad uint32) ([]byte, error) {
+	var edid C.struct_v4l2_edid
+	edid.pad = C.uint(pad)
+	if err := send(fd, C.VIDIOC_G_EDID, uintptr(unsafe.Pointer(&edid))); err != nil {
+		return nil, fmt.Errorf("edid: pad %d: %w", pad, err)
+	}
+	if edid.blocks == 0 {
+		return nil, nil
+	}
+
+	buf := make([]byte, int(edid.blocks)*EDIDBlockSize)
+	edid.start_block = 0
+	edid.edid = (*C.uchar)(unsafe.Pointer(&buf[0]))
+	err := send(fd, C.VIDIOC_G_EDID, uintptr(unsafe.Pointer(&edid)))
+	runtime.KeepAlive(buf)
+	if err != nil {
+		return nil, fmt.Errorf("edid: pad %d: %w", pad, err)
+	}
+	return buf[:int(edid.blocks)*EDIDBlockSize], nil
+}
+
+// SetEDID writes the EDID of pad (VIDIOC_S_EDID), its size must be a multiple of
+// EDIDBlockSize. An empty EDID clears the EDID of the pad, which signals the source that
+// no receiver is connected (hotplug deasserted).
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-edid.html
+func SetEDID(fd uintptr, pad uint32, data []byte) error {
+	if len(data)%EDIDBlockSize != 0 {
+		return fmt.Errorf("edid set: pad %d: size %d not a multiple of %d: %w", pad, len(data), EDIDBlockSize, ErrorBadArgument)
+	}
+	var edid C.struct_v4l2_edid
+	edid.pad = C.uint(pad)
+	edid.blocks = C.uint(len(data) / EDIDBlockSize)
+	if len(data) > 0 {
+		edid.edid = (*C.uchar)(unsafe.Pointer(&data[0]))
+	}
+	err := send(fd, C.VIDIOC_S_EDID, uintptr(unsafe.Pointer(&edid)))
+	runtime.KeepAlive(data)
+	if err != nil {
+		return fmt.Errorf("edid set: pad %d: %w", pad, err)
+	}
+	return nil
+}
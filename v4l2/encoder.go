@@ -0,0 +1,70 @@
+package v4l2
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// FrameEncoder encodes decoded frames to an image format (i.e. for serving frames
+// over HTTP or writing them to files).
+type FrameEncoder interface {
+	// Encode writes img to w in the encoder's format
+	Encode(w io.Writer, img image.Image) error
+
+	// ContentType returns the MIME type of the encoded images (i.e. image/jpeg)
+	ContentType() string
+}
+
+// JPEGEncoder encodes frames as JPEG. A zero Quality uses jpeg.DefaultQuality.
+type JPEGEncoder struct {
+	Quality int
+}
+
+func (e JPEGEncoder) Encode(w io.Writer, img image.Image) error {
+	quality := e.Quality
+	if quality == 0 {
+		quality = jpeg.DefaultQuality
+	}
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+}
+
+func (e JPEGEncoder) ContentType() string {
+	return "image/jpeg"
+}
+
+// PNGEncoder encodes frames as PNG with the specified compression level
+type PNGEncoder struct {
+	CompressionLevel png.CompressionLevel
+}
+
+func (e PNGEncoder) Encode(w io.Writer, img image.Image) error {
+	enc := png.Encoder{CompressionLevel: e.CompressionLevel}
+	return enc.Encode(w, img)
+}
+
+func (e PNGEncoder) ContentType() string {
+	return "image/png"
+}
+
+// EncodeFrame decodes the frame data, in the specified format, and writes it to w using enc.
+// JPEG and Motion-JPEG frames are written as-is when enc is a JPEGEncoder.
+func EncodeFrame(w io.Writer, frame Frame, pixFmt PixFormat, enc FrameEncoder) error {
+	if _, ok := enc.(JPEGEncoder); ok && (pixFmt.PixelFormat == PixelFmtJPEG || pixFmt.PixelFormat == PixelFmtMJPEG) {
+		if _, err := w.Write(frame.Data); err != nil {
+			return fmt.Errorf("encode frame: %w", err)
+		}
+		return nil
+	}
+
+	img, err := decodeImage(frame.Data, pixFmt)
+	if err != nil {
+		return fmt.Errorf("encode frame: %w", err)
+	}
+	if err := enc.Encode(w, img); err != nil {
+		return fmt.Errorf("encode frame: %s: %w", enc.ContentType(), err)
+	}
+	return nil
+}
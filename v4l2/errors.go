@@ -2,6 +2,7 @@ package v4l2
 
 import (
 	"errors"
+	"fmt"
 	sys "syscall"
 )
 
@@ -13,11 +14,100 @@ var (
 	ErrorUnsupported        = errors.New("unsupported error")
 	ErrorUnsupportedFeature = errors.New("feature unsupported error")
 	ErrorInterrupted        = errors.New("interrupted")
+
+	// ErrUnsupported is returned when the driver does not implement the ioctl (ENOTTY), or
+	// does not know the queried item (i.e. EINVAL when querying a control, see ErrControlNotSupported)
+	ErrUnsupported = ErrorUnsupported
+
+	// ErrBusy is returned when the device (or its buffer queue) is in use (EBUSY), i.e.
+	// when changing the format while streaming or when opened by another process
+	ErrBusy = errors.New("device busy")
+
+	// ErrNoSignal is returned when no stable signal is received on the input (ENOLINK, ENOLCK),
+	// i.e. when querying the timings of a disconnected HDMI input
+	ErrNoSignal = errors.New("no signal")
+
+	// ErrTimeout is returned when the device does not respond in time (ETIMEDOUT), or when
+	// no frame is produced before a deadline
+	ErrTimeout = ErrorTimeout
+
+	// ErrDeviceDisconnected is returned when the device is gone (ENODEV), i.e. a USB camera
+	// was unplugged: the device must be closed and enumerated again. It matches ErrorSystem.
+	ErrDeviceDisconnected error = &kindError{msg: "device disconnected", kind: ErrorSystem}
+
+	// ErrDeviceGone is ErrDeviceDisconnected
+	ErrDeviceGone = ErrDeviceDisconnected
+
+	// ErrBufferCorrupted reports a buffer dequeued with BufFlagError: the stream goes on
+	// but the frame data may be corrupted
+	ErrBufferCorrupted = errors.New("buffer corrupted")
+
+	// ErrStreamingUnsupported is returned when the driver does not grant streaming buffers
+	// for the requested memory IO type (i.e. a read/write only device)
+	ErrStreamingUnsupported = errors.New("streaming IO unsupported")
+
+	// ErrFormatChanged is returned when the driver changes the format of the stream
+	// after it has been negotiated (i.e. the resolution of the source changed)
+	ErrFormatChanged = errors.New("format changed")
+
+	// ErrInsufficientBuffers is returned when the driver grants fewer streaming buffers
+	// than the required minimum
+	ErrInsufficientBuffers = errors.New("insufficient buffers")
+
+	// ErrFormatAlignment is returned when the format dimensions do not match the alignment
+	// required by the chroma subsampling of the pixel format (i.e. an odd YUYV width)
+	ErrFormatAlignment = errors.New("format alignment")
+
+	// ErrControlNotSupported is returned when the driver does not support the
+	// requested control id (EINVAL when querying or reading the control), it matches
+	// ErrUnsupported
+	ErrControlNotSupported error = &kindError{msg: "control not supported", kind: ErrUnsupported}
+
+	// ErrControlAutoEnabled is returned when setting a manual control that has no effect
+	// while its automatic counterpart is enabled (i.e. the white balance temperature while
+	// auto white balance is on)
+	ErrControlAutoEnabled = errors.New("automatic mode enabled")
 )
 
+// kindError is a sentinel error specializing another sentinel error, its kind
+type kindError struct {
+	msg  string
+	kind error
+}
+
+func (e *kindError) Error() string {
+	return e.msg
+}
+
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
+
+// errnoError is the error of a failed system call (i.e. ioctl): it matches both its errno and
+// the category of the errno (see parseErrorType), i.e. errors.Is(err, sys.EBUSY) and
+// errors.Is(err, ErrBusy).
+type errnoError struct {
+	errno sys.Errno
+	kind  error
+}
+
+func (e errnoError) Error() string {
+	return fmt.Sprintf("%s: %s", e.kind, e.errno)
+}
+
+func (e errnoError) Is(target error) bool {
+	return errors.Is(e.kind, target)
+}
+
+func (e errnoError) Unwrap() error {
+	return e.errno
+}
+
 func parseErrorType(errno sys.Errno) error {
 	switch errno {
-	case sys.EBADF, sys.ENOMEM, sys.ENODEV, sys.EIO, sys.ENXIO, sys.EFAULT: // structural, terminal
+	case sys.ENODEV:
+		return ErrDeviceDisconnected
+	case sys.EBADF, sys.ENOMEM, sys.EIO, sys.ENXIO, sys.EFAULT: // structural, terminal
 		return ErrorSystem
 	case sys.EINTR:
 		return ErrorInterrupted
@@ -25,13 +115,25 @@ func parseErrorType(errno sys.Errno) error {
 		return ErrorBadArgument
 	case sys.ENOTTY: // unsupported
 		return ErrorUnsupported
+	case sys.EBUSY:
+		return ErrBusy
+	case sys.ENOLINK, sys.ENOLCK:
+		return ErrNoSignal
+	case sys.ETIMEDOUT:
+		return ErrTimeout
 	default:
-		if errno.Timeout() {
-			return ErrorTimeout
-		}
 		if errno.Temporary() {
 			return ErrorTemporary
 		}
 		return errno
 	}
 }
+
+// errnoErr returns the error of errno, along with its category (see errnoError)
+func errnoErr(errno sys.Errno) error {
+	kind := parseErrorType(errno)
+	if kind == error(errno) {
+		return errno
+	}
+	return errnoError{errno: errno, kind: kind}
+}
@@ -0,0 +1,41 @@
+package v4l2
+
+import (
+	"errors"
+	"testing"
+
+	sys "syscall"
+)
+
+func TestIoctlErrors(t *testing.T) {
+	tests := []struct {
+		errno sys.Errno
+		kind  error
+	}{
+		{errno: sys.ENOTTY, kind: ErrUnsupported},
+		{errno: sys.EINVAL, kind: ErrorBadArgument},
+		{errno: sys.EBUSY, kind: ErrBusy},
+		{errno: sys.ENOLINK, kind: ErrNoSignal},
+		{errno: sys.ENOLCK, kind: ErrNoSignal},
+		{errno: sys.ETIMEDOUT, kind: ErrTimeout},
+		{errno: sys.EAGAIN, kind: ErrorTemporary},
+		{errno: sys.ENODEV, kind: ErrDeviceDisconnected},
+		{errno: sys.ENODEV, kind: ErrorSystem},
+	}
+	for _, test := range tests {
+		err := errnoErr(test.errno)
+		if !errors.Is(err, test.kind) {
+			t.Errorf("%v: expecting %v", err, test.kind)
+		}
+		if !errors.Is(err, test.errno) {
+			t.Errorf("%v: expecting errno %d", err, test.errno)
+		}
+	}
+
+	if !errors.Is(ErrControlNotSupported, ErrUnsupported) {
+		t.Error("expecting ErrControlNotSupported to match ErrUnsupported")
+	}
+	if errors.Is(ErrControlNotSupported, ErrorBadArgument) {
+		t.Error("expecting ErrControlNotSupported not to match ErrorBadArgument")
+	}
+}
@@ -0,0 +1,191 @@
+package v4l2
+
+/*
+#cgo linux CFLAGS: -I ${SRCDIR}/../include/
+#include <linux/videodev2.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"time"
+	"unsafe"
+)
+
+// EventType (V4L2_EVENT_*) identifies the events that can be subscribed to and dequeued.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-dqevent.html
+// See https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/videodev2.h#L2390
+type EventType = uint32
+
+const (
+	EventAll          EventType = C.V4L2_EVENT_ALL
+	EventVSync        EventType = C.V4L2_EVENT_VSYNC
+	EventEOS          EventType = C.V4L2_EVENT_EOS
+	EventCtrl         EventType = C.V4L2_EVENT_CTRL
+	EventFrameSync    EventType = C.V4L2_EVENT_FRAME_SYNC
+	EventSourceChange EventType = C.V4L2_EVENT_SOURCE_CHANGE
+	EventMotionDet    EventType = C.V4L2_EVENT_MOTION_DET
+)
+
+// EventSubscribeFlag (V4L2_EVENT_SUB_FL_*) adjusts an event subscription. With
+// EventSubscribeSendInitial, a control event reporting the current state is queued
+// immediately, with EventSubscribeAllowFeedback the changes made through the same file
+// descriptor are also reported.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-subscribe-event.html
+type EventSubscribeFlag = uint32
+
+const (
+	EventSubscribeSendInitial   EventSubscribeFlag = C.V4L2_EVENT_SUB_FL_SEND_INITIAL
+	EventSubscribeAllowFeedback EventSubscribeFlag = C.V4L2_EVENT_SUB_FL_ALLOW_FEEDBACK
+)
+
+// CtrlEventChange (V4L2_EVENT_CTRL_CH_*) reports what changed in a control event.
+type CtrlEventChange = uint32
+
+const (
+	CtrlEventChangeValue CtrlEventChange = C.V4L2_EVENT_CTRL_CH_VALUE
+	CtrlEventChangeFlags CtrlEventChange = C.V4L2_EVENT_CTRL_CH_FLAGS
+	CtrlEventChangeRange CtrlEventChange = C.V4L2_EVENT_CTRL_CH_RANGE
+)
+
+// SourceChange (V4L2_EVENT_SRC_CH_*) reports why a source change event fired.
+type SourceChange = uint32
+
+const (
+	// SourceChangeResolution reports that the resolution of the source (i.e. the timings
+	// of an HDMI input) changed: the stream must be stopped and the format negotiated again.
+	SourceChangeResolution SourceChange = C.V4L2_EVENT_SRC_CH_RESOLUTION
+)
+
+// SourceChangeEvent (v4l2_event_src_change) reports a change of the source (see EventSourceChange).
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-dqevent.html#c.V4L.v4l2_event_src_change
+type SourceChangeEvent struct {
+	// Input is the input (or pad) index of the source, the subscription id
+	Input     uint32
+	Changes   SourceChange
+	Sequence  uint32
+	Timestamp time.Time
+}
+
+// ControlEvent (v4l2_event_ctrl) reports the new state of a control (see EventCtrl).
+// Value64 holds the value of 64-bit controls (CtrlTypeInt64).
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-dqevent.html#c.V4L.v4l2_event_ctrl
+type ControlEvent struct {
+	ID       CtrlID
+	Changes  CtrlEventChange
+	Type     CtrlType
+	Value    CtrlValue
+	Value64  int64
+	Flags    CtrlFlag
+	Minimum  int32
+	Maximum  int32
+	Step     int32
+	Default  int32
+	Sequence uint32
+}
+
+// v4l2EventCtrl mirrors struct v4l2_event_ctrl (the value union is 64-bit aligned)
+type v4l2EventCtrl struct {
+	changes      uint32
+	ctrlType     uint32
+	value64      int64
+	flags        uint32
+	minimum      int32
+	maximum      int32
+	step         int32
+	defaultValue int32
+}
+
+// Event (v4l2_event) is an event dequeued from the device. Data holds the raw event
+// payload, which can be interpreted according to the Type (i.e. with ControlEvent).
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-dqevent.html
+type Event struct {
+	Type      EventType
+	ID        uint32
+	Sequence  uint32
+	Pending   uint32
+	Timestamp time.Time
+	Data      [64]byte
+}
+
+// ControlEvent returns the control event carried by an EventCtrl event.
+func (e Event) ControlEvent() (ControlEvent, bool) {
+	if e.Type != EventCtrl {
+		return ControlEvent{}, false
+	}
+	ctrl := *(*v4l2EventCtrl)(unsafe.Pointer(&e.Data[0]))
+	return ControlEvent{
+		ID:       e.ID,
+		Changes:  ctrl.changes,
+		Type:     CtrlType(ctrl.ctrlType),
+		Value:    CtrlValue(ctrl.value64),
+		Value64:  ctrl.value64,
+		Flags:    ctrl.flags,
+		Minimum:  ctrl.minimum,
+		Maximum:  ctrl.maximum,
+		Step:     ctrl.step,
+		Default:  ctrl.defaultValue,
+		Sequence: e.Sequence,
+	}, true
+}
+
+// SourceChangeEvent returns the source change event carried by an EventSourceChange event.
+func (e Event) SourceChangeEvent() (SourceChangeEvent, bool) {
+	if e.Type != EventSourceChange {
+		return SourceChangeEvent{}, false
+	}
+	return SourceChangeEvent{
+		Input:     e.ID,
+		Changes:   *(*uint32)(unsafe.Pointer(&e.Data[0])),
+		Sequence:  e.Sequence,
+		Timestamp: e.Timestamp,
+	}, true
+}
+
+// SubscribeEvent subscribes to the events of the specified type. For control events, id is
+// the control ID, for source change events it is the input (or pad) index.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-subscribe-event.html
+func SubscribeEvent(fd uintptr, eventType EventType, id uint32, flags EventSubscribeFlag) error {
+	var sub C.struct_v4l2_event_subscription
+	sub._type = C.uint(eventType)
+	sub.id = C.uint(id)
+	sub.flags = C.uint(flags)
+
+	if err := send(fd, C.VIDIOC_SUBSCRIBE_EVENT, uintptr(unsafe.Pointer(&sub))); err != nil {
+		return fmt.Errorf("subscribe event: type %d: id %d: %w", eventType, id, err)
+	}
+	return nil
+}
+
+// UnsubscribeEvent cancels a subscription made with SubscribeEvent. EventAll cancels all subscriptions.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-subscribe-event.html
+func UnsubscribeEvent(fd uintptr, eventType EventType, id uint32) error {
+	var sub C.struct_v4l2_event_subscription
+	sub._type = C.uint(eventType)
+	sub.id = C.uint(id)
+
+	if err := send(fd, C.VIDIOC_UNSUBSCRIBE_EVENT, uintptr(unsafe.Pointer(&sub))); err != nil {
+		return fmt.Errorf("unsubscribe event: type %d: id %d: %w", eventType, id, err)
+	}
+	return nil
+}
+
+// DequeueEvent dequeues a pending event. Pending events are signaled by POLLPRI on the
+// device file descriptor.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-dqevent.html
+func DequeueEvent(fd uintptr) (Event, error) {
+	var ev C.struct_v4l2_event
+	if err := send(fd, C.VIDIOC_DQEVENT, uintptr(unsafe.Pointer(&ev))); err != nil {
+		return Event{}, fmt.Errorf("dequeue event: %w", err)
+	}
+
+	event := Event{
+		Type:      EventType(ev._type),
+		ID:        uint32(ev.id),
+		Sequence:  uint32(ev.sequence),
+		Pending:   uint32(ev.pending),
+		Timestamp: time.Unix(int64(ev.timestamp.tv_sec), int64(ev.timestamp.tv_nsec)),
+	}
+	event.Data = *(*[64]byte)(unsafe.Pointer(&ev.u[0]))
+	return event, nil
+}
@@ -6,8 +6,10 @@ package v4l2
 */
 import "C"
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	sys "syscall"
 	"unsafe"
 )
 
@@ -15,13 +17,25 @@ import (
 // See https://linuxtv.org/downloads/v4l-dvb-apis-new/userspace-api/v4l/extended-controls.html
 // See https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/videodev2.h#L1745
 func GetExtControlValue(fd uintptr, ctrlID CtrlID) (CtrlValue, error) {
-	var v4l2Ctrl C.struct_v4l2_ext_control
-	v4l2Ctrl.id = C.uint(ctrlID)
-	v4l2Ctrl.size = 0
-	if err := send(fd, C.VIDIOC_G_EXT_CTRLS, uintptr(unsafe.Pointer(&v4l2Ctrl))); err != nil {
-		return 0, fmt.Errorf("get ext controls: %w", err)
+	ctrls := []ExtControl{{ID: ctrlID}}
+	if err := GetExtControls(fd, CtrlWhichCurVal, ctrls); err != nil {
+		if errors.Is(err, ErrorBadArgument) {
+			return 0, fmt.Errorf("get ext control value: id %d: %w", ctrlID, ErrControlNotSupported)
+		}
+		return 0, fmt.Errorf("get ext control value: id %d: %w", ctrlID, err)
+	}
+	return ctrls[0].Value, nil
+}
+
+// GetExtControlValue64 retrieves the current value of a 64-bit control (CtrlTypeInt64), such
+// as CtrlImgProcPixelRate, which can only be read with the extended control API.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-ext-ctrls.html
+func GetExtControlValue64(fd uintptr, id CtrlID) (int64, error) {
+	ctrls := []ExtControl{{ID: id}}
+	if err := GetExtControls(fd, CtrlWhichCurVal, ctrls); err != nil {
+		return 0, fmt.Errorf("get ext control value64: id %d: %w", id, err)
 	}
-	return *(*CtrlValue)(unsafe.Pointer(&v4l2Ctrl.anon0[0])), nil
+	return ctrls[0].Value64, nil
 }
 
 // SetExtControlValue saves the value for an extended control with the specified id.
@@ -30,17 +44,13 @@ func GetExtControlValue(fd uintptr, ctrlID CtrlID) (CtrlValue, error) {
 func SetExtControlValue(fd uintptr, id CtrlID, val CtrlValue) error {
 	ctrlInfo, err := QueryExtControlInfo(fd, id)
 	if err != nil {
-		return fmt.Errorf("set ext control value: id %s: %w", id, err)
+		return fmt.Errorf("set ext control value: id %d: %w", id, err)
 	}
 	if val < ctrlInfo.Minimum || val > ctrlInfo.Maximum {
-		return fmt.Errorf("set ext control value: out-of-range failure: val %d: expected ctrl.Min %d, ctrl.Max %d", val, ctrlInfo.Minimum, ctrlInfo.Maximum)
+		return fmt.Errorf("set ext control value: out-of-range failure: val %d: expected ctrl.Min %d, ctrl.Max %d: %w", val, ctrlInfo.Minimum, ctrlInfo.Maximum, ErrorBadArgument)
 	}
 
-	var v4l2Ctrl C.struct_v4l2_ext_control
-	v4l2Ctrl.id = C.uint(id)
-	*(*C.int)(unsafe.Pointer(&v4l2Ctrl.anon0[0])) = *(*C.int)(unsafe.Pointer(&val))
-
-	if err := send(fd, C.VIDIOC_S_CTRL, uintptr(unsafe.Pointer(&v4l2Ctrl))); err != nil {
+	if err := SetExtControls(fd, CtrlWhichCurVal, []ExtControl{{ID: id, Value: val}}); err != nil {
 		return fmt.Errorf("set ext control value: id %d: %w", id, err)
 	}
 
@@ -52,27 +62,187 @@ func SetExtControlValue(fd uintptr, id CtrlID, val CtrlValue) error {
 // https://linuxtv.org/downloads/v4l-dvb-apis-new/userspace-api/v4l/extended-controls.html
 // See https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/videodev2.h#L1774
 func SetExtControlValues(fd uintptr, whichCtrl CtrlClass, ctrls []Control) error {
-	numCtrl := len(ctrls)
+	extCtrls := make([]ExtControl, len(ctrls))
+	for i, ctrl := range ctrls {
+		extCtrls[i] = ExtControl{ID: ctrl.ID, Value: ctrl.Value}
+	}
+	return SetExtControls(fd, whichCtrl, extCtrls)
+}
+
+// CtrlWhich selects the control values accessed with the extended control API: the current
+// values, or the default values (get only). A control class (see CtrlClass) can also be used
+// to restrict access to the controls of that class.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-ext-ctrls.html
+type CtrlWhich = uint32
+
+const (
+	CtrlWhichCurVal CtrlWhich = C.V4L2_CTRL_WHICH_CUR_VAL
+	CtrlWhichDefVal CtrlWhich = C.V4L2_CTRL_WHICH_DEF_VAL
+)
+
+// ExtControl (v4l2_ext_control) holds the ID and the value of a control accessed as part of
+// a batch with the extended control API (see GetExtControls and SetExtControls).
+//
+// The value is held according to the control type: Value for 32-bit controls (integer,
+// boolean, menu), Value64 for 64-bit controls (CtrlTypeInt64), and Payload for string and
+// compound controls. When Value64 is zero, Value is used for 64-bit controls as well.
+// GetExtControls sets both Value and Value64, sign-extending 32-bit values into Value64.
+// For string controls, NewExtControlString creates a payload that respects the control
+// size constraints, and String returns the value of the payload.
+// See https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/videodev2.h#L1745
+type ExtControl struct {
+	ID      CtrlID
+	Value   CtrlValue
+	Value64 int64
+	Payload []byte
+}
 
-	var v4l2CtrlArray []C.struct_v4l2_ext_control
+// NewExtControlString creates an ExtControl that sets the string control described by info
+// (see QueryExtControlInfo) to val. The string length must be within the control minimum and
+// maximum, and a multiple of the control step above the minimum. The payload is sized for the
+// longest string, plus the terminating NUL, so the control can be read back in place.
+func NewExtControlString(info ControlInfo, val string) (ExtControl, error) {
+	if info.Type != CtrlTypeString {
+		return ExtControl{}, fmt.Errorf("ext control string: id %d: control is not a string type", info.ID)
+	}
+	length := int32(len(val))
+	if length < info.Minimum || length > info.Maximum {
+		return ExtControl{}, fmt.Errorf("ext control string: id %d: length %d out of range [%d, %d]", info.ID, length, info.Minimum, info.Maximum)
+	}
+	if info.Step > 1 && (length-info.Minimum)%info.Step != 0 {
+		return ExtControl{}, fmt.Errorf("ext control string: id %d: length %d is not a multiple of step %d", info.ID, length, info.Step)
+	}
+	payload := make([]byte, info.Maximum+1)
+	copy(payload, val)
+	return ExtControl{ID: info.ID, Payload: payload}, nil
+}
 
-	for _, ctrl := range ctrls {
-		var v4l2Ctrl C.struct_v4l2_ext_control
-		v4l2Ctrl.id = C.uint(ctrl.ID)
-		*(*C.int)(unsafe.Pointer(&v4l2Ctrl.anon0[0])) = *(*C.int)(unsafe.Pointer(&ctrl.Value))
+// String returns the value of a string control, the payload up to its terminating NUL.
+func (c ExtControl) String() string {
+	if i := bytes.IndexByte(c.Payload, 0); i >= 0 {
+		return string(c.Payload[:i])
+	}
+	return string(c.Payload)
+}
 
-		v4l2CtrlArray = append(v4l2CtrlArray, v4l2Ctrl)
+// ExtControlError reports the control of a batch that caused GetExtControls or SetExtControls
+// to fail, from the error_idx field. When the driver cannot attribute the failure to one
+// control (i.e. it failed while validating the batch, before applying any value), Index is
+// the number of controls and ID is zero.
+type ExtControlError struct {
+	Index uint32
+	ID    CtrlID
+	Err   error
+}
+
+func (e *ExtControlError) Error() string {
+	if e.ID == 0 {
+		return e.Err.Error()
 	}
+	return fmt.Sprintf("control %d (id %d): %s", e.Index, e.ID, e.Err)
+}
 
-	var v4l2Ctrls C.struct_v4l2_ext_controls
-	*(*uint32)(unsafe.Pointer(&v4l2Ctrls.anon0[0])) = whichCtrl
-	v4l2Ctrls.count = C.uint(numCtrl)
-	v4l2Ctrls.controls = (*C.struct_v4l2_ext_control)(unsafe.Pointer(&v4l2CtrlArray))
+func (e *ExtControlError) Unwrap() error {
+	return e.Err
+}
+
+// GetExtControls retrieves the values of the controls with a single VIDIOC_G_EXT_CTRLS ioctl,
+// storing them in ctrls. The which argument selects the current (CtrlWhichCurVal) or the
+// default (CtrlWhichDefVal) values. The payload of string and compound controls is read into
+// Payload: when it is too small (or nil), it is reallocated with the size reported by the
+// driver. On failure, the error is an *ExtControlError.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-ext-ctrls.html
+func GetExtControls(fd uintptr, which CtrlWhich, ctrls []ExtControl) error {
+	if len(ctrls) == 0 {
+		return nil
+	}
+	v4l2CtrlArray := makeExtControlArray(ctrls, false)
+	err := sendExtControls(fd, C.VIDIOC_G_EXT_CTRLS, which, ctrls, v4l2CtrlArray)
+	if errors.Is(err, sys.ENOSPC) {
+		// the driver reported the payload sizes it requires, retry once with them
+		for i := range ctrls {
+			if size := int(v4l2CtrlArray[i].size); size > len(ctrls[i].Payload) {
+				ctrls[i].Payload = make([]byte, size)
+			}
+		}
+		v4l2CtrlArray = makeExtControlArray(ctrls, false)
+		err = sendExtControls(fd, C.VIDIOC_G_EXT_CTRLS, which, ctrls, v4l2CtrlArray)
+	}
+	if err != nil {
+		return fmt.Errorf("get ext controls: %w", err)
+	}
+	for i := range ctrls {
+		if v4l2CtrlArray[i].size > 0 {
+			ctrls[i].Payload = ctrls[i].Payload[:v4l2CtrlArray[i].size]
+			continue
+		}
+		value := *(*int64)(unsafe.Pointer(&v4l2CtrlArray[i].anon0[0]))
+		if !isExtControlInt64(fd, ctrls[i].ID) {
+			// 32-bit controls only set the lower half of the union
+			value = int64(int32(value))
+		}
+		ctrls[i].Value64 = value
+		ctrls[i].Value = CtrlValue(value)
+	}
+	return nil
+}
+
+// isExtControlInt64 returns true if the control is a 64-bit control (CtrlTypeInt64)
+func isExtControlInt64(fd uintptr, id CtrlID) bool {
+	info, err := QueryExtControlInfo(fd, id)
+	return err == nil && info.Type == CtrlTypeInt64
+}
 
-	if err := send(fd, C.VIDIOC_S_EXT_CTRLS, uintptr(unsafe.Pointer(&v4l2Ctrls))); err != nil {
+// SetExtControls applies the values of the controls atomically, with a single VIDIOC_S_EXT_CTRLS
+// ioctl: either all controls are set, or none is. Default values cannot be set (which is usually
+// CtrlWhichCurVal). On failure, the error is an *ExtControlError.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-ext-ctrls.html
+func SetExtControls(fd uintptr, which CtrlWhich, ctrls []ExtControl) error {
+	if len(ctrls) == 0 {
+		return nil
+	}
+	if err := sendExtControls(fd, C.VIDIOC_S_EXT_CTRLS, which, ctrls, makeExtControlArray(ctrls, true)); err != nil {
 		return fmt.Errorf("set ext controls: %w", err)
 	}
+	return nil
+}
+
+// makeExtControlArray prepares the v4l2_ext_control array of the batch. Controls with a
+// payload point to it, the others hold their value in the union when withValues is set.
+// The 64-bit union is written as a whole: on little-endian hosts, its lower half is the
+// 32-bit value read by the driver for 32-bit controls.
+func makeExtControlArray(ctrls []ExtControl, withValues bool) []C.struct_v4l2_ext_control {
+	v4l2CtrlArray := make([]C.struct_v4l2_ext_control, len(ctrls))
+	for i, ctrl := range ctrls {
+		v4l2CtrlArray[i].id = C.uint(ctrl.ID)
+		union := unsafe.Pointer(&v4l2CtrlArray[i].anon0[0])
+		switch {
+		case len(ctrl.Payload) > 0:
+			v4l2CtrlArray[i].size = C.uint(len(ctrl.Payload))
+			*(*unsafe.Pointer)(union) = unsafe.Pointer(&ctrl.Payload[0])
+		case withValues && ctrl.Value64 != 0:
+			*(*int64)(union) = ctrl.Value64
+		case withValues:
+			*(*int64)(union) = int64(ctrl.Value)
+		}
+	}
+	return v4l2CtrlArray
+}
 
+// sendExtControls issues the ext controls request and maps a failure to an *ExtControlError
+func sendExtControls(fd uintptr, req uintptr, which CtrlWhich, ctrls []ExtControl, v4l2CtrlArray []C.struct_v4l2_ext_control) error {
+	var v4l2Ctrls C.struct_v4l2_ext_controls
+	*(*uint32)(unsafe.Pointer(&v4l2Ctrls.anon0[0])) = which
+	v4l2Ctrls.count = C.uint(len(v4l2CtrlArray))
+	v4l2Ctrls.controls = &v4l2CtrlArray[0]
+
+	if err := send(fd, req, uintptr(unsafe.Pointer(&v4l2Ctrls))); err != nil {
+		ctrlErr := &ExtControlError{Index: uint32(v4l2Ctrls.error_idx), Err: err}
+		if int(ctrlErr.Index) < len(ctrls) {
+			ctrlErr.ID = ctrls[ctrlErr.Index].ID
+		}
+		return ctrlErr
+	}
 	return nil
 }
 
@@ -87,7 +257,7 @@ func GetExtControl(fd uintptr, id CtrlID) (Control, error) {
 	// retrieve control value
 	ctrlValue, err := GetExtControlValue(fd, uint32(id))
 	if err != nil {
-		return Control{}, fmt.Errorf("get control: %w", id, err)
+		return Control{}, fmt.Errorf("get control: id %d: %w", id, err)
 	}
 
 	control.Value = ctrlValue
@@ -102,6 +272,9 @@ func QueryExtControlInfo(fd uintptr, id CtrlID) (Control, error) {
 	qryCtrl.id = C.uint(id)
 
 	if err := send(fd, C.VIDIOC_QUERY_EXT_CTRL, uintptr(unsafe.Pointer(&qryCtrl))); err != nil {
+		if errors.Is(err, ErrorBadArgument) {
+			return Control{}, fmt.Errorf("query ext control info: VIDIOC_QUERY_EXT_CTRL: id %d: %w", id, ErrControlNotSupported)
+		}
 		return Control{}, fmt.Errorf("query ext control info: VIDIOC_QUERY_EXT_CTRL: id %d: %w", id, err)
 	}
 	control := makeExtControl(qryCtrl)
@@ -117,7 +290,7 @@ func QueryAllExtControls(fd uintptr) (result []Control, err error) {
 	for {
 		control, err := QueryExtControlInfo(fd, cid)
 		if err != nil {
-			if errors.Is(err, ErrorBadArgument) {
+			if errors.Is(err, ErrControlNotSupported) {
 				break
 			}
 			return result, fmt.Errorf("query all ext controls: %w", err)
@@ -131,6 +304,10 @@ func QueryAllExtControls(fd uintptr) (result []Control, err error) {
 }
 
 func makeExtControl(qryCtrl C.struct_v4l2_query_ext_ctrl) Control {
+	var dims []uint32
+	for i := 0; i < int(qryCtrl.nr_of_dims) && i < len(qryCtrl.dims); i++ {
+		dims = append(dims, uint32(qryCtrl.dims[i]))
+	}
 	return Control{
 		Type:    CtrlType(qryCtrl._type),
 		ID:      uint32(qryCtrl.id),
@@ -140,5 +317,7 @@ func makeExtControl(qryCtrl C.struct_v4l2_query_ext_ctrl) Control {
 		Step:    int32(qryCtrl.step),
 		Default: int32(qryCtrl.default_value),
 		flags:   uint32(qryCtrl.flags),
+		elems:   uint32(qryCtrl.elems),
+		dims:    dims,
 	}
 }
@@ -5,6 +5,7 @@ import "C"
 
 import (
 	"fmt"
+	"strings"
 	"unsafe"
 )
 
@@ -16,6 +17,7 @@ type FourCCType = uint32
 // https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/videodev2.h#L518
 var (
 	PixelFmtRGB24 FourCCType = C.V4L2_PIX_FMT_RGB24
+	PixelFmtBGR24 FourCCType = C.V4L2_PIX_FMT_BGR24
 	PixelFmtGrey  FourCCType = C.V4L2_PIX_FMT_GREY
 	PixelFmtYUYV  FourCCType = C.V4L2_PIX_FMT_YUYV
 	PixelFmtYYUV  FourCCType = C.V4L2_PIX_FMT_YYUV
@@ -27,18 +29,170 @@ var (
 	PixelFmtMPEG  FourCCType = C.V4L2_PIX_FMT_MPEG
 	PixelFmtH264  FourCCType = C.V4L2_PIX_FMT_H264
 	PixelFmtMPEG4 FourCCType = C.V4L2_PIX_FMT_MPEG4
+
+	PixelFmtNV12   FourCCType = C.V4L2_PIX_FMT_NV12
+	PixelFmtNV21   FourCCType = C.V4L2_PIX_FMT_NV21
+	PixelFmtYUV420 FourCCType = C.V4L2_PIX_FMT_YUV420
+	PixelFmtYVU420 FourCCType = C.V4L2_PIX_FMT_YVU420
 )
 
 // PixelFormats provides a map of FourCCType encoding description
 var PixelFormats = map[FourCCType]string{
 	PixelFmtRGB24: "24-bit RGB 8-8-8",
+	PixelFmtBGR24: "24-bit BGR 8-8-8",
 	PixelFmtGrey:  "8-bit Greyscale",
 	PixelFmtYUYV:  "YUYV 4:2:2",
+	PixelFmtYYUV:  "YYUV 4:2:2",
+	PixelFmtYVYU:  "YVYU 4:2:2",
+	PixelFmtUYVY:  "UYVY 4:2:2",
+	PixelFmtVYUY:  "VYUY 4:2:2",
 	PixelFmtMJPEG: "Motion-JPEG",
 	PixelFmtJPEG:  "JFIF JPEG",
 	PixelFmtMPEG:  "MPEG-1/2/4",
 	PixelFmtH264:  "H.264",
 	PixelFmtMPEG4: "MPEG-4 Part 2 ES",
+
+	PixelFmtNV12:   "Y/CbCr 4:2:0",
+	PixelFmtNV21:   "Y/CrCb 4:2:0",
+	PixelFmtYUV420: "Planar YUV 4:2:0",
+	PixelFmtYVU420: "Planar YVU 4:2:0",
+}
+
+// PixelFormatInfo describes a known pixel format
+type PixelFormatInfo struct {
+	// FourCC is the pixel format code
+	FourCC FourCCType
+	// Name is the four character code of the format (see FourCCString), i.e. "YUYV"
+	Name string
+	// Description is a human readable description of the format, as in PixelFormats
+	Description string
+	// Compressed is true for compressed formats (i.e. Motion-JPEG, H.264) and false for raw
+	// formats (i.e. YUYV, RGB24), which can be converted to images directly (see DecodeFrame)
+	Compressed bool
+}
+
+// knownPixelFormats lists the predefined pixel formats in presentation order, raw formats first
+var knownPixelFormats = []FourCCType{
+	PixelFmtYUYV, PixelFmtYYUV, PixelFmtYVYU, PixelFmtUYVY, PixelFmtVYUY,
+	PixelFmtNV12, PixelFmtNV21, PixelFmtYUV420, PixelFmtYVU420,
+	PixelFmtRGB24, PixelFmtBGR24, PixelFmtGrey,
+	PixelFmtMJPEG, PixelFmtJPEG, PixelFmtMPEG, PixelFmtH264, PixelFmtMPEG4,
+}
+
+// KnownPixelFormats returns the pixel formats predefined in this package, with their names
+// and descriptions, raw formats first. Intersect it with the formats of a device (see
+// GetAllFormatDescriptions) to present them with friendly labels.
+func KnownPixelFormats() []PixelFormatInfo {
+	result := make([]PixelFormatInfo, len(knownPixelFormats))
+	for i, fourcc := range knownPixelFormats {
+		result[i] = PixelFormatInfo{
+			FourCC:      fourcc,
+			Name:        FourCCString(fourcc),
+			Description: PixelFormats[fourcc],
+			Compressed:  IsPixFormatCompressed(fourcc),
+		}
+	}
+	return result
+}
+
+// IsPixFormatCompressed returns true if the pixel format is one of the predefined compressed
+// formats (JPEG, Motion-JPEG, MPEG, H.264 and MPEG-4). Devices also report it for the formats
+// they enumerate, see FmtDescFlagCompressed.
+func IsPixFormatCompressed(pixFmt FourCCType) bool {
+	switch pixFmt {
+	case PixelFmtMJPEG, PixelFmtJPEG, PixelFmtMPEG, PixelFmtH264, PixelFmtMPEG4:
+		return true
+	default:
+		return false
+	}
+}
+
+// ffmpegPixFmts maps uncompressed pixel formats to ffmpeg pixel format names (-pix_fmt)
+var ffmpegPixFmts = map[FourCCType]string{
+	PixelFmtRGB24:  "rgb24",
+	PixelFmtBGR24:  "bgr24",
+	PixelFmtGrey:   "gray",
+	PixelFmtYUYV:   "yuyv422",
+	PixelFmtYVYU:   "yvyu422",
+	PixelFmtUYVY:   "uyvy422",
+	PixelFmtNV12:   "nv12",
+	PixelFmtNV21:   "nv21",
+	PixelFmtYUV420: "yuv420p",
+}
+
+// gstreamerFormats maps uncompressed pixel formats to GStreamer raw video format names
+var gstreamerFormats = map[FourCCType]string{
+	PixelFmtRGB24:  "RGB",
+	PixelFmtBGR24:  "BGR",
+	PixelFmtGrey:   "GRAY8",
+	PixelFmtYUYV:   "YUY2",
+	PixelFmtYVYU:   "YVYU",
+	PixelFmtUYVY:   "UYVY",
+	PixelFmtVYUY:   "VYUY",
+	PixelFmtNV12:   "NV12",
+	PixelFmtNV21:   "NV21",
+	PixelFmtYUV420: "I420",
+	PixelFmtYVU420: "YV12",
+}
+
+// fourCCFlagBE marks the big-endian variant of a pixel format (see v4l2_fourcc_be)
+const fourCCFlagBE FourCCType = 1 << 31
+
+// FourCCFromString packs a four character code (i.e. "YUYV") into its FourCCType value, the
+// first character in the least significant byte as with the kernel v4l2_fourcc macro. Codes
+// shorter than four characters are padded with spaces (i.e. "Y10" is "Y10 "), and a "-BE"
+// suffix selects the big-endian variant, as printed by FourCCString. Any printable ASCII
+// code is accepted, not only the predefined pixel formats.
+func FourCCFromString(s string) (FourCCType, error) {
+	code, flags := s, FourCCType(0)
+	if len(code) > 4 && code[len(code)-3:] == "-BE" {
+		code, flags = code[:len(code)-3], fourCCFlagBE
+	}
+	if len(code) == 0 || len(code) > 4 {
+		return 0, fmt.Errorf("fourcc %q: %w: want 1 to 4 characters", s, ErrorBadArgument)
+	}
+	var fourcc FourCCType
+	for i := 0; i < 4; i++ {
+		c := byte(' ')
+		if i < len(code) {
+			c = code[i]
+		}
+		if c < ' ' || c > '~' {
+			return 0, fmt.Errorf("fourcc %q: %w: non printable character", s, ErrorBadArgument)
+		}
+		fourcc |= FourCCType(c) << (8 * i)
+	}
+	return fourcc | flags, nil
+}
+
+// FourCCString returns the four character code of fourcc (i.e. "YUYV" for PixelFmtYUYV),
+// with trailing spaces removed and a "-BE" suffix for big-endian variants. It is the
+// reverse of FourCCFromString.
+func FourCCString(fourcc FourCCType) string {
+	code := []byte{byte(fourcc), byte(fourcc >> 8), byte(fourcc >> 16), byte(fourcc >> 24 &^ 0x80)}
+	s := strings.TrimRight(string(code), " ")
+	if fourcc&fourCCFlagBE != 0 {
+		s += "-BE"
+	}
+	return s
+}
+
+// FFmpegPixFmt returns the ffmpeg pixel format name (i.e. "yuyv422" for PixelFmtYUYV) to use
+// with -pix_fmt for raw frames of the specified pixel format. It returns false for compressed
+// formats and formats ffmpeg does not support.
+// See https://ffmpeg.org/ffmpeg-all.html#video4linux2_002c-v4l2
+func FFmpegPixFmt(fourcc FourCCType) (string, bool) {
+	name, ok := ffmpegPixFmts[fourcc]
+	return name, ok
+}
+
+// GStreamerFormat returns the GStreamer raw video format name (i.e. "YUY2" for PixelFmtYUYV)
+// to use in video/x-raw caps for frames of the specified pixel format. It returns false for
+// compressed formats and formats GStreamer does not support.
+// See https://gstreamer.freedesktop.org/documentation/additional/design/mediatype-video-raw.html
+func GStreamerFormat(fourcc FourCCType) (string, bool) {
+	name, ok := gstreamerFormats[fourcc]
+	return name, ok
 }
 
 // IsPixYUVEncoded returns true if the pixel format is a chrome+luminance YUV format
@@ -56,6 +210,47 @@ func IsPixYUVEncoded(pixFmt FourCCType) bool {
 	}
 }
 
+// GetPixFormatAlignment returns the width and height multiples required by the chroma
+// subsampling of the pixel format: 4:2:2 formats (i.e. YUYV) share chroma samples between
+// horizontal pixel pairs and need an even width, while 4:2:0 formats (i.e. NV12) also share
+// them between line pairs and need an even height. Other formats return 1, 1.
+func GetPixFormatAlignment(pixFmt FourCCType) (width, height uint32) {
+	switch pixFmt {
+	case PixelFmtYUYV, PixelFmtYVYU, PixelFmtUYVY, PixelFmtVYUY:
+		return 2, 1
+	case PixelFmtNV12, PixelFmtNV21, PixelFmtYUV420, PixelFmtYVU420:
+		return 2, 2
+	default:
+		return 1, 1
+	}
+}
+
+// ValidatePixFormatAlignment returns an error wrapping ErrFormatAlignment if the format
+// dimensions are not multiples of the alignment required by its chroma subsampling.
+func ValidatePixFormatAlignment(pixFmt PixFormat) error {
+	alignW, alignH := GetPixFormatAlignment(pixFmt.PixelFormat)
+	if pixFmt.Width%alignW != 0 {
+		return fmt.Errorf("%w: %s: width %d must be a multiple of %d", ErrFormatAlignment, PixelFormats[pixFmt.PixelFormat], pixFmt.Width, alignW)
+	}
+	if pixFmt.Height%alignH != 0 {
+		return fmt.Errorf("%w: %s: height %d must be a multiple of %d", ErrFormatAlignment, PixelFormats[pixFmt.PixelFormat], pixFmt.Height, alignH)
+	}
+	return nil
+}
+
+// AlignPixFormat rounds the format dimensions down to the alignment required by its
+// chroma subsampling (see GetPixFormatAlignment).
+func AlignPixFormat(pixFmt PixFormat) PixFormat {
+	alignW, alignH := GetPixFormatAlignment(pixFmt.PixelFormat)
+	if pixFmt.Width >= alignW {
+		pixFmt.Width -= pixFmt.Width % alignW
+	}
+	if pixFmt.Height >= alignH {
+		pixFmt.Height -= pixFmt.Height % alignH
+	}
+	return pixFmt
+}
+
 // ColorspaceType
 // See https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/videodev2.h#L195
 type ColorspaceType = uint32
@@ -212,6 +407,60 @@ func ColorspaceToXferFunc(cs ColorspaceType) XferFunctionType {
 	}
 }
 
+// PixFmtFlag (V4L2_PIX_FMT_FLAG_*) pixel format flags (see PixFormat.Flags)
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/pixfmt-reserved.html#format-flags
+type PixFmtFlag = uint32
+
+const (
+	PixFmtFlagPremulAlpha PixFmtFlag = C.V4L2_PIX_FMT_FLAG_PREMUL_ALPHA
+	// PixFmtFlagSetCSC requests the colorspace, YCbCr encoding, quantization and transfer
+	// function of the format for a capture device (the driver clears it if unsupported)
+	PixFmtFlagSetCSC PixFmtFlag = C.V4L2_PIX_FMT_FLAG_SET_CSC
+)
+
+// HasColorimetry returns true if any of the colorimetry fields of the format (colorspace,
+// YCbCr encoding, quantization, transfer function) is not the default value.
+func (p PixFormat) HasColorimetry() bool {
+	return p.Colorspace != ColorspaceDefault || p.YcbcrEnc != YCbCrEncodingDefault ||
+		p.Quantization != QuantizationDefault || p.XferFunc != XferFuncDefault
+}
+
+// ResolveColorimetry returns the format with its default colorimetry fields replaced with the
+// concrete values they stand for, as drivers often report defaults. A default colorspace is
+// resolved from the pixel format and size (sRGB for RGB and greyscale formats, JPEG for JPEG
+// formats, Rec. 709 for HD and SMPTE 170M for SD YUV formats), then the YCbCr encoding and
+// transfer function from the colorspace. RGB formats are full range, other formats are
+// resolved from the colorspace.
+func (p PixFormat) ResolveColorimetry() PixFormat {
+	isRGB := p.PixelFormat == PixelFmtRGB24 || p.PixelFormat == PixelFmtBGR24 || p.PixelFormat == PixelFmtGrey
+	if p.Colorspace == ColorspaceDefault {
+		switch {
+		case isRGB:
+			p.Colorspace = ColorspaceSRGB
+		case p.PixelFormat == PixelFmtJPEG || p.PixelFormat == PixelFmtMJPEG:
+			p.Colorspace = ColorspaceJPEG
+		case p.Height >= 720:
+			p.Colorspace = ColorspaceREC709
+		default:
+			p.Colorspace = ColorspaceSMPTE170M
+		}
+	}
+	if p.YcbcrEnc == YCbCrEncodingDefault {
+		p.YcbcrEnc = ColorspaceToYCbCrEnc(p.Colorspace)
+	}
+	if p.XferFunc == XferFuncDefault {
+		p.XferFunc = ColorspaceToXferFunc(p.Colorspace)
+	}
+	if p.Quantization == QuantizationDefault {
+		if isRGB {
+			p.Quantization = QuantizationFullRange
+		} else {
+			p.Quantization = ColorspaceToQuantization(p.Colorspace)
+		}
+	}
+	return p
+}
+
 // FieldType (v4l2_field)
 // https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/field-order.html?highlight=v4l2_field#c.v4l2_field
 // https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/videodev2.h#L88
@@ -282,14 +531,24 @@ func (f PixFormat) String() string {
 // See https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/videodev2.h#L2331
 // and https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-fmt.html#ioctl-vidioc-g-fmt-vidioc-s-fmt-vidioc-try-fmt
 func GetPixFormat(fd uintptr) (PixFormat, error) {
+	return GetPixFormatForBufType(fd, BufTypeVideoCapture)
+}
+
+// GetPixFormatForBufType retrieves the pixel format of the buffer type, i.e. BufTypeVideoOutput
+// for the frames written to an output device.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-fmt.html
+func GetPixFormatForBufType(fd uintptr, bufType BufType) (PixFormat, error) {
 	var v4l2Format C.struct_v4l2_format
-	v4l2Format._type = C.uint(BufTypeVideoCapture)
+	v4l2Format._type = C.uint(bufType)
 
 	if err := send(fd, C.VIDIOC_G_FMT, uintptr(unsafe.Pointer(&v4l2Format))); err != nil {
 		return PixFormat{}, fmt.Errorf("pix format failed: %w", err)
 	}
 
-	v4l2PixFmt := *(*C.struct_v4l2_pix_format)(unsafe.Pointer(&v4l2Format.fmt[0]))
+	return makePixFormat(*(*C.struct_v4l2_pix_format)(unsafe.Pointer(&v4l2Format.fmt[0]))), nil
+}
+
+func makePixFormat(v4l2PixFmt C.struct_v4l2_pix_format) PixFormat {
 	return PixFormat{
 		Width:        uint32(v4l2PixFmt.width),
 		Height:       uint32(v4l2PixFmt.height),
@@ -304,14 +563,35 @@ func GetPixFormat(fd uintptr) (PixFormat, error) {
 		HSVEnc:       *(*uint32)(unsafe.Pointer(uintptr(unsafe.Pointer(&v4l2PixFmt.anon0[0])) + unsafe.Sizeof(C.uint(0)))),
 		Quantization: uint32(v4l2PixFmt.quantization),
 		XferFunc:     uint32(v4l2PixFmt.xfer_func),
-	}, nil
+	}
+}
+
+// TryPixFormat returns the pixel format the driver would adjust the specified format to,
+// with the driver-filled BytesPerLine and SizeImage, without changing the device format.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-fmt.html#ioctl-vidioc-g-fmt-vidioc-s-fmt-vidioc-try-fmt
+func TryPixFormat(fd uintptr, pixFmt PixFormat) (PixFormat, error) {
+	var v4l2Format C.struct_v4l2_format
+	v4l2Format._type = C.uint(BufTypeVideoCapture)
+	*(*C.struct_v4l2_pix_format)(unsafe.Pointer(&v4l2Format.fmt[0])) = *(*C.struct_v4l2_pix_format)(unsafe.Pointer(&pixFmt))
+
+	if err := send(fd, C.VIDIOC_TRY_FMT, uintptr(unsafe.Pointer(&v4l2Format))); err != nil {
+		return PixFormat{}, fmt.Errorf("try pix format: %w", err)
+	}
+	return makePixFormat(*(*C.struct_v4l2_pix_format)(unsafe.Pointer(&v4l2Format.fmt[0]))), nil
 }
 
 // SetPixFormat sets the pixel format information for the specified driver
 // See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-fmt.html#ioctl-vidioc-g-fmt-vidioc-s-fmt-vidioc-try-fmt
 func SetPixFormat(fd uintptr, pixFmt PixFormat) error {
+	return SetPixFormatForBufType(fd, BufTypeVideoCapture, pixFmt)
+}
+
+// SetPixFormatForBufType sets the pixel format of the buffer type, i.e. BufTypeVideoOutput
+// for the frames written to an output device.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-fmt.html
+func SetPixFormatForBufType(fd uintptr, bufType BufType, pixFmt PixFormat) error {
 	var v4l2Format C.struct_v4l2_format
-	v4l2Format._type = C.uint(BufTypeVideoCapture)
+	v4l2Format._type = C.uint(bufType)
 	*(*C.struct_v4l2_pix_format)(unsafe.Pointer(&v4l2Format.fmt[0])) = *(*C.struct_v4l2_pix_format)(unsafe.Pointer(&pixFmt))
 
 	if err := send(fd, C.VIDIOC_S_FMT, uintptr(unsafe.Pointer(&v4l2Format))); err != nil {
@@ -129,3 +129,27 @@ func GetFormatDescriptionByEncoding(fd uintptr, enc FourCCType) (FormatDescripti
 
 	return FormatDescription{}, fmt.Errorf("format desc: driver does not support encoding %d", enc)
 }
+
+// GetFormatDescriptionsByMbusCode returns the pixel formats the video node can produce from
+// the specified media bus code. This is supported by media-controller centric drivers
+// (those reporting V4L2_CAP_IO_MC), other drivers ignore the code and return all formats.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-enum-fmt.html
+func GetFormatDescriptionsByMbusCode(fd uintptr, code uint32) (result []FormatDescription, err error) {
+	index := uint32(0)
+	for {
+		var fmtDesc C.struct_v4l2_fmtdesc
+		fmtDesc.index = C.uint(index)
+		fmtDesc._type = C.uint(BufTypeVideoCapture)
+		fmtDesc.mbus_code = C.uint(code)
+
+		if err = send(fd, C.VIDIOC_ENUM_FMT, uintptr(unsafe.Pointer(&fmtDesc))); err != nil {
+			if errors.Is(err, ErrorBadArgument) && len(result) > 0 {
+				break
+			}
+			return result, fmt.Errorf("format desc: mbus code 0x%04x: %w", code, err)
+		}
+		result = append(result, makeFormatDescription(fmtDesc))
+		index++
+	}
+	return result, nil
+}
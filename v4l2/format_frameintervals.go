@@ -6,7 +6,9 @@ package v4l2
 */
 import "C"
 import (
+	"errors"
 	"fmt"
+	"math"
 	"unsafe"
 )
 
@@ -41,6 +43,65 @@ type FrameInterval struct {
 	Step Fract
 }
 
+// fpsTolerance is the relative tolerance used when matching a frame rate with frame intervals,
+// to absorb the rounding of rates such as 29.97 fps (1001/30000)
+const fpsTolerance = 1e-3
+
+// fractSeconds returns the duration, in seconds, of the interval fraction
+func fractSeconds(f Fract) float64 {
+	if f.Denominator == 0 {
+		return 0
+	}
+	return float64(f.Numerator) / float64(f.Denominator)
+}
+
+// fractFPS returns the frame rate, in frames per second, of the interval fraction
+func fractFPS(f Fract) float64 {
+	if f.Numerator == 0 {
+		return 0
+	}
+	return float64(f.Denominator) / float64(f.Numerator)
+}
+
+// MinFPS returns the lowest frame rate of the interval range (from its maximum interval).
+func (i FrameInterval) MinFPS() float64 {
+	return fractFPS(i.Max)
+}
+
+// MaxFPS returns the highest frame rate of the interval range (from its minimum interval).
+func (i FrameInterval) MaxFPS() float64 {
+	return fractFPS(i.Min)
+}
+
+// MinFPS returns the lowest supported frame rate, in frames per second. For a discrete
+// interval, it is the same as MaxFPS.
+func (e FrameIntervalEnum) MinFPS() float64 {
+	return e.Interval.MinFPS()
+}
+
+// MaxFPS returns the highest supported frame rate, in frames per second.
+func (e FrameIntervalEnum) MaxFPS() float64 {
+	return e.Interval.MaxFPS()
+}
+
+// SupportsFPS returns true if the frame rate can be set with the interval: it matches a
+// discrete interval, falls within a continuous range, or falls on a step of a stepwise range.
+func (e FrameIntervalEnum) SupportsFPS(fps float64) bool {
+	if fps <= 0 {
+		return false
+	}
+	minFPS, maxFPS := e.MinFPS(), e.MaxFPS()
+	if fps < minFPS*(1-fpsTolerance) || fps > maxFPS*(1+fpsTolerance) {
+		return false
+	}
+	step := fractSeconds(e.Interval.Step)
+	if e.Type != FrameIntervalTypeStepwise || step == 0 {
+		return true
+	}
+	steps := (1/fps - fractSeconds(e.Interval.Min)) / step
+	return math.Abs(steps-math.Round(steps))*step <= fpsTolerance/fps
+}
+
 // getFrameInterval retrieves the supported frame interval info from following union based on the type:
 
 // 	union {
@@ -87,3 +148,81 @@ func GetFormatFrameInterval(fd uintptr, index uint32, encoding FourCCType, width
 	}
 	return getFrameInterval(interval)
 }
+
+// GetFormatFrameIntervals returns all supported frame intervals for the encoding at the specified frame size.
+// For stepwise and continuous intervals, a single entry holds the interval range.
+func GetFormatFrameIntervals(fd uintptr, encoding FourCCType, width, height uint32) (result []FrameIntervalEnum, err error) {
+	for index := uint32(0); ; index++ {
+		interval, err := GetFormatFrameInterval(fd, index, encoding, width, height)
+		if err != nil {
+			if errors.Is(err, ErrorBadArgument) && len(result) > 0 {
+				break
+			}
+			return result, fmt.Errorf("frame intervals: encoding %s: %dx%d: %w", PixelFormats[encoding], width, height, err)
+		}
+		result = append(result, interval)
+		if interval.Type != FrameIntervalTypeDiscrete {
+			break
+		}
+	}
+	return result, nil
+}
+
+// FormatSizeRate is a frame interval (rate) supported by the device for a pixel format at a frame size.
+// For stepwise and continuous intervals, Interval holds the supported range.
+type FormatSizeRate struct {
+	PixelFormat FourCCType
+	Width       uint32
+	Height      uint32
+	Type        FrameIntervalType
+	Interval    FrameInterval
+}
+
+// MaxFPS returns the highest frame rate, in frames per second, of the interval (its minimum interval).
+func (r FormatSizeRate) MaxFPS() float64 {
+	return r.Interval.MaxFPS()
+}
+
+func (r FormatSizeRate) String() string {
+	return fmt.Sprintf("%s %dx%d @ %.3g fps", PixelFormats[r.PixelFormat], r.Width, r.Height, r.MaxFPS())
+}
+
+// GetAllFormatSizeRates returns the frame intervals supported for each frame size of each
+// supported format, as a flat list. For stepwise and continuous frame sizes, the intervals
+// are reported for the minimum and maximum sizes.
+func GetAllFormatSizeRates(fd uintptr) ([]FormatSizeRate, error) {
+	formats, err := GetAllFormatDescriptions(fd)
+	if len(formats) == 0 && err != nil {
+		return nil, fmt.Errorf("format size rates: %w", err)
+	}
+
+	var result []FormatSizeRate
+	for _, format := range formats {
+		sizes, err := GetFormatFrameSizes(fd, format.PixelFormat)
+		if err != nil {
+			return result, fmt.Errorf("format size rates: %w", err)
+		}
+		for _, size := range sizes {
+			dims := []FrameSizeDiscrete{{Width: size.Size.MaxWidth, Height: size.Size.MaxHeight}}
+			if size.Type != FrameSizeTypeDiscrete && (size.Size.MinWidth != size.Size.MaxWidth || size.Size.MinHeight != size.Size.MaxHeight) {
+				dims = append([]FrameSizeDiscrete{{Width: size.Size.MinWidth, Height: size.Size.MinHeight}}, dims...)
+			}
+			for _, dim := range dims {
+				intervals, err := GetFormatFrameIntervals(fd, format.PixelFormat, dim.Width, dim.Height)
+				if err != nil {
+					return result, fmt.Errorf("format size rates: %w", err)
+				}
+				for _, interval := range intervals {
+					result = append(result, FormatSizeRate{
+						PixelFormat: format.PixelFormat,
+						Width:       dim.Width,
+						Height:      dim.Height,
+						Type:        interval.Type,
+						Interval:    interval.Interval,
+					})
+				}
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,130 @@
+package v4l2
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPixFormatAlignment(t *testing.T) {
+	tests := []struct {
+		pixFmt  PixFormat
+		aligned PixFormat
+	}{
+		{PixFormat{PixelFormat: PixelFmtYUYV, Width: 641, Height: 479}, PixFormat{PixelFormat: PixelFmtYUYV, Width: 640, Height: 479}},
+		{PixFormat{PixelFormat: PixelFmtNV12, Width: 641, Height: 479}, PixFormat{PixelFormat: PixelFmtNV12, Width: 640, Height: 478}},
+		{PixFormat{PixelFormat: PixelFmtMJPEG, Width: 641, Height: 479}, PixFormat{PixelFormat: PixelFmtMJPEG, Width: 641, Height: 479}},
+	}
+	for _, test := range tests {
+		err := ValidatePixFormatAlignment(test.pixFmt)
+		if (test.pixFmt != test.aligned) != errors.Is(err, ErrFormatAlignment) {
+			t.Errorf("unexpected validation result for %#v: %v", test.pixFmt, err)
+		}
+		if aligned := AlignPixFormat(test.pixFmt); aligned != test.aligned {
+			t.Errorf("expecting %dx%d, got %dx%d", test.aligned.Width, test.aligned.Height, aligned.Width, aligned.Height)
+		}
+	}
+}
+
+func TestExternalFormatNames(t *testing.T) {
+	if name, ok := FFmpegPixFmt(PixelFmtYUYV); !ok || name != "yuyv422" {
+		t.Errorf("unexpected ffmpeg format for YUYV: %q", name)
+	}
+	if name, ok := GStreamerFormat(PixelFmtNV12); !ok || name != "NV12" {
+		t.Errorf("unexpected gstreamer format for NV12: %q", name)
+	}
+	if _, ok := FFmpegPixFmt(PixelFmtMJPEG); ok {
+		t.Error("expecting no ffmpeg pixel format for MJPEG")
+	}
+}
+
+func TestFourCC(t *testing.T) {
+	tests := []struct {
+		code   string
+		fourcc FourCCType
+	}{
+		{"YUYV", PixelFmtYUYV},
+		{"MJPG", PixelFmtMJPEG},
+		{"NV12", PixelFmtNV12},
+		{"Y10", 0x20303159},
+		{"Y16-BE", 0xa0363159},
+	}
+	for _, test := range tests {
+		fourcc, err := FourCCFromString(test.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fourcc != test.fourcc {
+			t.Errorf("%s: expecting 0x%08x, got 0x%08x", test.code, test.fourcc, fourcc)
+		}
+		if code := FourCCString(fourcc); code != test.code {
+			t.Errorf("0x%08x: expecting %q, got %q", fourcc, test.code, code)
+		}
+	}
+	for _, code := range []string{"", "YUYV2", "YU\x00V"} {
+		if _, err := FourCCFromString(code); !errors.Is(err, ErrorBadArgument) {
+			t.Errorf("%q: expecting ErrorBadArgument, got %v", code, err)
+		}
+	}
+}
+
+func TestKnownPixelFormats(t *testing.T) {
+	formats := KnownPixelFormats()
+	if len(formats) != len(knownPixelFormats) {
+		t.Fatalf("unexpected number of formats: %d", len(formats))
+	}
+	for _, info := range formats {
+		if info.Description == "" {
+			t.Errorf("%s: missing description", info.Name)
+		}
+		switch info.FourCC {
+		case PixelFmtYUYV:
+			if info.Name != "YUYV" || info.Compressed {
+				t.Errorf("unexpected YUYV info: %#v", info)
+			}
+		case PixelFmtH264:
+			if info.Name != "H264" || !info.Compressed {
+				t.Errorf("unexpected H264 info: %#v", info)
+			}
+		}
+	}
+}
+
+func TestFrameIntervalFPS(t *testing.T) {
+	discrete := FrameIntervalEnum{
+		Type:     FrameIntervalTypeDiscrete,
+		Interval: FrameInterval{Min: Fract{1001, 30000}, Max: Fract{1001, 30000}},
+	}
+	if !discrete.SupportsFPS(29.97) || discrete.SupportsFPS(25) {
+		t.Errorf("unexpected discrete support, fps range %f-%f", discrete.MinFPS(), discrete.MaxFPS())
+	}
+
+	stepwise := FrameIntervalEnum{
+		Type:     FrameIntervalTypeStepwise,
+		Interval: FrameInterval{Min: Fract{1, 60}, Max: Fract{1, 1}, Step: Fract{1, 60}},
+	}
+	if stepwise.MinFPS() != 1 || stepwise.MaxFPS() != 60 {
+		t.Errorf("unexpected stepwise fps range %f-%f", stepwise.MinFPS(), stepwise.MaxFPS())
+	}
+	if !stepwise.SupportsFPS(30) || !stepwise.SupportsFPS(20) || stepwise.SupportsFPS(25) || stepwise.SupportsFPS(120) {
+		t.Error("unexpected stepwise support")
+	}
+
+	continuous := stepwise
+	continuous.Type = FrameIntervalTypeContinuous
+	if !continuous.SupportsFPS(25) {
+		t.Error("expecting 25 fps within the continuous range")
+	}
+}
+
+func TestResolveColorimetry(t *testing.T) {
+	pixFmt := PixFormat{PixelFormat: PixelFmtYUYV, Width: 1280, Height: 720}.ResolveColorimetry()
+	if pixFmt.Colorspace != ColorspaceREC709 || pixFmt.YcbcrEnc != YCbCrEncoding709 ||
+		pixFmt.XferFunc != XferFunc709 || pixFmt.Quantization != QuantizationLimitedRange {
+		t.Errorf("unexpected HD YUYV colorimetry: %+v", pixFmt)
+	}
+
+	pixFmt = PixFormat{PixelFormat: PixelFmtRGB24, Width: 640, Height: 480, XferFunc: XferFuncNone}.ResolveColorimetry()
+	if pixFmt.Colorspace != ColorspaceSRGB || pixFmt.XferFunc != XferFuncNone || pixFmt.Quantization != QuantizationFullRange {
+		t.Errorf("unexpected RGB colorimetry: %+v", pixFmt)
+	}
+}
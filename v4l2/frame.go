@@ -0,0 +1,216 @@
+package v4l2
+
+import (
+	"fmt"
+	"time"
+
+	sys "golang.org/x/sys/unix"
+)
+
+// Frame represents a captured frame along with the buffer information
+// reported by the driver when the frame was dequeued (see v4l2_buffer).
+// https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/buffer.html#c.V4L.v4l2_buffer
+type Frame struct {
+	// Data is a copy of the bytes used in the dequeued buffer
+	Data []byte
+
+	// Width, Height, PixelFormat and BytesPerLine describe the format of the image in Data,
+	// the format negotiated for the stream (see WithFormat). They are zero for frames created
+	// without format information.
+	Width        uint32
+	Height       uint32
+	PixelFormat  FourCCType
+	BytesPerLine uint32
+
+	// Index is the index of the driver buffer the frame was dequeued from
+	Index uint32
+
+	// Sequence is the frame sequence number set by the driver
+	Sequence uint32
+
+	// Timestamp is the capture time reported by the driver (see BufFlagTimestampMask)
+	Timestamp time.Time
+
+	// Flags are the buffer flags reported by the driver (see BufFlag)
+	Flags BufFlag
+
+	// Field is the field order of the image in the buffer (see FieldType). When the
+	// device streams with FieldAlternate, it indicates whether the buffer holds
+	// the top (FieldTop) or the bottom (FieldBottom) field.
+	Field FieldType
+
+	// Timecode is the SMPTE timecode embedded in the buffer by the driver. It is nil
+	// unless the buffer is flagged with BufFlagTimeCode.
+	Timecode *Timecode
+
+	// OutOfOrder is set when the frame sequence precedes the sequence of the previously
+	// delivered frame (wrap around of the sequence counter aside), which indicates a driver
+	// issue. It is only checked when requested (see device.WithOutOfOrderCheck).
+	OutOfOrder bool
+
+	// LumaMean and LumaHistogram are the mean luma and the coarse luma histogram of the
+	// frame (see LumaStats). They are only computed when requested (see device.WithLumaStats)
+	// and the histogram is nil when the format is not supported.
+	LumaMean      uint8
+	LumaHistogram []uint32
+
+	// Raw is the complete buffer information dequeued from the driver. It is only
+	// populated when requested (see device.WithRawBufferInfo) and is left zero-valued
+	// for frames captured with the read/write IO method.
+	Raw Buffer
+}
+
+// NewFrame creates a Frame for the specified dequeued buffer and the data copied from it.
+func NewFrame(buf Buffer, data []byte) Frame {
+	frame := Frame{
+		Data:      data,
+		Index:     buf.Index,
+		Sequence:  buf.Sequence,
+		Timestamp: time.Unix(buf.Timestamp.Unix()),
+		Flags:     buf.Flags,
+		Field:     buf.Field,
+	}
+	if buf.Flags&BufFlagTimeCode != 0 {
+		tc := buf.Timecode
+		frame.Timecode = &tc
+	}
+	return frame
+}
+
+// WithFormat returns the frame with the format information (dimensions, pixel format and
+// line size) of pixFmt, the format of its data.
+func (f Frame) WithFormat(pixFmt PixFormat) Frame {
+	f.Width, f.Height = pixFmt.Width, pixFmt.Height
+	f.PixelFormat, f.BytesPerLine = pixFmt.PixelFormat, pixFmt.BytesPerLine
+	return f
+}
+
+// PixFormat returns the format of the frame data, made from its format information, its
+// field and its data size.
+func (f Frame) PixFormat() PixFormat {
+	return PixFormat{
+		Width:        f.Width,
+		Height:       f.Height,
+		PixelFormat:  f.PixelFormat,
+		Field:        f.Field,
+		BytesPerLine: f.BytesPerLine,
+		SizeImage:    uint32(len(f.Data)),
+	}
+}
+
+// IsTopField returns true if the frame holds only the top (odd) field of an interlaced image.
+func (f Frame) IsTopField() bool {
+	return f.Field == FieldTop
+}
+
+// IsBottomField returns true if the frame holds only the bottom (even) field of an interlaced image.
+func (f Frame) IsBottomField() bool {
+	return f.Field == FieldBottom
+}
+
+// IsErrored returns true if the driver flagged the buffer with BufFlagError: the data may be
+// corrupted (or empty) and the frame should be skipped rather than decoded.
+func (f Frame) IsErrored() bool {
+	return f.Flags&BufFlagError != 0
+}
+
+// IsInterlaced returns true if the frame contains interlaced content, either as both
+// fields in one buffer or as a single field of an alternating stream.
+func (f Frame) IsInterlaced() bool {
+	switch f.Field {
+	case FieldNone, FieldAny:
+		return false
+	default:
+		return true
+	}
+}
+
+// Buffer returns the buffer information of the frame. It is the complete dequeued buffer
+// (Raw) when available, otherwise the buffer is made from the frame fields, with BytesUsed
+// set to the data size.
+func (f Frame) Buffer() Buffer {
+	buf := f.Raw
+	if buf.Memory == 0 {
+		buf = Buffer{
+			Index:     f.Index,
+			BytesUsed: uint32(len(f.Data)),
+			Flags:     f.Flags,
+			Field:     f.Field,
+			Timestamp: sys.NsecToTimeval(f.Timestamp.UnixNano()),
+			Sequence:  f.Sequence,
+		}
+		if f.Timecode != nil {
+			buf.Timecode = *f.Timecode
+		}
+	}
+	return buf
+}
+
+// Clone returns a deep copy of the frame, with its data copied to a newly allocated slice.
+// It lets a consumer retain a frame whose data refers to a driver buffer beyond the
+// buffer lifecycle, so that the buffer can be re-queued safely.
+func (f Frame) Clone() Frame {
+	clone := f
+	if f.Data != nil {
+		clone.Data = make([]byte, len(f.Data))
+		copy(clone.Data, f.Data)
+	}
+	if f.LumaHistogram != nil {
+		clone.LumaHistogram = append([]uint32(nil), f.LumaHistogram...)
+	}
+	if f.Timecode != nil {
+		tc := *f.Timecode
+		clone.Timecode = &tc
+	}
+	return clone
+}
+
+// WeaveFields combines the top and bottom field frames of an alternating stream
+// (FieldAlternate) into a full frame, interleaving their lines: the top field lines become
+// the even lines and the bottom field lines the odd lines of the frame (FieldInterlaced).
+// The fields are made of lines of bytesPerLine bytes, which suits packed (i.e. YUYV) and
+// semi-planar (i.e. NV12) formats. The woven frame carries the buffer information of the
+// field captured first, with twice its height, and its data is newly allocated.
+func WeaveFields(top, bottom Frame, bytesPerLine uint32) (Frame, error) {
+	stride := int(bytesPerLine)
+	if !top.IsTopField() || !bottom.IsBottomField() {
+		return Frame{}, fmt.Errorf("weave fields: expecting top and bottom fields, got fields %d and %d", top.Field, bottom.Field)
+	}
+	if stride == 0 || len(top.Data) != len(bottom.Data) || len(top.Data)%stride != 0 {
+		return Frame{}, fmt.Errorf("weave fields: field sizes %d and %d do not match lines of %d bytes", len(top.Data), len(bottom.Data), stride)
+	}
+
+	frame := top
+	if bottom.Timestamp.Before(top.Timestamp) {
+		frame = bottom
+	}
+	frame.Field = FieldInterlaced
+	frame.Height *= 2
+	frame.Timecode = nil
+	frame.Data = make([]byte, len(top.Data)*2)
+	for line := 0; line < len(top.Data)/stride; line++ {
+		copy(frame.Data[2*line*stride:], top.Data[line*stride:(line+1)*stride])
+		copy(frame.Data[(2*line+1)*stride:], bottom.Data[line*stride:(line+1)*stride])
+	}
+	return frame, nil
+}
+
+// StripPadding repacks frame data with lines of bytesPerLine bytes, as negotiated with the
+// driver (see PixFormat.BytesPerLine and Frame.BytesPerLine), into tightly packed lines of
+// width bytes, the size of the image data of a line (i.e. 2*width pixels for YUYV). The frame
+// is returned as is when its lines are not padded. For semi-planar and planar formats, height
+// is the total number of lines of all planes sharing the line size (i.e. 3*height/2 for NV12).
+func StripPadding(frame []byte, width, bytesPerLine, height int) []byte {
+	if bytesPerLine <= width || width <= 0 {
+		return frame
+	}
+	packed := make([]byte, 0, width*height)
+	for y := 0; y < height && y*bytesPerLine < len(frame); y++ {
+		line := frame[y*bytesPerLine:]
+		if len(line) > width {
+			line = line[:width]
+		}
+		packed = append(packed, line...)
+	}
+	return packed
+}
@@ -0,0 +1,97 @@
+package v4l2
+
+import (
+	"bytes"
+	"testing"
+
+	sys "golang.org/x/sys/unix"
+)
+
+func TestNewFrameTimecode(t *testing.T) {
+	buf := Buffer{Timecode: Timecode{Type: TimecodeType30FPS, Flags: TimecodeFlagDropFrame, Frames: 7, Seconds: 5, Minutes: 4, Hours: 1}}
+
+	if frame := NewFrame(buf, nil); frame.Timecode != nil {
+		t.Errorf("expecting no timecode without BufFlagTimeCode, got %v", frame.Timecode)
+	}
+
+	buf.Flags = BufFlagTimeCode
+	frame := NewFrame(buf, nil)
+	if frame.Timecode == nil {
+		t.Fatal("expecting timecode")
+	}
+	if got := frame.Timecode.String(); got != "01:04:05;07" {
+		t.Errorf("unexpected timecode: %s", got)
+	}
+}
+
+func TestFrameClone(t *testing.T) {
+	frame := Frame{Data: []byte{1, 2, 3}, Sequence: 4, Timecode: &Timecode{Frames: 5}}
+	clone := frame.Clone()
+
+	frame.Data[0] = 9
+	frame.Timecode.Frames = 9
+	if clone.Data[0] != 1 || clone.Timecode.Frames != 5 {
+		t.Errorf("clone shares data with original: %v, %v", clone.Data, clone.Timecode)
+	}
+	if clone.Sequence != 4 || len(clone.Data) != 3 {
+		t.Errorf("unexpected clone: %#v", clone)
+	}
+}
+
+func TestFrameBuffer(t *testing.T) {
+	raw := Buffer{Index: 1, Sequence: 42, BytesUsed: 3, Flags: BufFlagMapped, Timestamp: sys.Timeval{Sec: 10, Usec: 500}}
+	buf := NewFrame(raw, []byte{1, 2, 3}).Buffer()
+	if buf.Index != 1 || buf.Sequence != 42 || buf.BytesUsed != 3 || buf.Flags != BufFlagMapped {
+		t.Errorf("unexpected buffer: %#v", buf)
+	}
+	if buf.Timestamp != raw.Timestamp {
+		t.Errorf("unexpected timestamp: %v, want %v", buf.Timestamp, raw.Timestamp)
+	}
+}
+
+func TestWeaveFields(t *testing.T) {
+	top := Frame{Data: []byte{1, 1, 3, 3}, Field: FieldTop, Sequence: 7}
+	bottom := Frame{Data: []byte{2, 2, 4, 4}, Field: FieldBottom, Sequence: 7}
+
+	frame, err := WeaveFields(top, bottom, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(frame.Data, []byte{1, 1, 2, 2, 3, 3, 4, 4}) {
+		t.Errorf("unexpected woven data %v", frame.Data)
+	}
+	if frame.Field != FieldInterlaced || frame.Sequence != 7 {
+		t.Errorf("unexpected woven frame info: field %d, sequence %d", frame.Field, frame.Sequence)
+	}
+
+	if _, err := WeaveFields(bottom, top, 2); err == nil {
+		t.Error("expecting an error for swapped fields")
+	}
+}
+
+func TestFrameWithFormat(t *testing.T) {
+	pixFmt := PixFormat{Width: 2, Height: 1, PixelFormat: PixelFmtGrey, Field: FieldNone, BytesPerLine: 2, SizeImage: 2}
+	frame := Frame{Data: []byte{1, 2}, Field: FieldNone}.WithFormat(pixFmt)
+	if frame.Width != 2 || frame.Height != 1 || frame.PixelFormat != PixelFmtGrey || frame.BytesPerLine != 2 {
+		t.Errorf("unexpected frame format: %#v", frame)
+	}
+	if got := frame.PixFormat(); got != pixFmt {
+		t.Errorf("unexpected pixel format: %#v, want %#v", got, pixFmt)
+	}
+	if _, err := frame.Decode(); err != nil {
+		t.Errorf("decode: %s", err)
+	}
+	if _, err := (Frame{Data: []byte{1, 2}}).Decode(); err == nil {
+		t.Error("expecting an error for a frame without format")
+	}
+}
+
+func TestStripPadding(t *testing.T) {
+	frame := []byte{1, 2, 3, 0, 4, 5, 6, 0}
+	if got := StripPadding(frame, 3, 4, 2); !bytes.Equal(got, []byte{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("unexpected packed data: %v", got)
+	}
+	if got := StripPadding(frame, 4, 4, 2); !bytes.Equal(got, frame) {
+		t.Errorf("expecting unpadded data as is, got %v", got)
+	}
+}
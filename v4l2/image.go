@@ -0,0 +1,355 @@
+package v4l2
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+)
+
+// FrameToDataURL encodes the frame as a JPEG data URL (data:image/jpeg;base64,...)
+// that can be embedded directly in a web page. JPEG and Motion-JPEG frames are wrapped as-is,
+// raw frames are transcoded to JPEG first. Encoding is costly, this is meant for
+// prototyping and debugging rather than for high frame rates.
+func FrameToDataURL(frame Frame, pixFmt PixFormat) (string, error) {
+	return FrameToDataURLWithEncoder(frame, pixFmt, JPEGEncoder{})
+}
+
+// FrameToDataURLWithEncoder encodes the frame as a data URL using the specified encoder
+// (i.e. PNGEncoder for data:image/png;base64,...).
+func FrameToDataURLWithEncoder(frame Frame, pixFmt PixFormat, enc FrameEncoder) (string, error) {
+	var buf bytes.Buffer
+	if err := EncodeFrame(&buf, frame, pixFmt, enc); err != nil {
+		return "", fmt.Errorf("data url: %w", err)
+	}
+	return "data:" + enc.ContentType() + ";base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DecodeFrame converts the frame data, captured in the specified format, to an image.
+// YUYV, NV12, NV21, RGB24, BGR24, greyscale and JPEG (or Motion-JPEG) frames are supported.
+func DecodeFrame(frame Frame, pixFmt PixFormat) (image.Image, error) {
+	return decodeImage(frame.Data, pixFmt)
+}
+
+// Decode converts the frame data to an image, using the format information of the frame
+// (see DecodeFrame and Frame.WithFormat).
+func (f Frame) Decode() (image.Image, error) {
+	if f.PixelFormat == 0 {
+		return nil, fmt.Errorf("decode frame: no format information")
+	}
+	return DecodeFrame(f, f.PixFormat())
+}
+
+// DecodeYUYV converts a packed YUYV (4:2:2) frame of the specified size, without line
+// padding, to an *image.YCbCr image with 4:2:2 subsampling. The frame must hold exactly
+// width*height*2 bytes. With odd widths, the last pixel of each line has no Cr sample of its
+// own and reuses the one of the preceding pixel pair. Frames with padded lines are decoded
+// with DecodeFrame, or repacked first with StripPadding.
+func DecodeYUYV(frame []byte, width, height int) (image.Image, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("decode YUYV: %w: size %dx%d", ErrorBadArgument, width, height)
+	}
+	if len(frame) != width*height*2 {
+		return nil, fmt.Errorf("decode YUYV: %w: got %d bytes, want %d for %dx%d", ErrorBadArgument, len(frame), width*height*2, width, height)
+	}
+	return yuyvToYCbCr(frame, width, height, width*2), nil
+}
+
+// yuyvToYCbCr converts YUYV data, with lines of stride bytes, to a 4:2:2 YCbCr image.
+// The data is expected to be large enough for the size.
+func yuyvToYCbCr(data []byte, width, height, stride int) *image.YCbCr {
+	img := image.NewYCbCr(image.Rect(0, 0, width, height), image.YCbCrSubsampleRatio422)
+	for y := 0; y < height; y++ {
+		row := data[y*stride:]
+		yRow, cRow := img.Y[y*img.YStride:], y*img.CStride
+		x := 0
+		for ; x+1 < width; x += 2 {
+			i := x * 2
+			yRow[x] = row[i]
+			yRow[x+1] = row[i+2]
+			img.Cb[cRow+x/2] = row[i+1]
+			img.Cr[cRow+x/2] = row[i+3]
+		}
+		if x < width {
+			// odd width: the last pixel only has Y and Cb samples
+			i := x * 2
+			yRow[x] = row[i]
+			img.Cb[cRow+x/2] = row[i+1]
+			img.Cr[cRow+x/2] = 128
+			if x > 0 {
+				img.Cr[cRow+x/2] = img.Cr[cRow+x/2-1]
+			}
+		}
+	}
+	return img
+}
+
+// DecodeNV12 converts a semi-planar NV12 (4:2:0) frame of the specified size, without line
+// padding, to an *image.YCbCr image with 4:2:0 subsampling. The frame holds the luma plane
+// (width*height bytes) followed by the interleaved Cb/Cr plane, with one sample pair for each
+// 2x2 block of pixels. Frames with padded lines are decoded with DecodeFrame, or repacked
+// first with StripPadding.
+func DecodeNV12(frame []byte, width, height int) (*image.YCbCr, error) {
+	return decodeNV(frame, width, height, false)
+}
+
+// DecodeNV21 converts a semi-planar NV21 (4:2:0) frame to an *image.YCbCr image, like
+// DecodeNV12 but with the Cr sample first in the interleaved chroma plane.
+func DecodeNV21(frame []byte, width, height int) (*image.YCbCr, error) {
+	return decodeNV(frame, width, height, true)
+}
+
+func decodeNV(frame []byte, width, height int, crFirst bool) (*image.YCbCr, error) {
+	name := "NV12"
+	if crFirst {
+		name = "NV21"
+	}
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("decode %s: %w: size %dx%d", name, ErrorBadArgument, width, height)
+	}
+	stride := (width + 1) / 2 * 2
+	if size := nvSize(width, height, stride); len(frame) < size {
+		return nil, fmt.Errorf("decode %s: %w: got %d bytes, want %d for %dx%d", name, ErrorBadArgument, len(frame), size, width, height)
+	}
+	return nvToYCbCr(frame, width, height, stride, crFirst), nil
+}
+
+// nvSize returns the size of a semi-planar 4:2:0 frame with lines of stride bytes, in both planes
+func nvSize(width, height, stride int) int {
+	return stride*height + stride*((height+1)/2)
+}
+
+// nvToYCbCr converts semi-planar 4:2:0 data, with luma and chroma lines of stride bytes, to a
+// 4:2:0 YCbCr image. The data is expected to be large enough for the size.
+func nvToYCbCr(data []byte, width, height, stride int, crFirst bool) *image.YCbCr {
+	img := image.NewYCbCr(image.Rect(0, 0, width, height), image.YCbCrSubsampleRatio420)
+	for y := 0; y < height; y++ {
+		copy(img.Y[y*img.YStride:y*img.YStride+width], data[y*stride:])
+	}
+	cb, cr := 0, 1
+	if crFirst {
+		cb, cr = 1, 0
+	}
+	chroma := data[stride*height:]
+	for y := 0; y < (height+1)/2; y++ {
+		row := chroma[y*stride:]
+		for x := 0; x < (width+1)/2; x++ {
+			img.Cb[y*img.CStride+x] = row[x*2+cb]
+			img.Cr[y*img.CStride+x] = row[x*2+cr]
+		}
+	}
+	return img
+}
+
+// DecodeRGB24 converts a packed RGB24 frame of the specified size to an *image.RGBA image.
+// Lines may be padded beyond width*3 bytes, as some drivers do (see PixFormat.BytesPerLine),
+// the line size is then taken as len(frame)/height.
+func DecodeRGB24(frame []byte, width, height int) (*image.RGBA, error) {
+	return decodeRGB(frame, width, height, false)
+}
+
+// DecodeBGR24 converts a packed BGR24 frame to an *image.RGBA image, like DecodeRGB24 but with
+// the blue sample first in each pixel.
+func DecodeBGR24(frame []byte, width, height int) (*image.RGBA, error) {
+	return decodeRGB(frame, width, height, true)
+}
+
+func decodeRGB(frame []byte, width, height int, bgr bool) (*image.RGBA, error) {
+	name := "RGB24"
+	if bgr {
+		name = "BGR24"
+	}
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("decode %s: %w: size %dx%d", name, ErrorBadArgument, width, height)
+	}
+	stride := len(frame) / height
+	if stride < width*3 {
+		return nil, fmt.Errorf("decode %s: %w: got %d bytes, want at least %d for %dx%d", name, ErrorBadArgument, len(frame), width*3*height, width, height)
+	}
+	return rgbToRGBA(frame, width, height, stride, bgr), nil
+}
+
+// rgbToRGBA converts packed 24-bit RGB (or BGR) data, with lines of stride bytes, to an RGBA
+// image. The data is expected to be large enough for the size.
+func rgbToRGBA(data []byte, width, height, stride int, bgr bool) *image.RGBA {
+	r, b := 0, 2
+	if bgr {
+		r, b = 2, 0
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		row, pix := data[y*stride:], img.Pix[y*img.Stride:]
+		for x := 0; x < width; x++ {
+			pix[x*4] = row[x*3+r]
+			pix[x*4+1] = row[x*3+1]
+			pix[x*4+2] = row[x*3+b]
+			pix[x*4+3] = 0xff
+		}
+	}
+	return img
+}
+
+// decodeImage converts raw frame data, in the specified format, to an image
+func decodeImage(data []byte, pixFmt PixFormat) (image.Image, error) {
+	width, height := int(pixFmt.Width), int(pixFmt.Height)
+	stride := int(pixFmt.BytesPerLine)
+
+	switch pixFmt.PixelFormat {
+	case PixelFmtYUYV:
+		if stride == 0 {
+			stride = width * 2
+		}
+		if len(data) < stride*(height-1)+width*2 {
+			return nil, fmt.Errorf("decode YUYV: frame too short: %d bytes", len(data))
+		}
+		return yuyvToYCbCr(data, width, height, stride), nil
+	case PixelFmtNV12, PixelFmtNV21:
+		if stride == 0 {
+			stride = (width + 1) / 2 * 2
+		}
+		if len(data) < nvSize(width, height, stride) {
+			return nil, fmt.Errorf("decode %s: frame too short: %d bytes", PixelFormats[pixFmt.PixelFormat], len(data))
+		}
+		return nvToYCbCr(data, width, height, stride, pixFmt.PixelFormat == PixelFmtNV21), nil
+	case PixelFmtRGB24, PixelFmtBGR24:
+		if stride == 0 {
+			stride = width * 3
+		}
+		if len(data) < stride*(height-1)+width*3 {
+			return nil, fmt.Errorf("decode %s: frame too short: %d bytes", PixelFormats[pixFmt.PixelFormat], len(data))
+		}
+		return rgbToRGBA(data, width, height, stride, pixFmt.PixelFormat == PixelFmtBGR24), nil
+	case PixelFmtGrey:
+		if stride == 0 {
+			stride = width
+		}
+		if len(data) < stride*(height-1)+width {
+			return nil, fmt.Errorf("decode grey: frame too short: %d bytes", len(data))
+		}
+		img := image.NewGray(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			copy(img.Pix[y*img.Stride:y*img.Stride+width], data[y*stride:])
+		}
+		return img, nil
+	case PixelFmtJPEG, PixelFmtMJPEG:
+		return jpeg.Decode(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("decode image: %w: %s", ErrorUnsupported, PixelFormats[pixFmt.PixelFormat])
+	}
+}
+
+// EncodeImage converts img to raw frame data in the specified format, the reverse of DecodeFrame.
+// The image is scaled (nearest neighbor) when its size differs from the format size, and rows
+// are padded to pixFmt.BytesPerLine. YUYV, greyscale, RGB24 and JPEG (or Motion-JPEG) are supported.
+func EncodeImage(img image.Image, pixFmt PixFormat) ([]byte, error) {
+	width, height := int(pixFmt.Width), int(pixFmt.Height)
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("encode image: invalid format size %dx%d", width, height)
+	}
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return nil, fmt.Errorf("encode image: empty image")
+	}
+	at := func(x, y int) color.Color {
+		return img.At(bounds.Min.X+x*bounds.Dx()/width, bounds.Min.Y+y*bounds.Dy()/height)
+	}
+
+	var bpp int
+	switch pixFmt.PixelFormat {
+	case PixelFmtYUYV:
+		bpp = 2
+	case PixelFmtGrey:
+		bpp = 1
+	case PixelFmtRGB24:
+		bpp = 3
+	case PixelFmtJPEG, PixelFmtMJPEG:
+		if bounds.Dx() != width || bounds.Dy() != height {
+			scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					scaled.Set(x, y, at(x, y))
+				}
+			}
+			img = scaled
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, nil); err != nil {
+			return nil, fmt.Errorf("encode image: %w", err)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("encode image: %w: %s", ErrorUnsupported, PixelFormats[pixFmt.PixelFormat])
+	}
+
+	stride := int(pixFmt.BytesPerLine)
+	if stride < width*bpp {
+		stride = width * bpp
+	}
+	data := make([]byte, stride*height)
+	for y := 0; y < height; y++ {
+		row := data[y*stride:]
+		for x := 0; x < width; x++ {
+			switch pixFmt.PixelFormat {
+			case PixelFmtGrey:
+				row[x] = color.GrayModel.Convert(at(x, y)).(color.Gray).Y
+			case PixelFmtRGB24:
+				r, g, b, _ := at(x, y).RGBA()
+				row[x*3], row[x*3+1], row[x*3+2] = uint8(r>>8), uint8(g>>8), uint8(b>>8)
+			case PixelFmtYUYV:
+				// chroma is taken from the even pixel of each pair
+				r, g, b, _ := at(x, y).RGBA()
+				yy, cb, cr := color.RGBToYCbCr(uint8(r>>8), uint8(g>>8), uint8(b>>8))
+				row[x*2] = yy
+				if x%2 == 0 && x+1 < width {
+					row[x*2+1], row[x*2+3] = cb, cr
+				}
+			}
+		}
+	}
+	return data, nil
+}
+
+// EncodeJPEGTargetSize encodes img as JPEG using the highest quality that produces
+// at most targetBytes bytes. The quality is found with a binary search, which costs about
+// seven encodings per call. If the image cannot fit within targetBytes, even at the lowest
+// quality, the lowest quality encoding is returned along with an error.
+func EncodeJPEGTargetSize(img image.Image, targetBytes int) ([]byte, error) {
+	if targetBytes <= 0 {
+		return nil, fmt.Errorf("jpeg target size: invalid target %d", targetBytes)
+	}
+
+	encode := func(quality int) ([]byte, error) {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, fmt.Errorf("jpeg target size: quality %d: %w", quality, err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	var best []byte
+	low, high := 1, 100
+	for low <= high {
+		quality := (low + high) / 2
+		data, err := encode(quality)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) <= targetBytes {
+			best = data
+			low = quality + 1
+		} else {
+			high = quality - 1
+		}
+	}
+
+	if best == nil {
+		data, err := encode(1)
+		if err != nil {
+			return nil, err
+		}
+		return data, fmt.Errorf("jpeg target size: %d bytes at lowest quality exceeds target %d", len(data), targetBytes)
+	}
+	return best, nil
+}
@@ -0,0 +1,228 @@
+package v4l2
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"strings"
+	"testing"
+)
+
+func TestFrameToDataURL(t *testing.T) {
+	pixFmt := PixFormat{PixelFormat: PixelFmtYUYV, Width: 4, Height: 2, BytesPerLine: 8}
+	frame := Frame{Data: bytes.Repeat([]byte{128}, 16)}
+
+	url, err := FrameToDataURL(frame, pixFmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := "data:image/jpeg;base64,"
+	if !strings.HasPrefix(url, prefix) {
+		t.Fatalf("unexpected data url prefix: %s", url)
+	}
+	jpg, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(url, prefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(jpg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 4 || img.Bounds().Dy() != 2 {
+		t.Errorf("unexpected image size: %v", img.Bounds())
+	}
+
+	if _, err := FrameToDataURL(Frame{Data: []byte{1, 2}}, pixFmt); err == nil {
+		t.Error("expected error for short frame")
+	}
+}
+
+func TestEncodeJPEGTargetSize(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 4), B: uint8(x * y), A: 255})
+		}
+	}
+
+	target := 2048
+	data, err := EncodeJPEGTargetSize(img, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) > target {
+		t.Errorf("encoded size %d exceeds target %d", len(data), target)
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := EncodeJPEGTargetSize(img, 10); err == nil {
+		t.Error("expected error for unreachable target")
+	}
+}
+
+func TestCenterCropResize(t *testing.T) {
+	// 6x2 image: a white 2x2 square centered between black borders
+	src := image.NewGray(image.Rect(0, 0, 6, 2))
+	for y := 0; y < 2; y++ {
+		src.SetGray(2, y, color.Gray{Y: 255})
+		src.SetGray(3, y, color.Gray{Y: 255})
+	}
+
+	img := CenterCropResize(src, 1)
+	if img.Bounds() != image.Rect(0, 0, 1, 1) {
+		t.Fatalf("unexpected bounds %v", img.Bounds())
+	}
+	if r, _, _, _ := img.At(0, 0).RGBA(); r>>8 != 255 {
+		t.Errorf("expecting the centered white square, got red %d", r>>8)
+	}
+
+	if img := CenterCropResize(src, 4); img.Bounds() != image.Rect(0, 0, 4, 4) {
+		t.Errorf("unexpected bounds %v", img.Bounds())
+	}
+}
+
+func TestLumaStats(t *testing.T) {
+	// 8x8 YUYV frame with luma 200 and chroma 128
+	data := make([]byte, 8*8*2)
+	for i := range data {
+		data[i] = 128
+		if i%2 == 0 {
+			data[i] = 200
+		}
+	}
+	mean, hist, ok := LumaStats(data, PixFormat{PixelFormat: PixelFmtYUYV, Width: 8, Height: 8})
+	if !ok {
+		t.Fatal("expecting YUYV to be supported")
+	}
+	if mean != 200 {
+		t.Errorf("expecting mean 200, got %d", mean)
+	}
+	if len(hist) != LumaHistogramBins || hist[200*LumaHistogramBins/256] != 4 {
+		t.Errorf("unexpected histogram %v", hist)
+	}
+	if _, _, ok := LumaStats(data, PixFormat{PixelFormat: PixelFmtMJPEG, Width: 8, Height: 8}); ok {
+		t.Error("expecting invalid MJPEG data to be rejected")
+	}
+
+	gray := image.NewGray(image.Rect(0, 0, 8, 8))
+	for i := range gray.Pix {
+		gray.Pix[i] = 100
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, gray, nil); err != nil {
+		t.Fatal(err)
+	}
+	mean, _, ok = LumaStats(buf.Bytes(), PixFormat{PixelFormat: PixelFmtMJPEG, Width: 8, Height: 8})
+	if !ok || mean < 98 || mean > 102 {
+		t.Errorf("expecting MJPEG mean about 100, got %d (%t)", mean, ok)
+	}
+}
+
+func TestEncodeImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for i := range img.Pix {
+		img.Pix[i] = 200
+	}
+
+	pixFmt := PixFormat{PixelFormat: PixelFmtYUYV, Width: 4, Height: 2, BytesPerLine: 8}
+	data, err := EncodeImage(img, pixFmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 16 {
+		t.Fatalf("expecting 16 bytes, got %d", len(data))
+	}
+	decoded, err := DecodeFrame(Frame{Data: data}, pixFmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if y := color.GrayModel.Convert(decoded.At(3, 1)).(color.Gray).Y; y < 195 || y > 205 {
+		t.Errorf("expecting luma of about 200, got %d", y)
+	}
+
+	if _, err := EncodeImage(img, PixFormat{PixelFormat: PixelFmtH264, Width: 2, Height: 2}); err == nil {
+		t.Error("expecting error for unsupported format")
+	}
+}
+
+func TestDecodeYUYV(t *testing.T) {
+	// 3x1 frame: Y0 U Y1 V, then Y2 U2 for the odd last pixel
+	frame := []byte{10, 100, 20, 200, 30, 110}
+	img, err := DecodeYUYV(frame, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ycbcr, ok := img.(*image.YCbCr)
+	if !ok || ycbcr.SubsampleRatio != image.YCbCrSubsampleRatio422 {
+		t.Fatalf("expecting a 4:2:2 YCbCr image, got %T", img)
+	}
+	if got := ycbcr.YCbCrAt(1, 0); got.Y != 20 || got.Cb != 100 || got.Cr != 200 {
+		t.Errorf("unexpected pixel 1: %v", got)
+	}
+	if got := ycbcr.YCbCrAt(2, 0); got.Y != 30 || got.Cb != 110 || got.Cr != 200 {
+		t.Errorf("unexpected pixel 2: %v", got)
+	}
+
+	if _, err := DecodeYUYV(frame[:4], 3, 1); !errors.Is(err, ErrorBadArgument) {
+		t.Errorf("expecting ErrorBadArgument for a short frame, got %v", err)
+	}
+}
+
+func TestDecodeNV12(t *testing.T) {
+	// 2x2 frame: 4 luma samples, then one chroma pair
+	frame := []byte{1, 2, 3, 4, 100, 200}
+	img, err := DecodeNV12(frame, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.SubsampleRatio != image.YCbCrSubsampleRatio420 {
+		t.Errorf("unexpected subsample ratio: %v", img.SubsampleRatio)
+	}
+	if got := img.YCbCrAt(1, 1); got.Y != 4 || got.Cb != 100 || got.Cr != 200 {
+		t.Errorf("unexpected NV12 pixel: %v", got)
+	}
+
+	img, err = DecodeNV21(frame, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.YCbCrAt(0, 0); got.Y != 1 || got.Cb != 200 || got.Cr != 100 {
+		t.Errorf("unexpected NV21 pixel: %v", got)
+	}
+
+	if _, err := DecodeNV12(frame[:5], 2, 2); !errors.Is(err, ErrorBadArgument) {
+		t.Errorf("expecting ErrorBadArgument for a short frame, got %v", err)
+	}
+}
+
+func TestDecodeRGB24(t *testing.T) {
+	// 2x2 frame, lines padded to 8 bytes
+	frame := []byte{
+		1, 2, 3, 4, 5, 6, 0, 0,
+		7, 8, 9, 10, 11, 12, 0, 0,
+	}
+	img, err := DecodeRGB24(frame, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.RGBAAt(1, 1); got != (color.RGBA{R: 10, G: 11, B: 12, A: 255}) {
+		t.Errorf("unexpected RGB24 pixel: %v", got)
+	}
+
+	img, err = DecodeBGR24(frame, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.RGBAAt(0, 1); got != (color.RGBA{R: 9, G: 8, B: 7, A: 255}) {
+		t.Errorf("unexpected BGR24 pixel: %v", got)
+	}
+
+	if _, err := DecodeRGB24(frame[:10], 2, 2); !errors.Is(err, ErrorBadArgument) {
+		t.Errorf("expecting ErrorBadArgument for a short frame, got %v", err)
+	}
+}
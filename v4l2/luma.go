@@ -0,0 +1,72 @@
+package v4l2
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+)
+
+// LumaHistogramBins is the number of bins of the luma histogram computed by LumaStats,
+// each bin counts 256/LumaHistogramBins consecutive luma values.
+const LumaHistogramBins = 16
+
+// lumaSampleStep is the horizontal and vertical distance between the luma samples
+const lumaSampleStep = 4
+
+// LumaStats computes the mean luma and a coarse luma histogram (LumaHistogramBins bins) of
+// frame data in the specified format. To keep it cheap, only the luma (Y) plane is read
+// and it is sampled every few pixels and lines. The packed YUV 4:2:2 (i.e. YUYV), planar and
+// semi-planar YUV 4:2:0 (i.e. NV12) and greyscale formats are read without decoding, JPEG
+// (and Motion-JPEG) frames are decoded first, which is much more costly. It returns false
+// for other formats, or if the data is shorter than the format image (or is not valid JPEG).
+func LumaStats(data []byte, pixFmt PixFormat) (uint8, []uint32, bool) {
+	width, height := int(pixFmt.Width), int(pixFmt.Height)
+	stride := int(pixFmt.BytesPerLine)
+
+	// position of the luma byte of pixel x within a line: offset + x*pitch
+	var offset, pitch int
+	switch pixFmt.PixelFormat {
+	case PixelFmtJPEG, PixelFmtMJPEG:
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return 0, nil, false
+		}
+		switch img := img.(type) {
+		case *image.YCbCr:
+			data, stride = img.Y, img.YStride
+		case *image.Gray:
+			data, stride = img.Pix, img.Stride
+		default:
+			return 0, nil, false
+		}
+		width, height = img.Bounds().Dx(), img.Bounds().Dy()
+		offset, pitch = 0, 1
+	case PixelFmtYUYV, PixelFmtYVYU:
+		offset, pitch = 0, 2
+	case PixelFmtUYVY, PixelFmtVYUY:
+		offset, pitch = 1, 2
+	case PixelFmtNV12, PixelFmtNV21, PixelFmtYUV420, PixelFmtYVU420, PixelFmtGrey:
+		offset, pitch = 0, 1
+	default:
+		return 0, nil, false
+	}
+	if stride == 0 {
+		stride = width * pitch
+	}
+	if width == 0 || height == 0 || len(data) < stride*(height-1)+width*pitch {
+		return 0, nil, false
+	}
+
+	hist := make([]uint32, LumaHistogramBins)
+	var sum, count uint64
+	for y := 0; y < height; y += lumaSampleStep {
+		line := data[y*stride:]
+		for x := 0; x < width; x += lumaSampleStep {
+			luma := line[offset+x*pitch]
+			sum += uint64(luma)
+			count++
+			hist[int(luma)*LumaHistogramBins/256]++
+		}
+	}
+	return uint8(sum / count), hist, true
+}
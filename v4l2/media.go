@@ -3,7 +3,9 @@ package v4l2
 // #include <linux/media.h>
 import "C"
 import (
+	"errors"
 	"fmt"
+	"runtime"
 	"unsafe"
 )
 
@@ -36,3 +38,353 @@ func GetMediaDeviceInfo(fd uintptr) (MediaDeviceInfo, error) {
 		DriverVersion:    VersionInfo{value: uint32(mdi.driver_version)},
 	}, nil
 }
+
+// MediaEntityFunction (MEDIA_ENT_F_*) identifies the main function of a media entity
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/mediactl/media-types.html
+type MediaEntityFunction = uint32
+
+const (
+	MediaEntityFunctionUnknown         MediaEntityFunction = C.MEDIA_ENT_F_UNKNOWN
+	MediaEntityFunctionSubdevUnknown   MediaEntityFunction = C.MEDIA_ENT_F_V4L2_SUBDEV_UNKNOWN
+	MediaEntityFunctionIOV4L           MediaEntityFunction = C.MEDIA_ENT_F_IO_V4L
+	MediaEntityFunctionIOVBI           MediaEntityFunction = C.MEDIA_ENT_F_IO_VBI
+	MediaEntityFunctionIOSWRadio       MediaEntityFunction = C.MEDIA_ENT_F_IO_SWRADIO
+	MediaEntityFunctionCamSensor       MediaEntityFunction = C.MEDIA_ENT_F_CAM_SENSOR
+	MediaEntityFunctionFlash           MediaEntityFunction = C.MEDIA_ENT_F_FLASH
+	MediaEntityFunctionLens            MediaEntityFunction = C.MEDIA_ENT_F_LENS
+	MediaEntityFunctionTuner           MediaEntityFunction = C.MEDIA_ENT_F_TUNER
+	MediaEntityFunctionATVDecoder      MediaEntityFunction = C.MEDIA_ENT_F_ATV_DECODER
+	MediaEntityFunctionIFVideoDecoder  MediaEntityFunction = C.MEDIA_ENT_F_IF_VID_DECODER
+	MediaEntityFunctionIFAudioDecoder  MediaEntityFunction = C.MEDIA_ENT_F_IF_AUD_DECODER
+	MediaEntityFunctionVideoComposer   MediaEntityFunction = C.MEDIA_ENT_F_PROC_VIDEO_COMPOSER
+	MediaEntityFunctionPixelFormatter  MediaEntityFunction = C.MEDIA_ENT_F_PROC_VIDEO_PIXEL_FORMATTER
+	MediaEntityFunctionPixelEncConv    MediaEntityFunction = C.MEDIA_ENT_F_PROC_VIDEO_PIXEL_ENC_CONV
+	MediaEntityFunctionVideoLUT        MediaEntityFunction = C.MEDIA_ENT_F_PROC_VIDEO_LUT
+	MediaEntityFunctionVideoScaler     MediaEntityFunction = C.MEDIA_ENT_F_PROC_VIDEO_SCALER
+	MediaEntityFunctionVideoStatistics MediaEntityFunction = C.MEDIA_ENT_F_PROC_VIDEO_STATISTICS
+	MediaEntityFunctionVideoEncoder    MediaEntityFunction = C.MEDIA_ENT_F_PROC_VIDEO_ENCODER
+	MediaEntityFunctionVideoDecoder    MediaEntityFunction = C.MEDIA_ENT_F_PROC_VIDEO_DECODER
+	MediaEntityFunctionVideoISP        MediaEntityFunction = C.MEDIA_ENT_F_PROC_VIDEO_ISP
+	MediaEntityFunctionVideoMux        MediaEntityFunction = C.MEDIA_ENT_F_VID_MUX
+	MediaEntityFunctionVideoIFBridge   MediaEntityFunction = C.MEDIA_ENT_F_VID_IF_BRIDGE
+	MediaEntityFunctionDVDecoder       MediaEntityFunction = C.MEDIA_ENT_F_DV_DECODER
+	MediaEntityFunctionDVEncoder       MediaEntityFunction = C.MEDIA_ENT_F_DV_ENCODER
+)
+
+// MediaEntityFunctions maps entity functions to their textual description
+var MediaEntityFunctions = map[MediaEntityFunction]string{
+	MediaEntityFunctionUnknown:         "unknown",
+	MediaEntityFunctionSubdevUnknown:   "unknown sub-device",
+	MediaEntityFunctionIOV4L:           "V4L2 I/O",
+	MediaEntityFunctionIOVBI:           "VBI I/O",
+	MediaEntityFunctionIOSWRadio:       "SDR I/O",
+	MediaEntityFunctionCamSensor:       "camera sensor",
+	MediaEntityFunctionFlash:           "flash controller",
+	MediaEntityFunctionLens:            "lens controller",
+	MediaEntityFunctionTuner:           "tuner",
+	MediaEntityFunctionATVDecoder:      "analog video decoder",
+	MediaEntityFunctionIFVideoDecoder:  "IF video decoder",
+	MediaEntityFunctionIFAudioDecoder:  "IF audio decoder",
+	MediaEntityFunctionVideoComposer:   "video composer",
+	MediaEntityFunctionPixelFormatter:  "video pixel formatter",
+	MediaEntityFunctionPixelEncConv:    "video pixel encoding converter",
+	MediaEntityFunctionVideoLUT:        "video look-up table",
+	MediaEntityFunctionVideoScaler:     "video scaler",
+	MediaEntityFunctionVideoStatistics: "video statistics",
+	MediaEntityFunctionVideoEncoder:    "video encoder",
+	MediaEntityFunctionVideoDecoder:    "video decoder",
+	MediaEntityFunctionVideoISP:        "image signal processor",
+	MediaEntityFunctionVideoMux:        "video multiplexer",
+	MediaEntityFunctionVideoIFBridge:   "video interface bridge",
+	MediaEntityFunctionDVDecoder:       "digital video decoder",
+	MediaEntityFunctionDVEncoder:       "digital video encoder",
+}
+
+// MediaEntityFlag (MEDIA_ENT_FL_*)
+type MediaEntityFlag = uint32
+
+const (
+	// MediaEntityFlagDefault marks the default entity of its type, i.e. the main capture
+	// node of a UVC camera
+	MediaEntityFlagDefault   MediaEntityFlag = C.MEDIA_ENT_FL_DEFAULT
+	MediaEntityFlagConnector MediaEntityFlag = C.MEDIA_ENT_FL_CONNECTOR
+)
+
+// MediaInterfaceType (MEDIA_INTF_T_*) identifies the type of a media interface
+type MediaInterfaceType = uint32
+
+const (
+	MediaInterfaceTypeV4LVideo  MediaInterfaceType = C.MEDIA_INTF_T_V4L_VIDEO
+	MediaInterfaceTypeV4LSubdev MediaInterfaceType = C.MEDIA_INTF_T_V4L_SUBDEV
+)
+
+// MediaPadFlag (MEDIA_PAD_FL_*)
+type MediaPadFlag = uint32
+
+const (
+	MediaPadFlagSink   MediaPadFlag = C.MEDIA_PAD_FL_SINK
+	MediaPadFlagSource MediaPadFlag = C.MEDIA_PAD_FL_SOURCE
+)
+
+// MediaLinkFlag (MEDIA_LNK_FL_*)
+type MediaLinkFlag = uint32
+
+const (
+	MediaLinkFlagEnabled       MediaLinkFlag = C.MEDIA_LNK_FL_ENABLED
+	MediaLinkFlagImmutable     MediaLinkFlag = C.MEDIA_LNK_FL_IMMUTABLE
+	MediaLinkFlagTypeMask      MediaLinkFlag = 0xf << 28 // MEDIA_LNK_FL_LINK_TYPE, negative as a C int
+	MediaLinkFlagDataLink      MediaLinkFlag = C.MEDIA_LNK_FL_DATA_LINK
+	MediaLinkFlagInterfaceLink MediaLinkFlag = C.MEDIA_LNK_FL_INTERFACE_LINK
+)
+
+// MediaEntity (media_v2_entity) is an element of the media pipeline (i.e. a sensor or a DMA engine)
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/mediactl/media-ioc-g-topology.html
+type MediaEntity struct {
+	ID       uint32
+	Name     string
+	Function MediaEntityFunction
+	Flags    uint32
+}
+
+// MediaInterface (media_v2_interface) is a device node used to control an entity
+type MediaInterface struct {
+	ID       uint32
+	Type     MediaInterfaceType
+	Flags    uint32
+	DevMajor uint32
+	DevMinor uint32
+}
+
+// MediaPad (media_v2_pad) is a connection endpoint of an entity
+type MediaPad struct {
+	ID       uint32
+	EntityID uint32
+	Flags    MediaPadFlag
+	Index    uint32
+}
+
+// MediaLink (media_v2_link) connects two pads (data link) or an interface to an entity
+// (interface link). For interface links, SourceID is the interface ID and SinkID the entity ID.
+type MediaLink struct {
+	ID       uint32
+	SourceID uint32
+	SinkID   uint32
+	Flags    MediaLinkFlag
+}
+
+// MediaTopology (media_v2_topology) is the graph of entities, interfaces, pads, and links
+// of a media device.
+type MediaTopology struct {
+	Version    uint64
+	Entities   []MediaEntity
+	Interfaces []MediaInterface
+	Pads       []MediaPad
+	Links      []MediaLink
+}
+
+// the media_v2_* structs are packed, they are mirrored here to avoid cgo's handling of packed structs
+type mediaV2Entity struct {
+	id       uint32
+	name     [64]byte
+	function uint32
+	flags    uint32
+	_        [5]uint32
+}
+
+type mediaV2Interface struct {
+	id       uint32
+	intfType uint32
+	flags    uint32
+	_        [9]uint32
+	raw      [16]uint32 // union, devnode major/minor
+}
+
+type mediaV2Pad struct {
+	id       uint32
+	entityID uint32
+	flags    uint32
+	index    uint32
+	_        [4]uint32
+}
+
+type mediaV2Link struct {
+	id       uint32
+	sourceID uint32
+	sinkID   uint32
+	flags    uint32
+	_        [6]uint32
+}
+
+type mediaV2Topology struct {
+	version       uint64
+	numEntities   uint32
+	_             uint32
+	ptrEntities   uint64
+	numInterfaces uint32
+	_             uint32
+	ptrInterfaces uint64
+	numPads       uint32
+	_             uint32
+	ptrPads       uint64
+	numLinks      uint32
+	_             uint32
+	ptrLinks      uint64
+}
+
+// GetMediaTopology retrieves the topology of the media device (/dev/mediaX) opened as fd.
+// The topology is queried twice: once for the element counts and once for the elements.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/mediactl/media-ioc-g-topology.html
+func GetMediaTopology(fd uintptr) (MediaTopology, error) {
+	var topo mediaV2Topology
+	if err := send(fd, C.MEDIA_IOC_G_TOPOLOGY, uintptr(unsafe.Pointer(&topo))); err != nil {
+		return MediaTopology{}, fmt.Errorf("media topology: %w", err)
+	}
+
+	entities := make([]mediaV2Entity, topo.numEntities)
+	interfaces := make([]mediaV2Interface, topo.numInterfaces)
+	pads := make([]mediaV2Pad, topo.numPads)
+	links := make([]mediaV2Link, topo.numLinks)
+	if len(entities) > 0 {
+		topo.ptrEntities = uint64(uintptr(unsafe.Pointer(&entities[0])))
+	}
+	if len(interfaces) > 0 {
+		topo.ptrInterfaces = uint64(uintptr(unsafe.Pointer(&interfaces[0])))
+	}
+	if len(pads) > 0 {
+		topo.ptrPads = uint64(uintptr(unsafe.Pointer(&pads[0])))
+	}
+	if len(links) > 0 {
+		topo.ptrLinks = uint64(uintptr(unsafe.Pointer(&links[0])))
+	}
+	err := send(fd, C.MEDIA_IOC_G_TOPOLOGY, uintptr(unsafe.Pointer(&topo)))
+	runtime.KeepAlive(entities)
+	runtime.KeepAlive(interfaces)
+	runtime.KeepAlive(pads)
+	runtime.KeepAlive(links)
+	if err != nil {
+		return MediaTopology{}, fmt.Errorf("media topology: %w", err)
+	}
+
+	result := MediaTopology{Version: topo.version}
+	for _, e := range entities[:topo.numEntities] {
+		result.Entities = append(result.Entities, MediaEntity{
+			ID:       e.id,
+			Name:     C.GoString((*C.char)(unsafe.Pointer(&e.name[0]))),
+			Function: e.function,
+			Flags:    e.flags,
+		})
+	}
+	for _, i := range interfaces[:topo.numInterfaces] {
+		result.Interfaces = append(result.Interfaces, MediaInterface{
+			ID:       i.id,
+			Type:     i.intfType,
+			Flags:    i.flags,
+			DevMajor: i.raw[0],
+			DevMinor: i.raw[1],
+		})
+	}
+	for _, p := range pads[:topo.numPads] {
+		result.Pads = append(result.Pads, MediaPad{ID: p.id, EntityID: p.entityID, Flags: p.flags, Index: p.index})
+	}
+	for _, l := range links[:topo.numLinks] {
+		result.Links = append(result.Links, MediaLink{ID: l.id, SourceID: l.sourceID, SinkID: l.sinkID, Flags: l.flags})
+	}
+	return result, nil
+}
+
+// Entity returns the entity with the specified ID
+func (t MediaTopology) Entity(id uint32) (MediaEntity, bool) {
+	for _, e := range t.Entities {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return MediaEntity{}, false
+}
+
+// Pad returns the pad with the specified ID
+func (t MediaTopology) Pad(id uint32) (MediaPad, bool) {
+	for _, p := range t.Pads {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return MediaPad{}, false
+}
+
+// EntityInterface returns the interface (device node) linked to the entity, if any
+func (t MediaTopology) EntityInterface(entityID uint32) (MediaInterface, bool) {
+	for _, l := range t.Links {
+		if l.Flags&MediaLinkFlagTypeMask != MediaLinkFlagInterfaceLink || l.SinkID != entityID {
+			continue
+		}
+		for _, i := range t.Interfaces {
+			if i.ID == l.SourceID {
+				return i, true
+			}
+		}
+	}
+	return MediaInterface{}, false
+}
+
+// MediaEntityDesc (media_entity_desc) describes an entity along with its device node, as
+// returned by MEDIA_IOC_ENUM_ENTITIES. Type holds the entity function. DevMajor and DevMinor
+// are zero when the entity has no device node.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/mediactl/media-ioc-enum-entities.html
+type MediaEntityDesc struct {
+	ID       uint32
+	Name     string
+	Type     MediaEntityFunction
+	Revision uint32
+	Flags    MediaEntityFlag
+	GroupID  uint32
+	Pads     uint16
+	Links    uint16
+	DevMajor uint32
+	DevMinor uint32
+}
+
+// mediaEntityDesc mirrors media_entity_desc, its anonymous union starts with the
+// devnode major/minor numbers
+type mediaEntityDesc struct {
+	id       uint32
+	name     [32]byte
+	entType  uint32
+	revision uint32
+	flags    uint32
+	groupID  uint32
+	pads     uint16
+	links    uint16
+	_        [4]uint32
+	raw      [46]uint32 // union, devnode major/minor
+}
+
+// GetMediaEntityDescs enumerates the entities of the media device opened as fd, using
+// MEDIA_IOC_ENUM_ENTITIES with MEDIA_ENT_ID_FLAG_NEXT until the driver returns EINVAL.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/mediactl/media-ioc-enum-entities.html
+func GetMediaEntityDescs(fd uintptr) ([]MediaEntityDesc, error) {
+	var result []MediaEntityDesc
+	var id uint32
+	for {
+		desc := mediaEntityDesc{id: id | C.MEDIA_ENT_ID_FLAG_NEXT}
+		if err := send(fd, C.MEDIA_IOC_ENUM_ENTITIES, uintptr(unsafe.Pointer(&desc))); err != nil {
+			if errors.Is(err, ErrorBadArgument) {
+				return result, nil
+			}
+			return result, fmt.Errorf("media entities: %w", err)
+		}
+		result = append(result, MediaEntityDesc{
+			ID:       desc.id,
+			Name:     C.GoString((*C.char)(unsafe.Pointer(&desc.name[0]))),
+			Type:     desc.entType,
+			Revision: desc.revision,
+			Flags:    desc.flags,
+			GroupID:  desc.groupID,
+			Pads:     desc.pads,
+			Links:    desc.links,
+			DevMajor: desc.raw[0],
+			DevMinor: desc.raw[1],
+		})
+		id = desc.id
+	}
+}
@@ -0,0 +1,245 @@
+package v4l2
+
+// #include <linux/videodev2.h>
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Multi-planar API
+// Multi-planar formats store the components of an image in separate, non-contiguous memory
+// buffers (planes), i.e. the luma and chroma planes of NV12M. Devices supporting it use the
+// BufTypeVideoCaptureMPlane (or BufTypeVideoOutputMPlane) buffer type.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/planar-apis.html
+
+// MaxPlanes is the maximum number of planes of a multi-planar buffer (VIDEO_MAX_PLANES)
+const MaxPlanes = C.VIDEO_MAX_PLANES
+
+// PlaneFormat (v4l2_plane_pix_format) holds the size information of one plane of a
+// multi-planar format.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/pixfmt-v4l2-mplane.html#c.V4L.v4l2_plane_pix_format
+type PlaneFormat struct {
+	SizeImage    uint32
+	BytesPerLine uint32
+}
+
+// PixFormatMPlane (v4l2_pix_format_mplane) is the image format of a multi-planar device,
+// with the size information of each plane.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/pixfmt-v4l2-mplane.html#c.V4L.v4l2_pix_format_mplane
+// See https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/videodev2.h#L2245
+type PixFormatMPlane struct {
+	Width        uint32
+	Height       uint32
+	PixelFormat  FourCCType
+	Field        FieldType
+	Colorspace   ColorspaceType
+	Planes       []PlaneFormat
+	Flags        uint8
+	YcbcrEnc     YCbCrEncodingType
+	Quantization QuantizationType
+	XferFunc     XferFunctionType
+}
+
+// PixFormat returns the single-planar equivalent of the format: the bytes per line of the
+// first plane and the total image size of all planes, which describes the frame data
+// assembled from the planes (i.e. for decoding).
+func (p PixFormatMPlane) PixFormat() PixFormat {
+	pixFmt := PixFormat{
+		Width:        p.Width,
+		Height:       p.Height,
+		PixelFormat:  p.PixelFormat,
+		Field:        p.Field,
+		Colorspace:   p.Colorspace,
+		YcbcrEnc:     p.YcbcrEnc,
+		Quantization: p.Quantization,
+		XferFunc:     p.XferFunc,
+	}
+	for i, plane := range p.Planes {
+		if i == 0 {
+			pixFmt.BytesPerLine = plane.BytesPerLine
+		}
+		pixFmt.SizeImage += plane.SizeImage
+	}
+	return pixFmt
+}
+
+// GetPixFormatMPlane retrieves the multi-planar format of the buffer type (i.e. BufTypeVideoCaptureMPlane).
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-fmt.html
+func GetPixFormatMPlane(fd uintptr, bufType BufType) (PixFormatMPlane, error) {
+	var v4l2Format C.struct_v4l2_format
+	v4l2Format._type = C.uint(bufType)
+
+	if err := send(fd, C.VIDIOC_G_FMT, uintptr(unsafe.Pointer(&v4l2Format))); err != nil {
+		return PixFormatMPlane{}, fmt.Errorf("pix format mplane: %w", err)
+	}
+	return makePixFormatMPlane((*C.struct_v4l2_pix_format_mplane)(unsafe.Pointer(&v4l2Format.fmt[0]))), nil
+}
+
+// SetPixFormatMPlane sets the multi-planar format of the buffer type and returns the format
+// as adjusted by the driver, with the number and size of its planes. The plane sizes of
+// pixFmt may be left empty for the driver to fill.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-fmt.html
+func SetPixFormatMPlane(fd uintptr, bufType BufType, pixFmt PixFormatMPlane) (PixFormatMPlane, error) {
+	if len(pixFmt.Planes) > MaxPlanes {
+		return PixFormatMPlane{}, fmt.Errorf("set pix format mplane: %d planes exceed maximum %d", len(pixFmt.Planes), MaxPlanes)
+	}
+	var v4l2Format C.struct_v4l2_format
+	v4l2Format._type = C.uint(bufType)
+	mp := (*C.struct_v4l2_pix_format_mplane)(unsafe.Pointer(&v4l2Format.fmt[0]))
+	mp.width = C.uint(pixFmt.Width)
+	mp.height = C.uint(pixFmt.Height)
+	mp.pixelformat = C.uint(pixFmt.PixelFormat)
+	mp.field = C.uint(pixFmt.Field)
+	mp.colorspace = C.uint(pixFmt.Colorspace)
+	mp.num_planes = C.uchar(len(pixFmt.Planes))
+	for i, plane := range pixFmt.Planes {
+		mp.plane_fmt[i].sizeimage = C.uint(plane.SizeImage)
+		mp.plane_fmt[i].bytesperline = C.uint(plane.BytesPerLine)
+	}
+	mp.flags = C.uchar(pixFmt.Flags)
+	*(*uint8)(unsafe.Pointer(&mp.anon0[0])) = uint8(pixFmt.YcbcrEnc)
+	mp.quantization = C.uchar(pixFmt.Quantization)
+	mp.xfer_func = C.uchar(pixFmt.XferFunc)
+
+	if err := send(fd, C.VIDIOC_S_FMT, uintptr(unsafe.Pointer(&v4l2Format))); err != nil {
+		return PixFormatMPlane{}, fmt.Errorf("set pix format mplane: %w", err)
+	}
+	return makePixFormatMPlane(mp), nil
+}
+
+func makePixFormatMPlane(mp *C.struct_v4l2_pix_format_mplane) PixFormatMPlane {
+	pixFmt := PixFormatMPlane{
+		Width:        uint32(mp.width),
+		Height:       uint32(mp.height),
+		PixelFormat:  FourCCType(mp.pixelformat),
+		Field:        FieldType(mp.field),
+		Colorspace:   ColorspaceType(mp.colorspace),
+		Flags:        uint8(mp.flags),
+		YcbcrEnc:     YCbCrEncodingType(*(*uint8)(unsafe.Pointer(&mp.anon0[0]))),
+		Quantization: QuantizationType(mp.quantization),
+		XferFunc:     XferFunctionType(mp.xfer_func),
+	}
+	for i := 0; i < int(mp.num_planes) && i < MaxPlanes; i++ {
+		pixFmt.Planes = append(pixFmt.Planes, PlaneFormat{
+			SizeImage:    uint32(mp.plane_fmt[i].sizeimage),
+			BytesPerLine: uint32(mp.plane_fmt[i].bytesperline),
+		})
+	}
+	return pixFmt
+}
+
+// IsMultiPlanar returns true for the multi-planar buffer types
+func IsMultiPlanar(bufType BufType) bool {
+	return bufType == BufTypeVideoCaptureMPlane || bufType == BufTypeVideoOutputMPlane
+}
+
+// GetBufferPlanes queries (VIDIOC_QUERYBUF) the multi-planar buffer at index and returns it
+// along with its planes (the buffer Length is the number of planes).
+func GetBufferPlanes(dev BufferQueue, index uint32) (Buffer, []Plane, error) {
+	var planes [MaxPlanes]C.struct_v4l2_plane
+	var v4l2Buf C.struct_v4l2_buffer
+	v4l2Buf._type = C.uint(dev.BufferType())
+	v4l2Buf.memory = C.uint(dev.MemIOType())
+	v4l2Buf.index = C.uint(index)
+	v4l2Buf.length = C.uint(MaxPlanes)
+	*(**C.struct_v4l2_plane)(unsafe.Pointer(&v4l2Buf.m[0])) = &planes[0]
+
+	if err := send(dev.Fd(), C.VIDIOC_QUERYBUF, uintptr(unsafe.Pointer(&v4l2Buf))); err != nil {
+		return Buffer{}, nil, fmt.Errorf("query buffer planes: %w", err)
+	}
+	return makeBuffer(v4l2Buf), makePlanes(planes[:v4l2Buf.length]), nil
+}
+
+// MapMemoryBufferPlanes maps each plane of each multi-planar buffer of the device into
+// local memory, indexed by buffer then plane.
+func MapMemoryBufferPlanes(dev BufferQueue) ([][][]byte, error) {
+	bufCount := int(dev.BufferCount())
+	buffers := make([][][]byte, bufCount)
+	for i := 0; i < bufCount; i++ {
+		_, planes, err := GetBufferPlanes(dev, uint32(i))
+		if err != nil {
+			return nil, fmt.Errorf("mapped buffer planes: %w", err)
+		}
+		for _, plane := range planes {
+			mapped, err := mapMemoryBuffer(dev.Fd(), int64(plane.Info.MemOffset), int(plane.Length))
+			if err != nil {
+				return nil, fmt.Errorf("mapped buffer planes: buffer %d: %w", i, err)
+			}
+			buffers[i] = append(buffers[i], mapped)
+		}
+	}
+	return buffers, nil
+}
+
+// QueueBufferPlanes enqueues the multi-planar buffer at index, with numPlanes planes.
+// https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-qbuf.html
+func QueueBufferPlanes(fd uintptr, ioType IOType, bufType BufType, index uint32, numPlanes int) (Buffer, error) {
+	var planes [MaxPlanes]C.struct_v4l2_plane
+	var v4l2Buf C.struct_v4l2_buffer
+	v4l2Buf._type = C.uint(bufType)
+	v4l2Buf.memory = C.uint(ioType)
+	v4l2Buf.index = C.uint(index)
+	v4l2Buf.length = C.uint(numPlanes)
+	*(**C.struct_v4l2_plane)(unsafe.Pointer(&v4l2Buf.m[0])) = &planes[0]
+
+	if err := send(fd, C.VIDIOC_QBUF, uintptr(unsafe.Pointer(&v4l2Buf))); err != nil {
+		return Buffer{}, fmt.Errorf("buffer queue: planes: %w", err)
+	}
+	return makeBuffer(v4l2Buf), nil
+}
+
+// QueueOutputBufferPlanes enqueues the multi-planar output buffer at index, filled with
+// bytesUsed bytes in each of its planes.
+// https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-qbuf.html
+func QueueOutputBufferPlanes(fd uintptr, ioType IOType, bufType BufType, index uint32, bytesUsed []uint32) (Buffer, error) {
+	if len(bytesUsed) > MaxPlanes {
+		return Buffer{}, fmt.Errorf("buffer queue: output planes: %d planes exceed maximum %d", len(bytesUsed), MaxPlanes)
+	}
+	var planes [MaxPlanes]C.struct_v4l2_plane
+	for i, n := range bytesUsed {
+		planes[i].bytesused = C.uint(n)
+	}
+	var v4l2Buf C.struct_v4l2_buffer
+	v4l2Buf._type = C.uint(bufType)
+	v4l2Buf.memory = C.uint(ioType)
+	v4l2Buf.index = C.uint(index)
+	v4l2Buf.length = C.uint(len(bytesUsed))
+	*(**C.struct_v4l2_plane)(unsafe.Pointer(&v4l2Buf.m[0])) = &planes[0]
+
+	if err := send(fd, C.VIDIOC_QBUF, uintptr(unsafe.Pointer(&v4l2Buf))); err != nil {
+		return Buffer{}, fmt.Errorf("buffer queue: output planes: %w", err)
+	}
+	return makeBuffer(v4l2Buf), nil
+}
+
+// DequeueBufferPlanes dequeues a multi-planar buffer with numPlanes planes and returns it
+// along with its planes, which report the bytes used of each plane.
+// https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-qbuf.html
+func DequeueBufferPlanes(fd uintptr, ioType IOType, bufType BufType, numPlanes int) (Buffer, []Plane, error) {
+	var planes [MaxPlanes]C.struct_v4l2_plane
+	var v4l2Buf C.struct_v4l2_buffer
+	v4l2Buf._type = C.uint(bufType)
+	v4l2Buf.memory = C.uint(ioType)
+	v4l2Buf.length = C.uint(numPlanes)
+	*(**C.struct_v4l2_plane)(unsafe.Pointer(&v4l2Buf.m[0])) = &planes[0]
+
+	if err := send(fd, C.VIDIOC_DQBUF, uintptr(unsafe.Pointer(&v4l2Buf))); err != nil {
+		return Buffer{}, nil, fmt.Errorf("buffer dequeue: planes: %w", err)
+	}
+	return makeBuffer(v4l2Buf), makePlanes(planes[:v4l2Buf.length]), nil
+}
+
+func makePlanes(v4l2Planes []C.struct_v4l2_plane) []Plane {
+	planes := make([]Plane, len(v4l2Planes))
+	for i, p := range v4l2Planes {
+		planes[i] = Plane{
+			BytesUsed:  uint32(p.bytesused),
+			Length:     uint32(p.length),
+			Info:       PlaneInfo{MemOffset: *(*uint32)(unsafe.Pointer(&p.m[0]))},
+			DataOffset: uint32(p.data_offset),
+		}
+	}
+	return planes
+}
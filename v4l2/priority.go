@@ -0,0 +1,60 @@
+package v4l2
+
+// #include <linux/videodev2.h>
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Priority is the access priority of a file descriptor (enum v4l2_priority). When several
+// processes (or file descriptors) have the same device open, only those with the highest
+// priority among them can change the device state: ioctls setting formats, controls, inputs,
+// standards, frequencies and such fail with EBUSY (see ErrBusy) for the others. Priorities
+// are released when their file descriptor is closed.
+// See https://linuxtv.org/downloads/v4l-dvb-apis/userspace-api/v4l/vidioc-g-priority.html
+type Priority = uint32
+
+const (
+	// PriorityUnset is never returned, it can only be used in comparisons
+	PriorityUnset Priority = C.V4L2_PRIORITY_UNSET
+	// PriorityBackground is the lowest priority, for background processes (i.e. monitoring the
+	// device in the background) that never change the device state while others have it open
+	PriorityBackground Priority = C.V4L2_PRIORITY_BACKGROUND
+	// PriorityInteractive is the default priority, for applications controlled by a user
+	PriorityInteractive Priority = C.V4L2_PRIORITY_INTERACTIVE
+	PriorityDefault     Priority = C.V4L2_PRIORITY_DEFAULT
+	// PriorityRecord is the highest priority, for applications that must not be interrupted
+	// (i.e. a capture daemon). It can be held by a single file descriptor at a time.
+	PriorityRecord Priority = C.V4L2_PRIORITY_RECORD
+)
+
+var Priorities = map[Priority]string{
+	PriorityUnset:       "unset",
+	PriorityBackground:  "background",
+	PriorityInteractive: "interactive",
+	PriorityRecord:      "record",
+}
+
+// GetPriority returns the highest access priority of all the file descriptors opened on the
+// device (VIDIOC_G_PRIORITY), not necessarily the one of fd.
+// See https://linuxtv.org/downloads/v4l-dvb-apis/userspace-api/v4l/vidioc-g-priority.html
+func GetPriority(fd uintptr) (Priority, error) {
+	var prio C.enum_v4l2_priority
+	if err := send(fd, C.VIDIOC_G_PRIORITY, uintptr(unsafe.Pointer(&prio))); err != nil {
+		return 0, fmt.Errorf("priority: %w", err)
+	}
+	return Priority(prio), nil
+}
+
+// SetPriority sets the access priority of fd (VIDIOC_S_PRIORITY). It fails with EBUSY
+// (see ErrBusy) when requesting PriorityRecord while another file descriptor holds it.
+// See https://linuxtv.org/downloads/v4l-dvb-apis/userspace-api/v4l/vidioc-g-priority.html
+func SetPriority(fd uintptr, prio Priority) error {
+	cPrio := C.enum_v4l2_priority(prio)
+	if err := send(fd, C.VIDIOC_S_PRIORITY, uintptr(unsafe.Pointer(&cPrio))); err != nil {
+		return fmt.Errorf("set priority: %w", err)
+	}
+	return nil
+}
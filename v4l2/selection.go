@@ -0,0 +1,73 @@
+package v4l2
+
+/*
+#cgo linux CFLAGS: -I ${SRCDIR}/../include/
+#include <linux/videodev2.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// SelectionTarget (V4L2_SEL_TGT_*) identifies the rectangle accessed with the selection API.
+// The crop targets select the area of the source (i.e. the sensor) that is captured, while
+// the compose targets select the area of the output buffer the captured image is scaled into.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/v4l2-selection-targets.html
+// See https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/v4l2-common.h#L63
+type SelectionTarget = uint32
+
+const (
+	SelectionTargetCrop           SelectionTarget = C.V4L2_SEL_TGT_CROP
+	SelectionTargetCropDefault    SelectionTarget = C.V4L2_SEL_TGT_CROP_DEFAULT
+	SelectionTargetCropBounds     SelectionTarget = C.V4L2_SEL_TGT_CROP_BOUNDS
+	SelectionTargetNativeSize     SelectionTarget = C.V4L2_SEL_TGT_NATIVE_SIZE
+	SelectionTargetCompose        SelectionTarget = C.V4L2_SEL_TGT_COMPOSE
+	SelectionTargetComposeDefault SelectionTarget = C.V4L2_SEL_TGT_COMPOSE_DEFAULT
+	SelectionTargetComposeBounds  SelectionTarget = C.V4L2_SEL_TGT_COMPOSE_BOUNDS
+	SelectionTargetComposePadded  SelectionTarget = C.V4L2_SEL_TGT_COMPOSE_PADDED
+)
+
+// SelectionFlag (V4L2_SEL_FLAG_*) constrains how the driver may adjust a requested selection rectangle.
+// With SelectionFlagGE the adjusted rectangle must contain the requested one, with SelectionFlagLE
+// it must be contained by it (both flags require the exact rectangle). The driver fails with ERANGE
+// when the constraints cannot be met, instead of silently adjusting the rectangle.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/v4l2-selection-flags.html
+type SelectionFlag = uint32
+
+const (
+	SelectionFlagGE         SelectionFlag = C.V4L2_SEL_FLAG_GE
+	SelectionFlagLE         SelectionFlag = C.V4L2_SEL_FLAG_LE
+	SelectionFlagKeepConfig SelectionFlag = C.V4L2_SEL_FLAG_KEEP_CONFIG
+)
+
+// GetSelection retrieves the rectangle for the selection target (see v4l2_selection).
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-selection.html
+func GetSelection(fd uintptr, bufType BufType, target SelectionTarget) (Rect, error) {
+	var sel C.struct_v4l2_selection
+	sel._type = C.uint(bufType)
+	sel.target = C.uint(target)
+
+	if err := send(fd, C.VIDIOC_G_SELECTION, uintptr(unsafe.Pointer(&sel))); err != nil {
+		return Rect{}, fmt.Errorf("get selection: target %d: %w", target, err)
+	}
+	return *(*Rect)(unsafe.Pointer(&sel.r)), nil
+}
+
+// SetSelection sets the rectangle for the selection target and returns the
+// rectangle as adjusted by the driver. The flags (see SelectionFlag) constrain the
+// adjustment, zero lets the driver pick the closest rectangle.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-selection.html
+func SetSelection(fd uintptr, bufType BufType, target SelectionTarget, r Rect, flags SelectionFlag) (Rect, error) {
+	var sel C.struct_v4l2_selection
+	sel._type = C.uint(bufType)
+	sel.target = C.uint(target)
+	sel.flags = C.uint(flags)
+	sel.r = *(*C.struct_v4l2_rect)(unsafe.Pointer(&r))
+
+	if err := send(fd, C.VIDIOC_S_SELECTION, uintptr(unsafe.Pointer(&sel))); err != nil {
+		return Rect{}, fmt.Errorf("set selection: target %d: %w", target, err)
+	}
+	return *(*Rect)(unsafe.Pointer(&sel.r)), nil
+}
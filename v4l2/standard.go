@@ -0,0 +1,109 @@
+package v4l2
+
+// #include <linux/videodev2.h>
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// StdID (v4l2_std_id) is a set of analog video standards, each bit identifying a standard.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-enumstd.html#v4l2-std-id
+type StdID = StandardId
+
+const (
+	StdUnknown StdID = C.V4L2_STD_UNKNOWN
+	StdPAL     StdID = C.V4L2_STD_PAL
+	StdPALM    StdID = C.V4L2_STD_PAL_M
+	StdPALN    StdID = C.V4L2_STD_PAL_N
+	StdPALNc   StdID = C.V4L2_STD_PAL_Nc
+	StdPAL60   StdID = C.V4L2_STD_PAL_60
+	StdNTSC    StdID = C.V4L2_STD_NTSC
+	StdNTSCM   StdID = C.V4L2_STD_NTSC_M
+	StdNTSCMJP StdID = C.V4L2_STD_NTSC_M_JP
+	StdNTSC443 StdID = C.V4L2_STD_NTSC_443
+	StdSECAM   StdID = C.V4L2_STD_SECAM
+	Std525_60  StdID = C.V4L2_STD_525_60
+	Std625_50  StdID = C.V4L2_STD_625_50
+	StdAll     StdID = C.V4L2_STD_ALL
+)
+
+// StandardInfo (v4l2_standard) describes an analog video standard supported by the current
+// input: its frame period (i.e. 1/25 for PAL, 1001/30000 for NTSC) and its number of lines
+// per frame (625 or 525).
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-enumstd.html#c.V4L.v4l2_standard
+type StandardInfo struct {
+	Index       uint32
+	ID          StdID
+	Name        string
+	FramePeriod Fract
+	FrameLines  uint32
+}
+
+// GetStandardInfo returns the information of the standard at index (VIDIOC_ENUMSTD).
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-enumstd.html
+func GetStandardInfo(fd uintptr, index uint32) (StandardInfo, error) {
+	var std C.struct_v4l2_standard
+	std.index = C.uint(index)
+	if err := send(fd, C.VIDIOC_ENUMSTD, uintptr(unsafe.Pointer(&std))); err != nil {
+		return StandardInfo{}, fmt.Errorf("standard info: index %d: %w", index, err)
+	}
+	return StandardInfo{
+		Index:       uint32(std.index),
+		ID:          StdID(std.id),
+		Name:        C.GoString((*C.char)(unsafe.Pointer(&std.name[0]))),
+		FramePeriod: Fract{Numerator: uint32(std.frameperiod.numerator), Denominator: uint32(std.frameperiod.denominator)},
+		FrameLines:  uint32(std.framelines),
+	}, nil
+}
+
+// GetAllStandards returns the standards supported by the current input, by iterating from
+// index 0 until the driver returns EINVAL. Inputs that are not analog (i.e. cameras) support
+// no standard: an empty list is returned.
+func GetAllStandards(fd uintptr) ([]StandardInfo, error) {
+	var result []StandardInfo
+	for index := uint32(0); ; index++ {
+		std, err := GetStandardInfo(fd, index)
+		if err != nil {
+			if errors.Is(err, ErrorBadArgument) || errors.Is(err, ErrorUnsupported) {
+				return result, nil
+			}
+			return result, fmt.Errorf("all standards: %w", err)
+		}
+		result = append(result, std)
+	}
+}
+
+// GetStandard returns the standard selected on the current input (VIDIOC_G_STD).
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-std.html
+func GetStandard(fd uintptr) (StdID, error) {
+	var id C.v4l2_std_id
+	if err := send(fd, C.VIDIOC_G_STD, uintptr(unsafe.Pointer(&id))); err != nil {
+		return StdUnknown, fmt.Errorf("standard get: %w", err)
+	}
+	return StdID(id), nil
+}
+
+// SetStandard selects the standard of the current input (VIDIOC_S_STD), the driver selects
+// one of the standards if id holds several (i.e. StdPAL).
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-std.html
+func SetStandard(fd uintptr, id StdID) error {
+	std := C.v4l2_std_id(id)
+	if err := send(fd, C.VIDIOC_S_STD, uintptr(unsafe.Pointer(&std))); err != nil {
+		return fmt.Errorf("standard set: %#x: %w", id, err)
+	}
+	return nil
+}
+
+// QueryStandard asks the driver to detect the standard of the signal received on the
+// current input (VIDIOC_QUERYSTD), it returns StdUnknown without signal.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-querystd.html
+func QueryStandard(fd uintptr) (StdID, error) {
+	var id C.v4l2_std_id
+	if err := send(fd, C.VIDIOC_QUERYSTD, uintptr(unsafe.Pointer(&id))); err != nil {
+		return StdUnknown, fmt.Errorf("standard query: %w", err)
+	}
+	return StdID(id), nil
+}
@@ -51,6 +51,36 @@ type OutputParam struct {
 	_            [4]uint32
 }
 
+// TimePerFrame returns the frame interval, in seconds, as the exact fraction reported by the
+// driver (i.e. 1001/30000 for 29.97 fps), from the output parameters for output buffer types
+// and from the capture parameters otherwise.
+func (p StreamParam) TimePerFrame() Fract {
+	if isOutputBufType(p.Type) {
+		return p.Output.TimePerFrame
+	}
+	return p.Capture.TimePerFrame
+}
+
+// FPS returns the frame rate, in frames per second, of the frame interval (see TimePerFrame).
+// It returns 0 when the driver reports no interval.
+func (p StreamParam) FPS() float64 {
+	return fractFPS(p.TimePerFrame())
+}
+
+// Capability returns the capability flags of the capture (or output) parameters
+func (p StreamParam) Capability() StreamParamFlag {
+	if isOutputBufType(p.Type) {
+		return p.Output.Capability
+	}
+	return p.Capture.Capability
+}
+
+// IsTimePerFrameSupported returns true if the driver supports setting the frame interval
+// (V4L2_CAP_TIMEPERFRAME), otherwise the interval set with SetStreamParam is ignored.
+func (p StreamParam) IsTimePerFrameSupported() bool {
+	return p.Capability()&StreamParamTimePerFrame != 0
+}
+
 // GetStreamParam returns streaming parameters for the driver (v4l2_streamparm).
 // https://linuxtv.org/downloads/v4l-dvb-apis/userspace-api/v4l/vidioc-g-parm.html
 // See https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/videodev2.h#L2362
@@ -62,26 +92,27 @@ func GetStreamParam(fd uintptr, bufType BufType) (StreamParam, error) {
 		return StreamParam{}, fmt.Errorf("stream param: %w", err)
 	}
 
-	capture := *(*CaptureParam)(unsafe.Pointer(&v4l2Param.parm[0]))
-	output := *(*OutputParam)(unsafe.Pointer(uintptr(unsafe.Pointer(&v4l2Param.parm[0])) + unsafe.Sizeof(C.struct_v4l2_captureparm{})))
-
-	return StreamParam{
-		Type:    BufTypeVideoCapture,
-		Capture: capture,
-		Output:  output,
-	}, nil
+	// parm is a union, the capture or output parameters (depending on the buffer type) share its storage
+	param := StreamParam{Type: bufType}
+	if isOutputBufType(bufType) {
+		param.Output = *(*OutputParam)(unsafe.Pointer(&v4l2Param.parm[0]))
+	} else {
+		param.Capture = *(*CaptureParam)(unsafe.Pointer(&v4l2Param.parm[0]))
+	}
+	return param, nil
 }
 
+// SetStreamParam sets the streaming parameters of the driver (VIDIOC_S_PARM), the capture
+// parameters for capture buffer types and the output parameters for output buffer types.
+// https://linuxtv.org/downloads/v4l-dvb-apis/userspace-api/v4l/vidioc-g-parm.html
 func SetStreamParam(fd uintptr, bufType BufType, param StreamParam) error {
 	var v4l2Parm C.struct_v4l2_streamparm
 	v4l2Parm._type = C.uint(bufType)
-	if bufType == BufTypeVideoCapture {
+	if isOutputBufType(bufType) {
+		*(*C.struct_v4l2_outputparm)(unsafe.Pointer(&v4l2Parm.parm[0])) = *(*C.struct_v4l2_outputparm)(unsafe.Pointer(&param.Output))
+	} else {
 		*(*C.struct_v4l2_captureparm)(unsafe.Pointer(&v4l2Parm.parm[0])) = *(*C.struct_v4l2_captureparm)(unsafe.Pointer(&param.Capture))
 	}
-	if bufType == BufTypeVideoOutput {
-		*(*C.struct_v4l2_outputparm)(unsafe.Pointer(uintptr(unsafe.Pointer(&v4l2Parm.parm[0])) + unsafe.Sizeof(v4l2Parm.parm[0]))) =
-			*(*C.struct_v4l2_outputparm)(unsafe.Pointer(&param.Output))
-	}
 
 	if err := send(fd, C.VIDIOC_S_PARM, uintptr(unsafe.Pointer(&v4l2Parm))); err != nil {
 		return fmt.Errorf("stream param: %w", err)
@@ -89,3 +120,8 @@ func SetStreamParam(fd uintptr, bufType BufType, param StreamParam) error {
 
 	return nil
 }
+
+// isOutputBufType returns true for the video output buffer types, which use the output parameters
+func isOutputBufType(bufType BufType) bool {
+	return bufType == BufTypeVideoOutput || bufType == BufTypeVideoOutputMPlane
+}
@@ -0,0 +1,24 @@
+package v4l2
+
+import "testing"
+
+func TestStreamParamTimePerFrame(t *testing.T) {
+	param := StreamParam{
+		Type:    BufTypeVideoCapture,
+		Capture: CaptureParam{Capability: StreamParamTimePerFrame, TimePerFrame: Fract{Numerator: 1001, Denominator: 30000}},
+	}
+	if interval := param.TimePerFrame(); interval != (Fract{Numerator: 1001, Denominator: 30000}) {
+		t.Errorf("unexpected interval: %v", interval)
+	}
+	if fps := param.FPS(); fps < 29.97 || fps > 29.98 {
+		t.Errorf("unexpected fps: %f", fps)
+	}
+	if !param.IsTimePerFrameSupported() {
+		t.Error("expecting time per frame support")
+	}
+
+	param.Type = BufTypeVideoOutput
+	if param.IsTimePerFrameSupported() || param.FPS() != 0 {
+		t.Error("expecting output parameters for an output buffer type")
+	}
+}
@@ -4,6 +4,7 @@ package v4l2
 import "C"
 
 import (
+	"errors"
 	"fmt"
 	"unsafe"
 
@@ -22,6 +23,9 @@ const (
 	BufTypeVideoCapture BufType = C.V4L2_BUF_TYPE_VIDEO_CAPTURE
 	BufTypeVideoOutput  BufType = C.V4L2_BUF_TYPE_VIDEO_OUTPUT
 	BufTypeOverlay      BufType = C.V4L2_BUF_TYPE_VIDEO_OVERLAY
+
+	BufTypeVideoCaptureMPlane BufType = C.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
+	BufTypeVideoOutputMPlane  BufType = C.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
 )
 
 // IOType (v4l2_memory)
@@ -34,6 +38,11 @@ const (
 	IOTypeUserPtr IOType = C.V4L2_MEMORY_USERPTR
 	IOTypeOverlay IOType = C.V4L2_MEMORY_OVERLAY
 	IOTypeDMABuf  IOType = C.V4L2_MEMORY_DMABUF
+
+	// IOTypeReadWrite selects the read/write IO method (see CapReadWrite) where frames are
+	// read from the device file. It is not a v4l2_memory value and is never sent to the driver.
+	// https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/rw.html
+	IOTypeReadWrite IOType = 0xFFFFFFFF
 )
 
 type BufFlag = uint32
@@ -65,6 +74,20 @@ const (
 
 // TODO implement vl42_create_buffers
 
+// BufCap (V4L2_BUF_CAP_*) are the buffer capabilities reported in RequestBuffers.Capabilities
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-reqbufs.html#v4l2-buf-capabilities
+type BufCap = uint32
+
+const (
+	BufCapSupportsMMAP              BufCap = C.V4L2_BUF_CAP_SUPPORTS_MMAP
+	BufCapSupportsUserPtr           BufCap = C.V4L2_BUF_CAP_SUPPORTS_USERPTR
+	BufCapSupportsDMABuf            BufCap = C.V4L2_BUF_CAP_SUPPORTS_DMABUF
+	BufCapSupportsRequests          BufCap = C.V4L2_BUF_CAP_SUPPORTS_REQUESTS
+	BufCapSupportsOrphanedBufs      BufCap = C.V4L2_BUF_CAP_SUPPORTS_ORPHANED_BUFS
+	BufCapSupportsM2MHoldCaptureBuf BufCap = C.V4L2_BUF_CAP_SUPPORTS_M2M_HOLD_CAPTURE_BUF
+	BufCapSupportsMMAPCacheHints    BufCap = C.V4L2_BUF_CAP_SUPPORTS_MMAP_CACHE_HINTS
+)
+
 // RequestBuffers (v4l2_requestbuffers) is used to request buffer allocation initializing
 // streaming for memory mapped, user pointer, or DMA buffer access.
 // https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/videodev2.h#L949
@@ -148,7 +171,7 @@ type PlaneInfo struct {
 // StreamOn requests streaming to be turned on for
 // capture (or output) that uses memory map, user ptr, or DMA buffers.
 // https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-streamon.html
-func StreamOn(dev StreamingDevice) error {
+func StreamOn(dev BufferQueue) error {
 	bufType := dev.BufferType()
 	if err := send(dev.Fd(), C.VIDIOC_STREAMON, uintptr(unsafe.Pointer(&bufType))); err != nil {
 		return fmt.Errorf("stream on: %w", err)
@@ -159,7 +182,7 @@ func StreamOn(dev StreamingDevice) error {
 // StreamOff requests streaming to be turned off for
 // capture (or output) that uses memory map, user ptr, or DMA buffers.
 // https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-streamon.html
-func StreamOff(dev StreamingDevice) error {
+func StreamOff(dev BufferQueue) error {
 	bufType := dev.BufferType()
 	if err := send(dev.Fd(), C.VIDIOC_STREAMOFF, uintptr(unsafe.Pointer(&bufType))); err != nil {
 		return fmt.Errorf("stream off: %w", err)
@@ -169,9 +192,10 @@ func StreamOff(dev StreamingDevice) error {
 
 // InitBuffers sends buffer allocation request (VIDIOC_REQBUFS) to initialize buffer IO
 // for video capture or video output when using either mem map, user pointer, or DMA buffers.
+// It returns ErrStreamingUnsupported if the driver rejects the memory type (EINVAL) or grants no buffers.
 // See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-reqbufs.html#vidioc-reqbufs
-func InitBuffers(dev StreamingDevice) (RequestBuffers, error) {
-	if dev.MemIOType() != IOTypeMMAP && dev.MemIOType() != IOTypeDMABuf {
+func InitBuffers(dev BufferQueue) (RequestBuffers, error) {
+	if dev.MemIOType() != IOTypeMMAP && dev.MemIOType() != IOTypeDMABuf && dev.MemIOType() != IOTypeUserPtr {
 		return RequestBuffers{}, fmt.Errorf("request buffers: %w", ErrorUnsupported)
 	}
 	var req C.struct_v4l2_requestbuffers
@@ -180,17 +204,71 @@ func InitBuffers(dev StreamingDevice) (RequestBuffers, error) {
 	req.memory = C.uint(dev.MemIOType())
 
 	if err := send(dev.Fd(), C.VIDIOC_REQBUFS, uintptr(unsafe.Pointer(&req))); err != nil {
+		if errors.Is(err, ErrorBadArgument) {
+			var errno sys.Errno
+			if errors.As(err, &errno) {
+				return RequestBuffers{}, fmt.Errorf("request buffers: %w", errnoError{errno: errno, kind: ErrStreamingUnsupported})
+			}
+			return RequestBuffers{}, fmt.Errorf("request buffers: %w: %v", ErrStreamingUnsupported, err)
+		}
 		return RequestBuffers{}, fmt.Errorf("request buffers: %w: type not supported", err)
 	}
 
+	// a zero count means the driver could not allocate buffers for the IO type
+	if req.count == 0 {
+		return RequestBuffers{}, fmt.Errorf("request buffers: %w: no buffers granted", ErrStreamingUnsupported)
+	}
+
 	return *(*RequestBuffers)(unsafe.Pointer(&req)), nil
 }
 
+// GetSupportedMemoryTypes returns the streaming memory types (IOTypeMMAP, IOTypeUserPtr,
+// IOTypeDMABuf) supported for the buffer type. It requests zero buffers (which allocates
+// nothing) and reads the buffer capabilities reported by the driver. For drivers that do
+// not report capabilities (before Linux 4.20), each memory type is probed instead.
+// It must not be called while streaming since requesting zero buffers frees them.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-reqbufs.html
+func GetSupportedMemoryTypes(fd uintptr, bufType BufType) ([]IOType, error) {
+	ioTypes := []IOType{IOTypeMMAP, IOTypeUserPtr, IOTypeDMABuf}
+	bufCaps := []BufCap{BufCapSupportsMMAP, BufCapSupportsUserPtr, BufCapSupportsDMABuf}
+
+	probe := func(ioType IOType) (RequestBuffers, error) {
+		var req C.struct_v4l2_requestbuffers
+		req.count = 0
+		req._type = C.uint(bufType)
+		req.memory = C.uint(ioType)
+		err := send(fd, C.VIDIOC_REQBUFS, uintptr(unsafe.Pointer(&req)))
+		return *(*RequestBuffers)(unsafe.Pointer(&req)), err
+	}
+
+	var result []IOType
+	req, err := probe(IOTypeMMAP)
+	if err == nil && req.Capabilities != 0 {
+		for i, ioType := range ioTypes {
+			if req.Capabilities&bufCaps[i] != 0 {
+				result = append(result, ioType)
+			}
+		}
+		return result, nil
+	}
+
+	for _, ioType := range ioTypes {
+		if _, err := probe(ioType); err != nil {
+			if errors.Is(err, ErrorBadArgument) {
+				continue
+			}
+			return result, fmt.Errorf("supported memory types: %w", err)
+		}
+		result = append(result, ioType)
+	}
+	return result, nil
+}
+
 // ResetBuffers allocates a buffer of size 0 VIDIOC_REQBUFS(0) to free (or orphan) all
 // buffers. Useful when shuttingdown the stream.
 // See https://linuxtv.org/downloads/v4l-dvb-apis-new/userspace-api/v4l/vidioc-reqbufs.html
-func ResetBuffers(dev StreamingDevice) (RequestBuffers, error) {
-	if dev.MemIOType() != IOTypeMMAP && dev.MemIOType() != IOTypeDMABuf {
+func ResetBuffers(dev BufferQueue) (RequestBuffers, error) {
+	if dev.MemIOType() != IOTypeMMAP && dev.MemIOType() != IOTypeDMABuf && dev.MemIOType() != IOTypeUserPtr {
 		return RequestBuffers{}, fmt.Errorf("reset buffers: %w", ErrorUnsupported)
 	}
 	var req C.struct_v4l2_requestbuffers
@@ -207,7 +285,7 @@ func ResetBuffers(dev StreamingDevice) (RequestBuffers, error) {
 
 // GetBuffer retrieves buffer info for allocated buffers at provided index.
 // This call should take place after buffers are allocated with RequestBuffers (for mmap for instance).
-func GetBuffer(dev StreamingDevice, index uint32) (Buffer, error) {
+func GetBuffer(dev BufferQueue, index uint32) (Buffer, error) {
 	var v4l2Buf C.struct_v4l2_buffer
 	v4l2Buf._type = C.uint(dev.BufferType())
 	v4l2Buf.memory = C.uint(dev.MemIOType())
@@ -230,7 +308,7 @@ func mapMemoryBuffer(fd uintptr, offset int64, len int) ([]byte, error) {
 }
 
 // MapMemoryBuffers creates mapped memory buffers for specified buffer count of device.
-func MapMemoryBuffers(dev StreamingDevice) ([][]byte, error) {
+func MapMemoryBuffers(dev BufferQueue) ([][]byte, error) {
 	bufCount := int(dev.BufferCount())
 	buffers := make([][]byte, bufCount)
 	for i := 0; i < bufCount; i++ {
@@ -290,6 +368,96 @@ func QueueBuffer(fd uintptr, ioType IOType, bufType BufType, index uint32) (Buff
 	return makeBuffer(v4l2Buf), nil
 }
 
+// QueueOutputBuffer enqueues a buffer filled with bytesUsed bytes of data for a video output
+// device, when using memory map or user pointer buffers.
+// https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-qbuf.html#vidioc-qbuf
+func QueueOutputBuffer(fd uintptr, ioType IOType, bufType BufType, index, bytesUsed uint32) (Buffer, error) {
+	var v4l2Buf C.struct_v4l2_buffer
+	v4l2Buf._type = C.uint(bufType)
+	v4l2Buf.memory = C.uint(ioType)
+	v4l2Buf.index = C.uint(index)
+	v4l2Buf.bytesused = C.uint(bytesUsed)
+
+	if err := send(fd, C.VIDIOC_QBUF, uintptr(unsafe.Pointer(&v4l2Buf))); err != nil {
+		return Buffer{}, fmt.Errorf("buffer queue: output: %w", err)
+	}
+
+	return makeBuffer(v4l2Buf), nil
+}
+
+// QueueDMABuffer enqueues an imported DMA buffer (IOTypeDMABuf), identified by its dma-buf
+// file descriptor, at the specified buffer index. The flags can carry cache hints
+// (BufFlagNoCacheInvalidate, BufFlagNoCacheClean) to skip cache synchronization.
+// https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/dmabuf.html
+func QueueDMABuffer(fd uintptr, bufType BufType, index uint32, dmabufFd int, flags BufFlag) (Buffer, error) {
+	var v4l2Buf C.struct_v4l2_buffer
+	v4l2Buf._type = C.uint(bufType)
+	v4l2Buf.memory = C.uint(IOTypeDMABuf)
+	v4l2Buf.index = C.uint(index)
+	v4l2Buf.flags = C.uint(flags)
+	*(*C.int)(unsafe.Pointer(&v4l2Buf.m[0])) = C.int(dmabufFd)
+
+	if err := send(fd, C.VIDIOC_QBUF, uintptr(unsafe.Pointer(&v4l2Buf))); err != nil {
+		return Buffer{}, fmt.Errorf("buffer queue: dmabuf %d: %w", dmabufFd, err)
+	}
+
+	return makeBuffer(v4l2Buf), nil
+}
+
+// QueueUserBuffer enqueues the user allocated memory buf (IOTypeUserPtr) at the specified
+// buffer index. The driver captures directly into buf, which must stay allocated, and must
+// not be accessed, until the buffer is dequeued (see ValidateUserBuffer).
+// https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/userp.html
+func QueueUserBuffer(fd uintptr, bufType BufType, index uint32, buf []byte) (Buffer, error) {
+	if len(buf) == 0 {
+		return Buffer{}, fmt.Errorf("buffer queue: userptr: %w", ErrorBadArgument)
+	}
+	var v4l2Buf C.struct_v4l2_buffer
+	v4l2Buf._type = C.uint(bufType)
+	v4l2Buf.memory = C.uint(IOTypeUserPtr)
+	v4l2Buf.index = C.uint(index)
+	v4l2Buf.length = C.uint(len(buf))
+	*(*uintptr)(unsafe.Pointer(&v4l2Buf.m[0])) = uintptr(unsafe.Pointer(&buf[0]))
+
+	if err := send(fd, C.VIDIOC_QBUF, uintptr(unsafe.Pointer(&v4l2Buf))); err != nil {
+		return Buffer{}, fmt.Errorf("buffer queue: userptr: %w", err)
+	}
+
+	return makeBuffer(v4l2Buf), nil
+}
+
+// ValidateUserBuffer checks that the user allocated memory buf can back a device buffer
+// (IOTypeUserPtr) holding images of sizeImage bytes: it must be large enough and, as most
+// drivers require, page aligned (i.e. allocated with unix.Mmap).
+func ValidateUserBuffer(buf []byte, sizeImage uint32) error {
+	if len(buf) == 0 || len(buf) < int(sizeImage) {
+		return fmt.Errorf("user buffer: size %d less than image size %d: %w", len(buf), sizeImage, ErrorBadArgument)
+	}
+	pageSize := uintptr(sys.Getpagesize())
+	if addr := uintptr(unsafe.Pointer(&buf[0])); addr%pageSize != 0 {
+		return fmt.Errorf("user buffer: address %#x not aligned to page size %d: %w", addr, pageSize, ErrorBadArgument)
+	}
+	return nil
+}
+
+// ExportBuffer exports the plane (0 for single-planar buffer types) of the device buffer at
+// index as a dma-buf file descriptor (VIDIOC_EXPBUF), i.e. to share memory mapped buffers with
+// a GPU or an encoder without copying. The buffers must have been allocated with IOTypeMMAP.
+// The returned descriptor is owned by the caller, which must close it.
+// https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-expbuf.html
+func ExportBuffer(fd uintptr, bufType BufType, index, plane uint32) (int, error) {
+	var expBuf C.struct_v4l2_exportbuffer
+	expBuf._type = C.uint(bufType)
+	expBuf.index = C.uint(index)
+	expBuf.plane = C.uint(plane)
+	expBuf.flags = C.uint(sys.O_CLOEXEC | sys.O_RDWR)
+
+	if err := send(fd, C.VIDIOC_EXPBUF, uintptr(unsafe.Pointer(&expBuf))); err != nil {
+		return -1, fmt.Errorf("export buffer: index %d: plane %d: %w", index, plane, err)
+	}
+	return int(expBuf.fd), nil
+}
+
 // DequeueBuffer dequeues a buffer in the device driver, marking it as consumed by the application,
 // when using either memory map, user pointer, or DMA buffers. Buffer is returned with
 // additional information about the dequeued buffer.
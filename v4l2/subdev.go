@@ -0,0 +1,127 @@
+package v4l2
+
+// #include <linux/v4l2-subdev.h>
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// SubdevFormatWhence (v4l2_subdev_format_whence) selects whether the try (negotiation only)
+// or the active (applied to the device) format of a sub-device pad is accessed.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-subdev-g-fmt.html
+type SubdevFormatWhence = uint32
+
+const (
+	SubdevFormatTry    SubdevFormatWhence = C.V4L2_SUBDEV_FORMAT_TRY
+	SubdevFormatActive SubdevFormatWhence = C.V4L2_SUBDEV_FORMAT_ACTIVE
+)
+
+// MbusFramefmt (v4l2_mbus_framefmt) is the format of the image transmitted on a media bus
+// between two entities (i.e. from a sensor to the CSI-2 receiver). Code is a media bus
+// format code (MEDIA_BUS_FMT_*).
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/subdev-formats.html
+// See https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/v4l2-mediabus.h
+type MbusFramefmt struct {
+	Width        uint32
+	Height       uint32
+	Code         uint32
+	Field        FieldType
+	Colorspace   ColorspaceType
+	YcbcrEnc     uint16
+	Quantization uint16
+	XferFunc     uint16
+	Flags        uint16
+	_            [10]uint16
+}
+
+// SensorMode is a readout mode of an image sensor: a media bus code along with
+// a frame size supported for that code.
+type SensorMode struct {
+	Width    uint32
+	Height   uint32
+	MbusCode uint32
+}
+
+func (m SensorMode) String() string {
+	return fmt.Sprintf("%dx%d [mbus code: 0x%04x]", m.Width, m.Height, m.MbusCode)
+}
+
+// GetSubdevFormat retrieves the media bus format of the sub-device (/dev/v4l-subdevX) pad
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-subdev-g-fmt.html
+func GetSubdevFormat(fd uintptr, pad uint32, which SubdevFormatWhence) (MbusFramefmt, error) {
+	var format C.struct_v4l2_subdev_format
+	format.which = C.uint(which)
+	format.pad = C.uint(pad)
+
+	if err := send(fd, C.VIDIOC_SUBDEV_G_FMT, uintptr(unsafe.Pointer(&format))); err != nil {
+		return MbusFramefmt{}, fmt.Errorf("subdev format: pad %d: %w", pad, err)
+	}
+	return *(*MbusFramefmt)(unsafe.Pointer(&format.format)), nil
+}
+
+// SetSubdevFormat sets the media bus format of the sub-device pad and returns the
+// format as adjusted by the driver.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-subdev-g-fmt.html
+func SetSubdevFormat(fd uintptr, pad uint32, which SubdevFormatWhence, mbusFmt MbusFramefmt) (MbusFramefmt, error) {
+	var format C.struct_v4l2_subdev_format
+	format.which = C.uint(which)
+	format.pad = C.uint(pad)
+	format.format = *(*C.struct_v4l2_mbus_framefmt)(unsafe.Pointer(&mbusFmt))
+
+	if err := send(fd, C.VIDIOC_SUBDEV_S_FMT, uintptr(unsafe.Pointer(&format))); err != nil {
+		return MbusFramefmt{}, fmt.Errorf("subdev set format: pad %d: %w", pad, err)
+	}
+	return *(*MbusFramefmt)(unsafe.Pointer(&format.format)), nil
+}
+
+// GetSubdevMbusCodes returns the media bus codes supported on the sub-device pad
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-subdev-enum-mbus-code.html
+func GetSubdevMbusCodes(fd uintptr, pad uint32) ([]uint32, error) {
+	var codes []uint32
+	for index := uint32(0); ; index++ {
+		var enum C.struct_v4l2_subdev_mbus_code_enum
+		enum.pad = C.uint(pad)
+		enum.index = C.uint(index)
+		enum.which = C.uint(SubdevFormatActive)
+
+		if err := send(fd, C.VIDIOC_SUBDEV_ENUM_MBUS_CODE, uintptr(unsafe.Pointer(&enum))); err != nil {
+			if errors.Is(err, ErrorBadArgument) && len(codes) > 0 {
+				break
+			}
+			return codes, fmt.Errorf("subdev mbus codes: pad %d: %w", pad, err)
+		}
+		codes = append(codes, uint32(enum.code))
+	}
+	return codes, nil
+}
+
+// GetSubdevFrameSizes returns the frame size ranges supported on the sub-device pad for
+// the media bus code. Sensors typically report discrete sizes (min and max are equal).
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-subdev-enum-frame-size.html
+func GetSubdevFrameSizes(fd uintptr, pad uint32, code uint32) ([]FrameSize, error) {
+	var sizes []FrameSize
+	for index := uint32(0); ; index++ {
+		var enum C.struct_v4l2_subdev_frame_size_enum
+		enum.index = C.uint(index)
+		enum.pad = C.uint(pad)
+		enum.code = C.uint(code)
+		enum.which = C.uint(SubdevFormatActive)
+
+		if err := send(fd, C.VIDIOC_SUBDEV_ENUM_FRAME_SIZE, uintptr(unsafe.Pointer(&enum))); err != nil {
+			if errors.Is(err, ErrorBadArgument) && len(sizes) > 0 {
+				break
+			}
+			return sizes, fmt.Errorf("subdev frame sizes: pad %d: code 0x%04x: %w", pad, code, err)
+		}
+		sizes = append(sizes, FrameSize{
+			MinWidth:  uint32(enum.min_width),
+			MaxWidth:  uint32(enum.max_width),
+			MinHeight: uint32(enum.min_height),
+			MaxHeight: uint32(enum.max_height),
+		})
+	}
+	return sizes, nil
+}
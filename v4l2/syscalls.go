@@ -53,6 +53,22 @@ func closeDev(fd uintptr) error {
 	return sys.Close(int(fd))
 }
 
+// ReadDevice reads frame data from a device that supports the read/write IO method.
+// The read is retried if interrupted.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/func-read.html
+func ReadDevice(fd uintptr, buf []byte) (int, error) {
+	for {
+		n, err := sys.Read(int(fd), buf)
+		if errors.Is(err, sys.EINTR) {
+			continue
+		}
+		if errno, ok := err.(sys.Errno); ok {
+			return n, errnoErr(errno)
+		}
+		return n, err
+	}
+}
+
 // ioctl is a wrapper for Syscall(SYS_IOCTL)
 func ioctl(fd, req, arg uintptr) (err sys.Errno) {
 	for {
@@ -74,35 +90,40 @@ func send(fd, req, arg uintptr) error {
 	if errno == 0 {
 		return nil
 	}
-	parsedErr := parseErrorType(errno)
-	switch parsedErr {
-	case ErrorUnsupported, ErrorSystem, ErrorBadArgument:
-		return parsedErr
-	case ErrorTimeout, ErrorTemporary:
-		// TODO add code for automatic retry/recovery
-		return errno
-	default:
-		return errno
-	}
+	return errnoErr(errno)
 }
 
 // WaitForRead returns a channel that can be used to be notified when
 // a device's is ready to be read.
 func WaitForRead(dev Device) <-chan struct{} {
+	return WaitForReadDone(dev, nil)
+}
+
+// WaitForReadDone is WaitForRead with a done channel: the notifying goroutine exits, closing
+// the returned channel, when done is closed or the device descriptor becomes invalid.
+func WaitForReadDone(dev Device, done <-chan struct{}) <-chan struct{} {
 	sigChan := make(chan struct{})
 
 	go func(fd uintptr) {
 		defer close(sigChan)
-		var fdsRead sys.FdSet
-		fdsRead.Set(int(fd))
-		tv := sys.Timeval{Sec: 2, Usec: 0}
 		for {
+			// select updates both the set and the timeout, they are reset for each call
+			var fdsRead sys.FdSet
+			fdsRead.Set(int(fd))
+			tv := sys.Timeval{Sec: 2, Usec: 0}
 			_, errno := sys.Select(int(fd+1), &fdsRead, nil, nil, &tv)
 			if errno == sys.EINTR {
 				continue
 			}
+			if errno == sys.EBADF {
+				return
+			}
 
-			sigChan <- struct{}{}
+			select {
+			case sigChan <- struct{}{}:
+			case <-done:
+				return
+			}
 		}
 	}(dev.Fd())
 
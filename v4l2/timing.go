@@ -3,6 +3,8 @@ package v4l2
 // #include <linux/videodev2.h>
 import "C"
 
+import "fmt"
+
 // TimecodeType
 // https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/buffer.html?highlight=v4l2_timecode#timecode-type
 // https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/videodev2.h#L886
@@ -38,3 +40,13 @@ type Timecode struct {
 	Hours   uint8
 	_       [4]uint8 // userbits
 }
+
+// String returns the timecode formatted as HH:MM:SS:FF, using a semicolon before
+// the frame count for drop-frame timecodes (HH:MM:SS;FF).
+func (t Timecode) String() string {
+	sep := ":"
+	if t.Flags&TimecodeFlagDropFrame != 0 {
+		sep = ";"
+	}
+	return fmt.Sprintf("%02d:%02d:%02d%s%02d", t.Hours, t.Minutes, t.Seconds, sep, t.Frames)
+}
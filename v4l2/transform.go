@@ -0,0 +1,69 @@
+package v4l2
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// CenterCropResize center-crops img to a square of its shorter dimension, then resizes
+// the square to size x size. When downscaling, each output pixel is the average of the
+// source pixels it covers, which avoids the aliasing of nearest-neighbor sampling. This is
+// the usual preprocessing of images fed to vision models. It returns nil if size is not positive.
+func CenterCropResize(img image.Image, size int) image.Image {
+	if size <= 0 {
+		return nil
+	}
+	bounds := img.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-side)/2,
+		bounds.Min.Y+(bounds.Dy()-side)/2,
+	))
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	if side == 0 {
+		return dst
+	}
+	for dy := 0; dy < size; dy++ {
+		y0, y1 := crop.Min.Y+dy*side/size, crop.Min.Y+(dy+1)*side/size
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for dx := 0; dx < size; dx++ {
+			x0, x1 := crop.Min.X+dx*side/size, crop.Min.X+(dx+1)*side/size
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+			var r, g, b, a, n uint32
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					sr, sg, sb, sa := img.At(x, y).RGBA()
+					r, g, b, a, n = r+sr, g+sg, b+sb, a+sa, n+1
+				}
+			}
+			dst.SetRGBA(dx, dy, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+// ToRGBA converts img to RGBA, writing into dst when it has the same size as img (dst is
+// reallocated otherwise, or when nil). It returns the converted image, which lets callers
+// reuse a destination image across frames to avoid allocating one per frame.
+func ToRGBA(img image.Image, dst *image.RGBA) *image.RGBA {
+	bounds := img.Bounds()
+	if dst == nil || dst.Bounds().Size() != bounds.Size() {
+		dst = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	}
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
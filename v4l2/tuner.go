@@ -0,0 +1,194 @@
+package v4l2
+
+// #include <linux/videodev2.h>
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// TunerType (v4l2_tuner_type) identifies the kind of tuner
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-tuner.html#c.V4L.v4l2_tuner_type
+type TunerType = uint32
+
+const (
+	TunerTypeRadio    TunerType = C.V4L2_TUNER_RADIO
+	TunerTypeAnalogTV TunerType = C.V4L2_TUNER_ANALOG_TV
+	TunerTypeADC      TunerType = C.V4L2_TUNER_ADC
+	TunerTypeSDR      TunerType = C.V4L2_TUNER_SDR
+	TunerTypeRF       TunerType = C.V4L2_TUNER_RF
+)
+
+// TunerCapability flags of a tuner
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-tuner.html#tuner-capability
+type TunerCapability = uint32
+
+const (
+	TunerCapLow           TunerCapability = C.V4L2_TUNER_CAP_LOW
+	TunerCapNorm          TunerCapability = C.V4L2_TUNER_CAP_NORM
+	TunerCapHwSeekBounded TunerCapability = C.V4L2_TUNER_CAP_HWSEEK_BOUNDED
+	TunerCapHwSeekWrap    TunerCapability = C.V4L2_TUNER_CAP_HWSEEK_WRAP
+	TunerCapStereo        TunerCapability = C.V4L2_TUNER_CAP_STEREO
+	TunerCapLang1         TunerCapability = C.V4L2_TUNER_CAP_LANG1
+	TunerCapLang2         TunerCapability = C.V4L2_TUNER_CAP_LANG2
+	TunerCapSAP           TunerCapability = C.V4L2_TUNER_CAP_SAP
+	TunerCapRDS           TunerCapability = C.V4L2_TUNER_CAP_RDS
+	TunerCapFreqBands     TunerCapability = C.V4L2_TUNER_CAP_FREQ_BANDS
+	TunerCap1Hz           TunerCapability = C.V4L2_TUNER_CAP_1HZ
+)
+
+// TunerSubchannel flags the audio subprograms detected in the received signal
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-tuner.html#tuner-rxsubchans
+type TunerSubchannel = uint32
+
+const (
+	TunerSubMono   TunerSubchannel = C.V4L2_TUNER_SUB_MONO
+	TunerSubStereo TunerSubchannel = C.V4L2_TUNER_SUB_STEREO
+	TunerSubLang1  TunerSubchannel = C.V4L2_TUNER_SUB_LANG1
+	TunerSubLang2  TunerSubchannel = C.V4L2_TUNER_SUB_LANG2
+	TunerSubSAP    TunerSubchannel = C.V4L2_TUNER_SUB_SAP
+	TunerSubRDS    TunerSubchannel = C.V4L2_TUNER_SUB_RDS
+)
+
+// TunerAudioMode selects the audio subprogram played by the tuner
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-tuner.html#tuner-audmode
+type TunerAudioMode = uint32
+
+const (
+	TunerModeMono       TunerAudioMode = C.V4L2_TUNER_MODE_MONO
+	TunerModeStereo     TunerAudioMode = C.V4L2_TUNER_MODE_STEREO
+	TunerModeLang1      TunerAudioMode = C.V4L2_TUNER_MODE_LANG1
+	TunerModeLang2      TunerAudioMode = C.V4L2_TUNER_MODE_LANG2
+	TunerModeLang1Lang2 TunerAudioMode = C.V4L2_TUNER_MODE_LANG1_LANG2
+)
+
+// TunerInfo (v4l2_tuner) describes a tuner. RangeLow and RangeHigh are expressed in
+// frequency units, see FrequencyUnit. Signal is the signal strength (0 to 65535) and
+// AFC the automatic frequency control offset (negative when the frequency is too low).
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-tuner.html#c.V4L.v4l2_tuner
+type TunerInfo struct {
+	Index      uint32
+	Name       string
+	Type       TunerType
+	Capability TunerCapability
+	RangeLow   uint32
+	RangeHigh  uint32
+	RxSubchans TunerSubchannel
+	AudioMode  TunerAudioMode
+	Signal     int32
+	AFC        int32
+}
+
+// FrequencyUnit returns the size, in Hz, of the frequency unit used by the tuner: 62.5 kHz by
+// default, 62.5 Hz when the tuner has TunerCapLow or 1 Hz when it has TunerCap1Hz.
+func (t TunerInfo) FrequencyUnit() float64 {
+	switch {
+	case t.Capability&TunerCap1Hz != 0:
+		return 1
+	case t.Capability&TunerCapLow != 0:
+		return 62.5
+	default:
+		return 62500
+	}
+}
+
+// ToHz converts freq, expressed in the tuner frequency units, to Hz.
+func (t TunerInfo) ToHz(freq uint32) float64 {
+	return float64(freq) * t.FrequencyUnit()
+}
+
+// FromHz converts hz to the tuner frequency units, rounded to the nearest unit.
+func (t TunerInfo) FromHz(hz float64) uint32 {
+	return uint32(hz/t.FrequencyUnit() + 0.5)
+}
+
+// GetTunerInfo returns the information of the tuner at index (VIDIOC_G_TUNER)
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-tuner.html
+func GetTunerInfo(fd uintptr, index uint32) (TunerInfo, error) {
+	var tuner C.struct_v4l2_tuner
+	tuner.index = C.uint(index)
+	if err := send(fd, C.VIDIOC_G_TUNER, uintptr(unsafe.Pointer(&tuner))); err != nil {
+		return TunerInfo{}, fmt.Errorf("tuner info: index %d: %w", index, err)
+	}
+	return TunerInfo{
+		Index:      uint32(tuner.index),
+		Name:       C.GoString((*C.char)(unsafe.Pointer(&tuner.name[0]))),
+		Type:       TunerType(tuner._type),
+		Capability: TunerCapability(tuner.capability),
+		RangeLow:   uint32(tuner.rangelow),
+		RangeHigh:  uint32(tuner.rangehigh),
+		RxSubchans: TunerSubchannel(tuner.rxsubchans),
+		AudioMode:  TunerAudioMode(tuner.audmode),
+		Signal:     int32(tuner.signal),
+		AFC:        int32(tuner.afc),
+	}, nil
+}
+
+// GetAllTuners returns the tuners of the device, by iterating from index 0 until the
+// driver returns EINVAL.
+func GetAllTuners(fd uintptr) ([]TunerInfo, error) {
+	var result []TunerInfo
+	for index := uint32(0); ; index++ {
+		tuner, err := GetTunerInfo(fd, index)
+		if err != nil {
+			if errors.Is(err, ErrorBadArgument) {
+				return result, nil
+			}
+			return result, fmt.Errorf("all tuners: %w", err)
+		}
+		result = append(result, tuner)
+	}
+}
+
+// SetTunerAudioMode selects the audio mode of the tuner at index (VIDIOC_S_TUNER), the only
+// tuner attribute that can be set.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-tuner.html
+func SetTunerAudioMode(fd uintptr, index uint32, mode TunerAudioMode) error {
+	var tuner C.struct_v4l2_tuner
+	tuner.index = C.uint(index)
+	tuner.audmode = C.uint(mode)
+	if err := send(fd, C.VIDIOC_S_TUNER, uintptr(unsafe.Pointer(&tuner))); err != nil {
+		return fmt.Errorf("tuner set: index %d: %w", index, err)
+	}
+	return nil
+}
+
+// Frequency (v4l2_frequency) is the frequency, in tuner frequency units, of a tuner. Type
+// must match the type of the tuner when set.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-frequency.html#c.V4L.v4l2_frequency
+type Frequency struct {
+	Tuner     uint32
+	Type      TunerType
+	Frequency uint32
+}
+
+// GetFrequency returns the current frequency of tuner (VIDIOC_G_FREQUENCY)
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-frequency.html
+func GetFrequency(fd uintptr, tuner uint32) (Frequency, error) {
+	var freq C.struct_v4l2_frequency
+	freq.tuner = C.uint(tuner)
+	if err := send(fd, C.VIDIOC_G_FREQUENCY, uintptr(unsafe.Pointer(&freq))); err != nil {
+		return Frequency{}, fmt.Errorf("frequency get: tuner %d: %w", tuner, err)
+	}
+	return Frequency{
+		Tuner:     uint32(freq.tuner),
+		Type:      TunerType(freq._type),
+		Frequency: uint32(freq.frequency),
+	}, nil
+}
+
+// SetFrequency tunes freq.Tuner to freq.Frequency (VIDIOC_S_FREQUENCY), the driver clamps
+// the frequency to the tuner range.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-g-frequency.html
+func SetFrequency(fd uintptr, freq Frequency) error {
+	var v4l2Freq C.struct_v4l2_frequency
+	v4l2Freq.tuner = C.uint(freq.Tuner)
+	v4l2Freq._type = C.uint(freq.Type)
+	v4l2Freq.frequency = C.uint(freq.Frequency)
+	if err := send(fd, C.VIDIOC_S_FREQUENCY, uintptr(unsafe.Pointer(&v4l2Freq))); err != nil {
+		return fmt.Errorf("frequency set: tuner %d: %w", freq.Tuner, err)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package v4l2
+
+import "testing"
+
+func TestTunerFrequencyUnit(t *testing.T) {
+	tests := []struct {
+		name string
+		cap  TunerCapability
+		hz   float64
+		freq uint32
+	}{
+		{name: "tv", cap: TunerCapNorm, hz: 471_250_000, freq: 7540},
+		{name: "radio", cap: TunerCapLow | TunerCapStereo, hz: 101_100_000, freq: 1_617_600},
+		{name: "sdr", cap: TunerCap1Hz, hz: 2_048_000, freq: 2_048_000},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tuner := TunerInfo{Capability: test.cap}
+			if freq := tuner.FromHz(test.hz); freq != test.freq {
+				t.Errorf("expecting %d units, got %d", test.freq, freq)
+			}
+			if hz := tuner.ToHz(test.freq); hz != test.hz {
+				t.Errorf("expecting %v Hz, got %v", test.hz, hz)
+			}
+		})
+	}
+}
@@ -15,6 +15,16 @@ type Device interface {
 	Close() error
 }
 
+// BufferQueue is a queue of streaming buffers of a device, identified by its buffer type,
+// i.e. one of the output and capture queues of a memory-to-memory device sharing one file
+// descriptor. A StreamingDevice is the buffer queue of its buffer type.
+type BufferQueue interface {
+	Fd() uintptr
+	BufferType() BufType
+	BufferCount() uint32
+	MemIOType() IOType
+}
+
 // StreamingDevice represents device that supports streaming IO
 // via mapped buffer sharing.
 type StreamingDevice interface {
@@ -4,10 +4,9 @@ package v4l2
 import "C"
 
 import (
+	"errors"
 	"fmt"
 	"unsafe"
-
-	sys "golang.org/x/sys/unix"
 )
 
 // InputStatus
@@ -15,16 +14,36 @@ import (
 type InputStatus = uint32
 
 var (
-	InputStatusNoPower  InputStatus = C.V4L2_IN_ST_NO_POWER
-	InputStatusNoSignal InputStatus = C.V4L2_IN_ST_NO_SIGNAL
-	InputStatusNoColor  InputStatus = C.V4L2_IN_ST_NO_COLOR
+	InputStatusNoPower     InputStatus = C.V4L2_IN_ST_NO_POWER
+	InputStatusNoSignal    InputStatus = C.V4L2_IN_ST_NO_SIGNAL
+	InputStatusNoColor     InputStatus = C.V4L2_IN_ST_NO_COLOR
+	InputStatusNoHLock     InputStatus = C.V4L2_IN_ST_NO_H_LOCK
+	InputStatusNoVLock     InputStatus = C.V4L2_IN_ST_NO_V_LOCK
+	InputStatusNoStdLock   InputStatus = C.V4L2_IN_ST_NO_STD_LOCK
+	InputStatusNoSync      InputStatus = C.V4L2_IN_ST_NO_SYNC
+	InputStatusNoCarrier   InputStatus = C.V4L2_IN_ST_NO_CARRIER
+	InputStatusNoAccess    InputStatus = C.V4L2_IN_ST_NO_ACCESS
+	InputStatusHFlip       InputStatus = C.V4L2_IN_ST_HFLIP
+	InputStatusVFlip       InputStatus = C.V4L2_IN_ST_VFLIP
+	InputStatusColorKill   InputStatus = C.V4L2_IN_ST_COLOR_KILL
+	InputStatusMacrovision InputStatus = C.V4L2_IN_ST_MACROVISION
 )
 
 var InputStatuses = map[InputStatus]string{
-	0:                   "ok",
-	InputStatusNoPower:  "no power",
-	InputStatusNoSignal: "no signal",
-	InputStatusNoColor:  "no color",
+	0:                      "ok",
+	InputStatusNoPower:     "no power",
+	InputStatusNoSignal:    "no signal",
+	InputStatusNoColor:     "no color",
+	InputStatusNoHLock:     "no horizontal sync lock",
+	InputStatusNoVLock:     "no vertical sync lock",
+	InputStatusNoStdLock:   "no standard lock",
+	InputStatusNoSync:      "no sync",
+	InputStatusNoCarrier:   "no carrier",
+	InputStatusNoAccess:    "no access",
+	InputStatusHFlip:       "horizontal flip",
+	InputStatusVFlip:       "vertical flip",
+	InputStatusColorKill:   "color killer active",
+	InputStatusMacrovision: "macrovision detected",
 }
 
 type InputType = uint32
@@ -76,6 +95,12 @@ func (i InputInfo) GetCapabilities() uint32 {
 	return uint32(i.v4l2Input.capabilities)
 }
 
+// HasSignal returns true unless the input status reports no power, no signal or no sync,
+// i.e. when no cable is plugged in. The status is only meaningful for the current input.
+func (i InputInfo) HasSignal() bool {
+	return i.GetStatus()&(InputStatusNoPower|InputStatusNoSignal|InputStatusNoSync) == 0
+}
+
 // GetCurrentVideoInputIndex returns the currently selected video input index
 // See https://linuxtv.org/downloads/v4l-dvb-apis/userspace-api/v4l/vidioc-g-input.html
 func GetCurrentVideoInputIndex(fd uintptr) (int32, error) {
@@ -86,6 +111,15 @@ func GetCurrentVideoInputIndex(fd uintptr) (int32, error) {
 	return index, nil
 }
 
+// SetVideoInputIndex selects the video input with the specified index
+// See https://linuxtv.org/downloads/v4l-dvb-apis/userspace-api/v4l/vidioc-g-input.html
+func SetVideoInputIndex(fd uintptr, index int32) error {
+	if err := send(fd, C.VIDIOC_S_INPUT, uintptr(unsafe.Pointer(&index))); err != nil {
+		return fmt.Errorf("video input set: index %d: %w", index, err)
+	}
+	return nil
+}
+
 // GetVideoInputInfo returns specified input information for video device
 // See https://linuxtv.org/downloads/v4l-dvb-apis/userspace-api/v4l/vidioc-enuminput.html
 func GetVideoInputInfo(fd uintptr, index uint32) (InputInfo, error) {
@@ -105,8 +139,7 @@ func GetAllVideoInputInfo(fd uintptr) (result []InputInfo, err error) {
 		var input C.struct_v4l2_input
 		input.index = C.uint(index)
 		if err = send(fd, C.VIDIOC_ENUMINPUT, uintptr(unsafe.Pointer(&input))); err != nil {
-			errno := err.(sys.Errno)
-			if errno.Is(sys.EINVAL) && len(result) > 0 {
+			if errors.Is(err, ErrorBadArgument) && len(result) > 0 {
 				break
 			}
 			return result, fmt.Errorf("all video info: %w", err)
@@ -114,5 +147,15 @@ func GetAllVideoInputInfo(fd uintptr) (result []InputInfo, err error) {
 		result = append(result, InputInfo{v4l2Input: input})
 		index++
 	}
-	return result, err
+	return result, nil
+}
+
+// LogStatus asks the driver to log its status (VIDIOC_LOG_STATUS) to the kernel log,
+// where it can be read with dmesg. Drivers that do not implement it return ErrorUnsupported.
+// See https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/vidioc-log-status.html
+func LogStatus(fd uintptr) error {
+	if err := send(fd, C.VIDIOC_LOG_STATUS, 0); err != nil {
+		return fmt.Errorf("log status: %w", err)
+	}
+	return nil
 }